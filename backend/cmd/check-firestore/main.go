@@ -2,14 +2,29 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
+	"kareru-backend/internal/infrastructure/diagnostics"
 	"kareru-backend/internal/infrastructure/firestore"
 	"kareru-backend/internal/infrastructure/repository"
 )
 
 func main() {
+	pageSize := flag.Int("page-size", 100, "1回の取得で読み込むドキュメント数")
+	limit := flag.Int("limit", 0, "診断するドキュメントの最大数 (0は無制限)")
+	issuesOnly := flag.Bool("issues-only", false, "問題のあるドキュメントのみ表示する")
+	jsonOutput := flag.Bool("json", false, "結果をJSONで出力する")
+	flag.Parse()
+
+	if *pageSize <= 0 {
+		log.Fatalf("-page-size は1以上を指定してください")
+	}
+
 	ctx := context.Background()
 
 	client, err := firestore.NewClient(ctx)
@@ -20,17 +35,85 @@ func main() {
 
 	repo := repository.NewScheduleRepository(client)
 
-	// スケジュール一覧を取得
-	fmt.Println("=== Firestoreエミュレータのデータ確認 ===")
+	report, err := inspect(ctx, repo, *pageSize, *limit, time.Now())
+	if err != nil {
+		log.Fatalf("スケジュールの取得に失敗: %v", err)
+	}
+
+	if *issuesOnly {
+		filtered := report.Documents[:0]
+		for _, doc := range report.Documents {
+			if len(doc.Issues) > 0 {
+				filtered = append(filtered, doc)
+			}
+		}
+		report.Documents = filtered
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("JSON出力に失敗: %v", err)
+		}
+	} else {
+		printReport(client.ProjectID(), report)
+	}
+
+	if report.Summary.Invalid > 0 {
+		os.Exit(1)
+	}
+}
+
+// inspect はページ単位でドキュメントを読み込みながら診断する
+func inspect(ctx context.Context, repo *repository.ScheduleRepository, pageSize, limit int, now time.Time) (*diagnostics.Report, error) {
+	report := &diagnostics.Report{Documents: []diagnostics.DocumentReport{}}
 
-	scheduleIDs := []string{"sample-schedule-1", "sample-schedule-2"}
+	startAfter := ""
+	for {
+		size := pageSize
+		if limit > 0 && limit-report.Summary.Total < size {
+			size = limit - report.Summary.Total
+		}
+		if size <= 0 {
+			break
+		}
 
-	for _, id := range scheduleIDs {
-		schedule, err := repo.GetByID(ctx, id)
+		docs, err := repo.ListDocuments(ctx, size, startAfter)
 		if err != nil {
-			fmt.Printf("❌ %s: 取得失敗 (%v)\n", id, err)
-		} else {
-			fmt.Printf("✅ %s: %s\n", schedule.ID, schedule.Comment)
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			report.Add(diagnostics.InspectDocument(doc.ID, doc.Data, now))
+		}
+
+		if len(docs) < size {
+			break
 		}
+		startAfter = docs[len(docs)-1].ID
 	}
+
+	return report, nil
+}
+
+func printReport(projectID string, report *diagnostics.Report) {
+	fmt.Printf("=== Firestore診断 (project: %s) ===\n", projectID)
+
+	for _, doc := range report.Documents {
+		switch doc.Status {
+		case diagnostics.StatusActive:
+			fmt.Printf("✅ %s: %s (%d枠)\n", doc.ID, doc.Comment, doc.TimeSlotCount)
+		case diagnostics.StatusExpired:
+			fmt.Printf("⌛ %s: %s (%d枠, 期限切れ)\n", doc.ID, doc.Comment, doc.TimeSlotCount)
+		default:
+			fmt.Printf("❌ %s: %s\n", doc.ID, doc.Comment)
+			for _, issue := range doc.Issues {
+				fmt.Printf("    - %s: %s\n", issue.Field, issue.Message)
+			}
+		}
+	}
+
+	fmt.Printf("\n合計: %d件 (有効: %d件, 期限切れ: %d件, 不正: %d件)\n",
+		report.Summary.Total, report.Summary.Active, report.Summary.Expired, report.Summary.Invalid)
 }
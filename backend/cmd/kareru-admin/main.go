@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"kareru-backend/internal/infrastructure/backup"
+	"kareru-backend/internal/infrastructure/storage"
+)
+
+const usage = `使い方: kareru-admin <command> [options]
+
+コマンド:
+  export  全てのスケジュールをNDJSONで書き出す
+  import  NDJSONのスケジュールを読み込んで保存する
+
+各コマンドのオプションは kareru-admin <command> -h で確認できます`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Println(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "不明なコマンド: %s\n\n%s\n", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("%sに失敗: %v", os.Args[1], err)
+	}
+}
+
+// filterFlags は作成日時による絞り込みフラグ
+type filterFlags struct {
+	createdAfter  string
+	createdBefore string
+}
+
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.createdAfter, "created-after", "", "この日時以降に作成されたスケジュールのみ対象にする (RFC3339 または YYYY-MM-DD)")
+	fs.StringVar(&f.createdBefore, "created-before", "", "この日時より前に作成されたスケジュールのみ対象にする (RFC3339 または YYYY-MM-DD)")
+}
+
+func (f *filterFlags) filter() (backup.Filter, error) {
+	var filter backup.Filter
+	var err error
+
+	if filter.CreatedAfter, err = parseTime(f.createdAfter); err != nil {
+		return filter, fmt.Errorf("invalid -created-after: %w", err)
+	}
+	if filter.CreatedBefore, err = parseTime(f.createdBefore); err != nil {
+		return filter, fmt.Errorf("invalid -created-before: %w", err)
+	}
+	return filter, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	backend := fs.String("backend", storage.BackendFirestore, "エクスポート元のストレージ (firestore, redis)")
+	output := fs.String("o", "", "出力ファイル (省略時は標準出力)")
+	var filters filterFlags
+	filters.register(fs)
+	fs.Parse(args)
+
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.Open(ctx, *backend)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	result, err := backup.Export(ctx, store, w, filter)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d件のスケジュールをエクスポートしました（対象外: %d件）", result.Processed, result.Skipped)
+	return nil
+}
+
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	backend := fs.String("backend", storage.BackendFirestore, "インポート先のストレージ (firestore, redis)")
+	input := fs.String("i", "", "入力ファイル (省略時は標準入力)")
+	dryRun := fs.Bool("dry-run", false, "読み込みと検証のみ行い保存しない")
+	var filters filterFlags
+	filters.register(fs)
+	fs.Parse(args)
+
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	// dry-runでは保存先に接続しない
+	var dst backup.Destination
+	if !*dryRun {
+		store, err := storage.Open(ctx, *backend)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		dst = store
+	}
+
+	result, err := backup.Import(ctx, r, dst, backup.ImportOptions{
+		Filter: filter,
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		log.Printf("[dry-run] %d件のスケジュールをインポート可能です（対象外: %d件）", result.Processed, result.Skipped)
+		return nil
+	}
+	log.Printf("%d件のスケジュールをインポートしました（対象外: %d件）", result.Processed, result.Skipped)
+	return nil
+}
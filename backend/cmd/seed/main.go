@@ -2,26 +2,88 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"log"
 	"os"
+	"time"
 
-	"kareru-backend/internal/infrastructure/firestore"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/infrastructure/backup"
 	"kareru-backend/internal/infrastructure/seed"
+	"kareru-backend/internal/infrastructure/storage"
 )
 
 func main() {
+	defaults := seed.DefaultOptions()
+
+	backend := flag.String("backend", storage.BackendFirestore, "投入先のストレージ (memory, firestore, redis)")
+	count := flag.Int("count", defaults.Count, "生成するスケジュール数")
+	from := flag.String("from", defaults.From.Format("2006-01-02"), "タイムスロットを配置する開始日 (YYYY-MM-DD)")
+	days := flag.Int("days", defaults.Days, "タイムスロットを配置する日数")
+	interval := flag.Duration("interval", defaults.Interval, "タイムスロットの刻み (30m, 1h, 3h, 24h)")
+	density := flag.Float64("density", defaults.Density, "営業時間内の各枠をタイムスロットにする確率 (0〜1)")
+	expiredRatio := flag.Float64("expired-ratio", defaults.ExpiredRatio, "失効済みスケジュールの割合 (0〜1)")
+	randomSeed := flag.Int64("seed", defaults.Seed, "乱数のシード (同じ値なら同じデータを生成)")
+	output := flag.String("o", "", "生成したスケジュールをNDJSONで書き出すファイル (kareru-admin importで読み込み可能)")
+	flag.Parse()
+
+	fromDate, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+	if err != nil {
+		log.Fatalf("-from の形式が不正です: %v", err)
+	}
+
+	opts := seed.Options{
+		Count:        *count,
+		From:         fromDate,
+		Days:         *days,
+		Interval:     *interval,
+		Density:      *density,
+		ExpiredRatio: *expiredRatio,
+		Seed:         *randomSeed,
+		Now:          defaults.Now,
+	}
+	if err := opts.Validate(); err != nil {
+		log.Fatalf("オプションが不正です: %v", err)
+	}
+
 	ctx := context.Background()
 
-	client, err := firestore.NewClient(ctx)
+	store, err := storage.Open(ctx, *backend)
 	if err != nil {
-		log.Fatalf("Firestoreクライアント作成に失敗: %v", err)
+		log.Fatalf("ストレージへの接続に失敗: %v", err)
 	}
-	defer client.Close()
+	defer store.Close()
 
-	if err := seed.SeedSchedules(ctx, client); err != nil {
+	schedules, err := seed.SeedSchedules(ctx, store, opts)
+	if err != nil {
 		log.Fatalf("シードデータ投入に失敗: %v", err)
 	}
 
-	log.Println("シードデータの投入が完了しました")
-	os.Exit(0)
+	log.Printf("%d件のシードデータの投入が完了しました", len(schedules))
+
+	if *output != "" {
+		if err := writeSchedules(*output, schedules); err != nil {
+			log.Fatalf("生成データの書き出しに失敗: %v", err)
+		}
+		log.Printf("生成データを %s に書き出しました", *output)
+	} else if *backend == storage.BackendMemory {
+		log.Println("memoryバックエンドはプロセス終了時に破棄されます（-o で生成データを書き出せます）")
+	}
+}
+
+func writeSchedules(path string, schedules []*model.Schedule) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, schedule := range schedules {
+		if err := encoder.Encode(backup.NewRecord(schedule)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -1,40 +1,442 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/config"
 	"kareru-backend/internal/handlers"
+	"kareru-backend/internal/infrastructure/firestore"
+	"kareru-backend/internal/infrastructure/redis"
 	"kareru-backend/internal/infrastructure/repository"
+	"kareru-backend/internal/lockout"
+	"kareru-backend/internal/logging"
+	"kareru-backend/internal/mail"
+	"kareru-backend/internal/metrics"
+	"kareru-backend/internal/qr"
+	"kareru-backend/internal/ratelimit"
+	"kareru-backend/internal/reminder"
 	"kareru-backend/internal/routes"
+	"kareru-backend/internal/security"
+	"kareru-backend/internal/server"
+	"kareru-backend/internal/tracing"
+	"kareru-backend/internal/webhook"
 )
 
 func main() {
+	// 設定の読み込み（不正な設定はここで終了する）
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	// 標準のlogパッケージの出力も含め、構造化ログ（JSON）に統一する
+	logger := logging.New(os.Stdout, logging.ParseLevel(cfg.Log.Level))
+	slog.SetDefault(logger)
+
+	// SIGTERM（Cloud Run/Kubernetesの停止）とSIGINT（Ctrl+C）でシャットダウンを開始する
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// トレース（エクスポーターがnoneの場合はスパンを記録しない）
+	tracerProvider, shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	tracingEnabled := cfg.Tracing.Exporter != config.TracingExporterNone
+
 	// Ginルーターの初期化
-	r := gin.Default()
+	// gin.Defaultのロガーは生のパス（編集トークンを含む）を出力するため使わない
+	r := gin.New()
+	// X-Forwarded-Forは信頼するプロキシから受け取った場合のみクライアントIPとして使う
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	r.Use(logging.RequestID(), logging.Middleware(logger), logging.Recovery(logger))
+	if tracingEnabled {
+		r.Use(tracing.Middleware(tracerProvider))
+	}
 
-	// CORS設定
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true // 開発時のテスト用
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.AllowCredentials = true
-	r.Use(cors.New(config))
+	// セキュリティヘッダー（CORSで拒否されたレスポンスやエラーレスポンスにも付与する）
+	r.Use(security.Headers(security.HeadersConfig{
+		HSTSMaxAge:            cfg.Security.HSTSMaxAge,
+		HSTSIncludeSubdomains: cfg.Security.HSTSIncludeSubdomains,
+	}))
+
+	// CORS設定（オリジンが指定されていない場合はクロスオリジンのリクエストを許可しない）
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		r.Use(cors.New(corsConfig(cfg.CORS)))
+	}
+
+	// メトリクス（無効な場合はnil）
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+		r.Use(m.Middleware())
+	}
 
 	// リポジトリとハンドラーの初期化
-	// 注: 実際の実装では、Firestoreクライアントなどを使用します
-	// ここでは簡単なメモリ実装を使用
-	scheduleRepo := repository.NewMemoryScheduleRepository()
-	scheduleHandler := handlers.NewScheduleHandler(scheduleRepo)
+	scheduleRepo, backendRepo, closeRepo, err := newScheduleRepository(ctx, cfg.Storage, m)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", cfg.Storage.Backend, err)
+	}
+	if tracingEnabled {
+		// キャッシュを含めたリポジトリ全体の所要時間をリクエストのスパンの子として記録する
+		scheduleRepo = tracing.NewTracedScheduleRepository(scheduleRepo, cfg.Storage.Backend, tracerProvider)
+	}
+
+	// 誤り訂正レベルは設定の検証で確認済み
+	qrLevel, _ := qr.ParseLevel(cfg.Share.QR.ErrorCorrection)
+	scheduleHandler := handlers.NewScheduleHandlerWithConfig(scheduleRepo, handlers.ScheduleHandlerConfig{
+		Expiry:           cfg.Schedule.Expiry,
+		MaxTimeSlots:     cfg.Schedule.MaxTimeSlots,
+		MaxCommentLength: cfg.Schedule.MaxCommentLength,
+		ViewTokenTTL:     cfg.Security.ViewTokenTTL,
+		PublicBaseURL:    cfg.Share.PublicBaseURL,
+		ShortLinkBaseURL: cfg.Share.ShortLinkBaseURL,
+		QRDefaultSize:    cfg.Share.QR.DefaultSize,
+		QRMaxSize:        cfg.Share.QR.MaxSize,
+		QRLevel:          qrLevel,
+		WebhooksEnabled:  cfg.Webhook.Enabled,
+		EmailEnabled:     cfg.Mail.Enabled,
+	})
+	if cfg.Security.ViewTokenSecret != "" {
+		scheduleHandler.SetViewTokenKey([]byte(cfg.Security.ViewTokenSecret))
+	} else if cfg.Environment == config.EnvironmentProduction {
+		slog.Warn("security.viewTokenSecret is not set; view tokens are signed with a per-process key and will not survive restarts or work across instances")
+	}
+	if cfg.Share.PublicBaseURL == "" {
+		slog.Warn("share.publicBaseURL is not set; QR code and short link endpoints will respond with 503")
+	}
+	if m != nil {
+		scheduleHandler.SetMetrics(m)
+	}
+
+	// 編集トークンの総当たり対策（失敗が続いたスケジュールを一時的にロックする）
+	closeLockout := func() error { return nil }
+	if cfg.Lockout.Enabled {
+		lockout, closeFn, err := newEditLockout(ctx, cfg)
+		if err != nil {
+			log.Fatalf("Failed to initialize lockout store: %v", err)
+		}
+		scheduleHandler.SetEditLockout(lockout)
+		closeLockout = closeFn
+	}
+
+	// Webhook（作成者が登録したURLにスケジュールのイベントを配信する）
+	var notifiers handlers.ScheduleNotifiers
+	shutdownWebhooks := func(context.Context) error { return nil }
+	if cfg.Webhook.Enabled {
+		dispatcher := webhook.NewDispatcher(webhookOptions(cfg.Webhook))
+		dispatcher.Start()
+		if m != nil {
+			m.RegisterWebhookDispatcher(dispatcher)
+		}
+		notifiers = append(notifiers, webhook.NewNotifier(dispatcher))
+		shutdownWebhooks = dispatcher.Shutdown
+	}
+
+	// メール（作成者に共有URLと編集URL、失効前のリマインダーを送る）
+	shutdownMail := func(context.Context) error { return nil }
+	if cfg.Mail.Enabled {
+		mailNotifier, err := newMailNotifier(cfg.Mail, cfg.Reminder.Enabled, scheduleHandler)
+		if err != nil {
+			log.Fatalf("Failed to initialize mailer: %v", err)
+		}
+		mailNotifier.Start()
+		notifiers = append(notifiers, mailNotifier)
+		shutdownMail = mailNotifier.Shutdown
+	}
+	if len(notifiers) > 0 {
+		scheduleHandler.SetNotifier(notifiers)
+	}
+
+	// 失効が近づいたスケジュールの通知（通知先がない場合は動かさない）
+	stopReminder := func(context.Context) error { return nil }
+	if cfg.Reminder.Enabled && len(notifiers) > 0 {
+		stopReminder = startReminder(reminder.NewScanner(backendRepo, notifiers, cfg.Reminder.Lead, cfg.Reminder.Interval))
+	} else if cfg.Mail.Enabled {
+		slog.Info("reminder.enabled is not set; expiry reminder emails will not be sent")
+	}
+
+	// readinessでは設定されたストレージへの疎通を確認する
+	healthHandler := handlers.NewHealthHandler(cfg.Storage.Timeout)
+	if pinger, ok := scheduleRepo.(handlers.Pinger); ok {
+		healthHandler.AddCheck(cfg.Storage.Backend, pinger)
+	}
 
 	// ルートの設定
-	routes.SetupRoutes(r, scheduleHandler)
+	routeOpts := routes.RouteOptions{}
+	closeRateLimit := func() error { return nil }
+	if cfg.RateLimit.Enabled {
+		store, closeStore, err := newRateLimitStore(ctx, cfg)
+		if err != nil {
+			log.Fatalf("Failed to initialize rate limit store: %v", err)
+		}
+		routeOpts = rateLimitRoutes(store, cfg.RateLimit)
+		closeRateLimit = closeStore
+	}
+	routeOpts.DisableLegacyEditRoutes = !cfg.Schedule.LegacyEditRoutes
+	routes.SetupRoutesWithOptions(r, scheduleHandler, routeOpts)
+	routes.SetupHealthRoutes(r, healthHandler)
 
-	log.Println("Server starting on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	// メトリクスは公開用のルーターに載せず、管理用のアドレスで別に待ち受ける
+	shutdownMetrics := func(context.Context) error { return nil }
+	if m != nil {
+		ln, err := net.Listen("tcp", cfg.Metrics.Addr)
+		if err != nil {
+			log.Fatalf("Failed to start metrics server: %v", err)
+		}
+		shutdownMetrics = serveMetrics(ln, m.Handler(), logger)
+	}
+
+	// HTTPサーバーの停止後、登録と逆順に後処理が実行される
+	// （バックグラウンドワーカーはストレージより後に登録し、先に停止させる）
+	srv := server.New(r, cfg.Server)
+	srv.OnDrain(healthHandler.SetDraining)
+	srv.OnShutdown("tracing", shutdownTracing)
+	srv.OnShutdown("metrics server", shutdownMetrics)
+	srv.OnShutdown(cfg.Storage.Backend+" storage", func(context.Context) error {
+		return closeRepo()
+	})
+	srv.OnShutdown("rate limit store", func(context.Context) error {
+		return closeRateLimit()
+	})
+	srv.OnShutdown("lockout store", func(context.Context) error {
+		return closeLockout()
+	})
+	// リマインダーが配信を依頼しなくなってから、配信待ちのWebhookとメールを送り切る
+	srv.OnShutdown("webhook dispatcher", shutdownWebhooks)
+	srv.OnShutdown("mail", shutdownMail)
+	srv.OnShutdown("reminder", stopReminder)
+
+	log.Printf("Server starting on %s (environment: %s, storage: %s)", cfg.Server.Addr, cfg.Environment, cfg.Storage.Backend)
+	if err := srv.Run(ctx); err != nil {
+		log.Fatal("Server stopped with error: ", err)
+	}
+	log.Println("Server stopped")
+}
+
+// serveMetrics はlnでメトリクスだけを返すサーバーを起動し、停止する関数を返す
+func serveMetrics(ln net.Listener, handler http.Handler, logger *slog.Logger) func(context.Context) error {
+	router := gin.New()
+	router.Use(logging.Recovery(logger))
+	routes.SetupMetricsRoutes(router, handler)
+	srv := &http.Server{
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server stopped", slog.String("error", err.Error()))
+		}
+	}()
+	slog.Info("metrics server listening", slog.String("addr", ln.Addr().String()))
+	return srv.Shutdown
+}
+
+// corsConfig は設定からCORSミドルウェアの設定を作成する
+func corsConfig(cfg config.CORSConfig) cors.Config {
+	corsCfg := cors.DefaultConfig()
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			corsCfg.AllowAllOrigins = true
+		}
+	}
+	if !corsCfg.AllowAllOrigins {
+		corsCfg.AllowOrigins = cfg.AllowedOrigins
+	}
+	corsCfg.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	corsCfg.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "traceparent", "tracestate", logging.RequestIDHeader, handlers.EditTokenHeader, handlers.ViewTokenHeader}
+	corsCfg.ExposeHeaders = []string{logging.RequestIDHeader, "Deprecation"}
+	corsCfg.AllowCredentials = cfg.AllowCredentials
+	return corsCfg
+}
+
+// activeSchedulesRefresh は有効なスケジュール数を数え直す間隔
+const activeSchedulesRefresh = 30 * time.Second
+
+// newScheduleRepository は設定されたバックエンドのリポジトリを作成する
+// mがnilでなければリポジトリの操作を計測する（キャッシュより内側で計測し、バックエンド自体の性能を記録する）
+// 全件の走査やリマインダーの検索はキャッシュや計測を通さず、バックエンドのリポジトリで直接行う
+// 戻り値の関数でクライアントの接続を閉じる
+func newScheduleRepository(ctx context.Context, cfg config.StorageConfig, m *metrics.Metrics) (handlers.ScheduleRepository, scheduleBackend, func() error, error) {
+	var repo interface {
+		handlers.ScheduleRepository
+		scheduleBackend
+	}
+	closeFn := func() error { return nil }
+
+	switch cfg.Backend {
+	case config.StorageFirestore:
+		client, err := firestore.NewClientFromConfig(ctx, firestore.Config{
+			ProjectID:    cfg.Firestore.ProjectID,
+			EmulatorHost: cfg.Firestore.EmulatorHost,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		repo = repository.NewFirestoreScheduleAdapter(repository.NewScheduleRepository(client), cfg.Timeout)
+		closeFn = client.Close
+	case config.StorageRedis:
+		client, err := redis.NewClientFromConfig(ctx, redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		repo = repository.NewRedisScheduleRepository(client)
+		closeFn = client.Close
+	default:
+		repo = repository.NewMemoryScheduleRepository()
+	}
+
+	var wrapped handlers.ScheduleRepository = repo
+	if m != nil {
+		m.RegisterActiveSchedules(repo, activeSchedulesRefresh)
+		wrapped = m.InstrumentRepository(cfg.Backend, wrapped)
+	}
+
+	if cfg.Cache.Enabled {
+		wrapped = repository.NewCachedScheduleRepository(wrapped, repository.CacheOptions{
+			Capacity:    cfg.Cache.Capacity,
+			TTL:         cfg.Cache.TTL,
+			NegativeTTL: cfg.Cache.NegativeTTL,
+		})
+	}
+
+	return wrapped, repo, closeFn, nil
+}
+
+// scheduleBackend はキャッシュや計測を通さずにバックエンドのリポジトリへ直接行う操作
+type scheduleBackend interface {
+	metrics.ScheduleLister
+	reminder.Store
+}
+
+// webhookOptions は設定からWebhookのディスパッチャーの設定を作成する
+func webhookOptions(cfg config.WebhookConfig) webhook.Options {
+	return webhook.Options{
+		Workers:              cfg.Workers,
+		QueueSize:            cfg.QueueSize,
+		MaxAttempts:          cfg.MaxAttempts,
+		InitialBackoff:       cfg.InitialBackoff,
+		MaxBackoff:           cfg.MaxBackoff,
+		Timeout:              cfg.Timeout,
+		MaxDeadLetters:       cfg.MaxDeadLetters,
+		AllowPrivateNetworks: cfg.AllowPrivateNetworks,
+	}
+}
+
+// newMailNotifier は設定されたSMTPサーバーでメールを送るNotifierを作成する
+// メールに載せるURLはハンドラーと同じ共有URLの基点から作る
+// remindersが無効の場合、作成時のメールでリマインダーを予告しない
+func newMailNotifier(cfg config.MailConfig, reminders bool, scheduleHandler *handlers.ScheduleHandler) (*mail.Notifier, error) {
+	mailer, err := mail.NewSMTPMailer(mail.SMTPConfig{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
+		From:     cfg.From,
+		TLS:      cfg.SMTP.TLS,
+		Timeout:  cfg.SMTP.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	links := mail.Links{
+		Share: scheduleHandler.ShareURL,
+		Edit:  scheduleHandler.EditURL,
+	}
+	return mail.NewNotifier(mailer, links, mail.Options{
+		QueueSize:   cfg.QueueSize,
+		MaxAttempts: cfg.MaxAttempts,
+		RetryDelay:  cfg.RetryDelay,
+		Reminders:   reminders,
+	}), nil
+}
+
+// startReminder はリマインダーをバックグラウンドで動かし、停止する関数を返す
+// 停止する関数は実行中の確認が終わるまで（ctxが終了した場合はそこまで）待つ
+func startReminder(scanner *reminder.Scanner) func(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner.Run(ctx)
+	}()
+	return func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	}
+}
+
+// newRateLimitStore はレート制限のストアを作成する
+// redisの場合はstorage.redisの接続先を使い、インスタンス間で予算を共有する
+func newRateLimitStore(ctx context.Context, cfg *config.Config) (ratelimit.Store, func() error, error) {
+	if cfg.RateLimit.Store != config.RateLimitStoreRedis {
+		return ratelimit.NewMemoryStore(), func() error { return nil }, nil
+	}
+	client, err := redis.NewClientFromConfig(ctx, redis.Config{
+		Addr:     cfg.Storage.Redis.Addr,
+		Password: cfg.Storage.Redis.Password,
+		DB:       cfg.Storage.Redis.DB,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ratelimit.NewRedisStore(client), client.Close, nil
+}
+
+// newEditLockout は編集トークンの検証失敗を記録するストアを作成する
+// redisの場合はstorage.redisの接続先を使い、インスタンス間で失敗回数を共有する
+func newEditLockout(ctx context.Context, cfg *config.Config) (handlers.EditLockout, func() error, error) {
+	policy := lockout.Policy{
+		MaxAttempts: cfg.Lockout.MaxAttempts,
+		BaseDelay:   cfg.Lockout.BaseDelay,
+		MaxDelay:    cfg.Lockout.MaxDelay,
+		ResetAfter:  cfg.Lockout.ResetAfter,
+	}
+	if cfg.Lockout.Store != config.LockoutStoreRedis {
+		return lockout.NewMemoryTracker(policy), func() error { return nil }, nil
+	}
+	client, err := redis.NewClientFromConfig(ctx, redis.Config{
+		Addr:     cfg.Storage.Redis.Addr,
+		Password: cfg.Storage.Redis.Password,
+		DB:       cfg.Storage.Redis.DB,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return lockout.NewRedisTracker(client, policy), client.Close, nil
+}
+
+// rateLimitRoutes は作成・閲覧・編集の予算ごとのミドルウェアを返す
+func rateLimitRoutes(store ratelimit.Store, cfg config.RateLimitConfig) routes.RouteOptions {
+	middleware := func(budget string, b config.RateLimitBudget) []gin.HandlerFunc {
+		return []gin.HandlerFunc{ratelimit.Middleware(store, budget, ratelimit.PerMinute(b.PerMinute, b.Burst))}
+	}
+	return routes.RouteOptions{
+		Create: middleware(ratelimit.BudgetCreate, cfg.Create),
+		Read:   middleware(ratelimit.BudgetRead, cfg.Read),
+		Edit:   middleware(ratelimit.BudgetEdit, cfg.Edit),
 	}
 }
 
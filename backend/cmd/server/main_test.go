@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/config"
+	"kareru-backend/internal/infrastructure/repository"
+	"kareru-backend/internal/metrics"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -21,3 +30,88 @@ func TestHealthCheck(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "ok")
 }
+
+func TestNewScheduleRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("memoryバックエンドでキャッシュを有効にできる", func(t *testing.T) {
+		cfg := config.Default().Storage
+		cfg.Cache.Enabled = true
+
+		repo, lister, closeRepo, err := newScheduleRepository(ctx, cfg, nil)
+		require.NoError(t, err)
+		defer closeRepo()
+		assert.IsType(t, &repository.CachedScheduleRepository{}, repo)
+		// 全件の走査はキャッシュを通さない
+		assert.IsType(t, &repository.MemoryScheduleRepository{}, lister)
+	})
+
+	t.Run("メトリクスが有効な場合はリポジトリの操作を計測する", func(t *testing.T) {
+		cfg := config.Default().Storage
+
+		repo, _, closeRepo, err := newScheduleRepository(ctx, cfg, metrics.New())
+		require.NoError(t, err)
+		defer closeRepo()
+		assert.IsType(t, &metrics.InstrumentedScheduleRepository{}, repo)
+	})
+
+	t.Run("redisバックエンドに接続できる", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cfg := config.Default().Storage
+		cfg.Backend = config.StorageRedis
+		cfg.Redis.Addr = mr.Addr()
+
+		repo, _, closeRepo, err := newScheduleRepository(ctx, cfg, nil)
+		require.NoError(t, err)
+		defer closeRepo()
+		assert.IsType(t, &repository.RedisScheduleRepository{}, repo)
+	})
+
+	t.Run("redisに接続できない場合はエラーになる", func(t *testing.T) {
+		cfg := config.Default().Storage
+		cfg.Backend = config.StorageRedis
+		cfg.Redis.Addr = "127.0.0.1:1"
+
+		_, _, _, err := newScheduleRepository(ctx, cfg, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestServeMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	shutdown := serveMetrics(ln, metrics.New().Handler(), slog.Default())
+	defer shutdown(context.Background())
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "kareru_")
+
+	// メトリクス以外のルートは持たない
+	resp, err = http.Get("http://" + ln.Addr().String() + "/api/v1/schedules/uuid")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.NoError(t, shutdown(context.Background()))
+	_, err = http.Get("http://" + ln.Addr().String() + "/metrics")
+	assert.Error(t, err)
+}
+
+func TestCorsConfig(t *testing.T) {
+	t.Run("*を指定すると全てのオリジンを許可する", func(t *testing.T) {
+		corsCfg := corsConfig(config.CORSConfig{AllowedOrigins: []string{"*"}})
+		assert.True(t, corsCfg.AllowAllOrigins)
+		assert.Empty(t, corsCfg.AllowOrigins)
+	})
+
+	t.Run("指定したオリジンのみ許可する", func(t *testing.T) {
+		corsCfg := corsConfig(config.CORSConfig{AllowedOrigins: []string{"https://kareru.example.com"}})
+		assert.False(t, corsCfg.AllowAllOrigins)
+		assert.Equal(t, []string{"https://kareru.example.com"}, corsCfg.AllowOrigins)
+	})
+}
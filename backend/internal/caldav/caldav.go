@@ -0,0 +1,371 @@
+// Package caldav は読み取り専用のCalDAV（RFC 4791）で使うXMLのリクエストとレスポンスを扱う
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// XMLの名前空間
+const (
+	NamespaceDAV            = "DAV:"
+	NamespaceCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// ContentType はXMLのレスポンスに指定するContent-Type
+const ContentType = "application/xml; charset=utf-8"
+
+var (
+	// ErrInvalidRequest はリクエストのXMLが解釈できない場合に返される
+	ErrInvalidRequest = errors.New("caldav: invalid request")
+	// ErrUnsupportedReport は対応していないREPORTの場合に返される
+	ErrUnsupportedReport = errors.New("caldav: unsupported report")
+)
+
+// DAV はDAV:名前空間の要素名を返す
+func DAV(local string) xml.Name {
+	return xml.Name{Space: NamespaceDAV, Local: local}
+}
+
+// CalDAV はCalDAVの名前空間の要素名を返す
+func CalDAV(local string) xml.Name {
+	return xml.Name{Space: NamespaceCalDAV, Local: local}
+}
+
+// CalendarServer はCalendarServerの拡張（getctagなど）の名前空間の要素名を返す
+func CalendarServer(local string) xml.Name {
+	return xml.Name{Space: NamespaceCalendarServer, Local: local}
+}
+
+// Propfind はPROPFINDのリクエスト
+// 本文が空の場合はallpropとして扱う（RFC 4918 9.1）
+type Propfind struct {
+	AllProp  bool
+	PropName bool
+	Props    []xml.Name
+}
+
+type propfindXML struct {
+	XMLName  xml.Name  `xml:"DAV: propfind"`
+	AllProp  *struct{} `xml:"DAV: allprop"`
+	PropName *struct{} `xml:"DAV: propname"`
+	Prop     *propXML  `xml:"DAV: prop"`
+}
+
+type propXML struct {
+	Names []nameXML `xml:",any"`
+}
+
+type nameXML struct {
+	XMLName xml.Name
+}
+
+func (p *propXML) names() []xml.Name {
+	if p == nil {
+		return nil
+	}
+	names := make([]xml.Name, len(p.Names))
+	for i, n := range p.Names {
+		names[i] = n.XMLName
+	}
+	return names
+}
+
+// ParsePropfind はPROPFINDの本文を解釈する
+func ParsePropfind(r io.Reader) (*Propfind, error) {
+	var v propfindXML
+	if err := xml.NewDecoder(r).Decode(&v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &Propfind{AllProp: true}, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	propfind := &Propfind{
+		AllProp:  v.AllProp != nil,
+		PropName: v.PropName != nil,
+		Props:    v.Prop.names(),
+	}
+	if !propfind.AllProp && !propfind.PropName && v.Prop == nil {
+		return nil, fmt.Errorf("%w: propfind needs allprop, propname or prop", ErrInvalidRequest)
+	}
+	return propfind, nil
+}
+
+// ReportType はREPORTの種類
+type ReportType int
+
+const (
+	// ReportFreeBusyQuery は空き時間の問い合わせ（RFC 4791 7.10）
+	ReportFreeBusyQuery ReportType = iota + 1
+	// ReportCalendarQuery は条件に合うカレンダーオブジェクトの問い合わせ（RFC 4791 7.8）
+	ReportCalendarQuery
+	// ReportCalendarMultiget はhrefを指定したカレンダーオブジェクトの取得（RFC 4791 7.9）
+	ReportCalendarMultiget
+)
+
+// TimeRange はtime-range要素の期間
+// StartやEndがゼロ値の場合はその方向に制限がない
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Overlaps は[start, end)の時間帯が期間と重なるかを判定する
+func (tr *TimeRange) Overlaps(start, end time.Time) bool {
+	if tr == nil {
+		return true
+	}
+	return (tr.End.IsZero() || start.Before(tr.End)) && (tr.Start.IsZero() || end.After(tr.Start))
+}
+
+// Report はREPORTのリクエスト
+type Report struct {
+	Type ReportType
+	// TimeRange はfree-busy-queryの期間、またはcalendar-queryのVEVENTに対する期間の条件
+	TimeRange *TimeRange
+	// Props はcalendar-queryとcalendar-multigetで取得するプロパティ
+	Props []xml.Name
+	// Hrefs はcalendar-multigetで指定されたhref
+	Hrefs []string
+}
+
+type timeRangeXML struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+type freeBusyQueryXML struct {
+	TimeRange *timeRangeXML `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+}
+
+type compFilterXML struct {
+	Name        string          `xml:"name,attr"`
+	TimeRange   *timeRangeXML   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	CompFilters []compFilterXML `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type calendarQueryXML struct {
+	Prop   *propXML `xml:"DAV: prop"`
+	Filter struct {
+		CompFilters []compFilterXML `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type calendarMultigetXML struct {
+	Prop  *propXML `xml:"DAV: prop"`
+	Hrefs []string `xml:"DAV: href"`
+}
+
+// ParseReport はREPORTの本文を解釈する
+// free-busy-query・calendar-query・calendar-multiget以外はErrUnsupportedReportを返す
+func ParseReport(r io.Reader) (*Report, error) {
+	decoder := xml.NewDecoder(r)
+	var root xml.StartElement
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root = start
+			break
+		}
+	}
+
+	report := &Report{}
+	var err error
+	switch root.Name {
+	case CalDAV("free-busy-query"):
+		var v freeBusyQueryXML
+		if err := decoder.DecodeElement(&v, &root); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		if v.TimeRange == nil {
+			return nil, fmt.Errorf("%w: free-busy-query needs time-range", ErrInvalidRequest)
+		}
+		report.Type = ReportFreeBusyQuery
+		report.TimeRange, err = v.TimeRange.parse()
+	case CalDAV("calendar-query"):
+		var v calendarQueryXML
+		if err := decoder.DecodeElement(&v, &root); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		report.Type = ReportCalendarQuery
+		report.Props = v.Prop.names()
+		if tr := eventTimeRange(v.Filter.CompFilters); tr != nil {
+			report.TimeRange, err = tr.parse()
+		}
+	case CalDAV("calendar-multiget"):
+		var v calendarMultigetXML
+		if err := decoder.DecodeElement(&v, &root); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		report.Type = ReportCalendarMultiget
+		report.Props = v.Prop.names()
+		report.Hrefs = v.Hrefs
+	default:
+		return nil, fmt.Errorf("%w: {%s}%s", ErrUnsupportedReport, root.Name.Space, root.Name.Local)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// eventTimeRange はVCALENDARの中のVEVENTに対するtime-rangeの条件を探す
+// それ以外の条件（prop-filterなど）は解釈せず、該当しうるものを全て返す
+func eventTimeRange(filters []compFilterXML) *timeRangeXML {
+	for _, calendar := range filters {
+		if calendar.Name != "VCALENDAR" {
+			continue
+		}
+		for _, component := range calendar.CompFilters {
+			if component.Name == "VEVENT" && component.TimeRange != nil {
+				return component.TimeRange
+			}
+		}
+	}
+	return nil
+}
+
+// parse はtime-rangeの属性を解釈する（UTCの日時でなければならない）
+func (v *timeRangeXML) parse() (*TimeRange, error) {
+	tr := &TimeRange{}
+	for _, attr := range []struct {
+		value string
+		dst   *time.Time
+	}{{v.Start, &tr.Start}, {v.End, &tr.End}} {
+		if attr.value == "" {
+			continue
+		}
+		t, err := time.Parse("20060102T150405Z", attr.value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid time-range %q", ErrInvalidRequest, attr.value)
+		}
+		*attr.dst = t
+	}
+	if tr.Start.IsZero() && tr.End.IsZero() {
+		return nil, fmt.Errorf("%w: time-range needs start or end", ErrInvalidRequest)
+	}
+	if !tr.Start.IsZero() && !tr.End.IsZero() && !tr.Start.Before(tr.End) {
+		return nil, fmt.Errorf("%w: time-range end must be after start", ErrInvalidRequest)
+	}
+	return tr, nil
+}
+
+// Property はレスポンスに含めるプロパティ
+// Innerはエスケープ済みのXML
+type Property struct {
+	Name  xml.Name
+	Inner string
+}
+
+// TextProperty は文字列の値を持つプロパティを作成する
+func TextProperty(name xml.Name, value string) Property {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(value))
+	return Property{Name: name, Inner: b.String()}
+}
+
+// XMLProperty は子要素を持つプロパティを作成する
+func XMLProperty(name xml.Name, inner string) Property {
+	return Property{Name: name, Inner: inner}
+}
+
+// Response はmultistatusの1件分のレスポンス
+type Response struct {
+	Href string
+	// Status は0以外の場合、プロパティの代わりにリソース全体の状態を返す（multigetで存在しないhrefなど）
+	Status int
+	// Props は見つかったプロパティ
+	Props []Property
+	// Missing はリソースに存在しないプロパティ
+	Missing []xml.Name
+}
+
+// prefixes は既知の名前空間の接頭辞
+var prefixes = map[string]string{
+	NamespaceDAV:            "D",
+	NamespaceCalDAV:         "C",
+	NamespaceCalendarServer: "CS",
+}
+
+// Element は既知の名前空間の要素を接頭辞付きで書き出す
+// 子要素を持たない場合はinnerを空にする
+func Element(name xml.Name, inner string) string {
+	tag, attr := qualified(name)
+	if inner == "" {
+		return "<" + tag + attr + "/>"
+	}
+	return "<" + tag + attr + ">" + inner + "</" + tag + ">"
+}
+
+// qualified は要素名を接頭辞付きの名前にし、未知の名前空間の場合はその宣言を返す
+func qualified(name xml.Name) (string, string) {
+	if prefix, ok := prefixes[name.Space]; ok {
+		return prefix + ":" + name.Local, ""
+	}
+	if name.Space == "" {
+		return name.Local, ""
+	}
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(name.Space))
+	return "X:" + name.Local, ` xmlns:X="` + b.String() + `"`
+}
+
+// WriteMultistatus は207 Multi-Statusの本文を書き出す
+func WriteMultistatus(w io.Writer, responses []Response) error {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="` + NamespaceCalDAV + `" xmlns:CS="` + NamespaceCalendarServer + `">`)
+	for _, r := range responses {
+		b.WriteString("<D:response>")
+		b.WriteString("<D:href>")
+		xml.EscapeText(&b, []byte(r.Href))
+		b.WriteString("</D:href>")
+		if r.Status != 0 {
+			b.WriteString(status(r.Status))
+		} else {
+			if len(r.Props) > 0 {
+				b.WriteString("<D:propstat><D:prop>")
+				for _, p := range r.Props {
+					b.WriteString(Element(p.Name, p.Inner))
+				}
+				b.WriteString("</D:prop>" + status(http.StatusOK) + "</D:propstat>")
+			}
+			if len(r.Missing) > 0 {
+				b.WriteString("<D:propstat><D:prop>")
+				for _, name := range r.Missing {
+					b.WriteString(Element(name, ""))
+				}
+				b.WriteString("</D:prop>" + status(http.StatusNotFound) + "</D:propstat>")
+			}
+		}
+		b.WriteString("</D:response>")
+	}
+	b.WriteString("</D:multistatus>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteError はpreconditionやpostconditionに違反したことを伝えるerror要素を書き出す（RFC 4918 16）
+func WriteError(w io.Writer, condition xml.Name) error {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<D:error xmlns:D="DAV:" xmlns:C="` + NamespaceCalDAV + `">`)
+	b.WriteString(Element(condition, ""))
+	b.WriteString("</D:error>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func status(code int) string {
+	return fmt.Sprintf("<D:status>HTTP/1.1 %d %s</D:status>", code, http.StatusText(code))
+}
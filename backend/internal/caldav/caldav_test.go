@@ -0,0 +1,125 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePropfind(t *testing.T) {
+	t.Run("propで指定したプロパティを名前空間付きで返す", func(t *testing.T) {
+		propfind, err := ParsePropfind(strings.NewReader(`<?xml version="1.0"?>
+<propfind xmlns="DAV:" xmlns:CS="http://calendarserver.org/ns/"><prop><displayname/><CS:getctag/></prop></propfind>`))
+		require.NoError(t, err)
+		assert.False(t, propfind.AllProp)
+		assert.Equal(t, []xml.Name{DAV("displayname"), CalendarServer("getctag")}, propfind.Props)
+	})
+
+	t.Run("本文が空の場合はallprop", func(t *testing.T) {
+		propfind, err := ParsePropfind(strings.NewReader(""))
+		require.NoError(t, err)
+		assert.True(t, propfind.AllProp)
+	})
+
+	t.Run("不正な本文はErrInvalidRequestを返す", func(t *testing.T) {
+		for _, body := range []string{"<propfind", `<propfind xmlns="DAV:"/>`, `<other xmlns="DAV:"><allprop/></other>`} {
+			_, err := ParsePropfind(strings.NewReader(body))
+			assert.ErrorIs(t, err, ErrInvalidRequest, body)
+		}
+	})
+}
+
+func TestParseReport(t *testing.T) {
+	t.Run("free-busy-query", func(t *testing.T) {
+		report, err := ParseReport(strings.NewReader(`<C:free-busy-query xmlns:C="urn:ietf:params:xml:ns:caldav">
+<C:time-range start="20261020T000000Z" end="20261027T000000Z"/></C:free-busy-query>`))
+		require.NoError(t, err)
+		assert.Equal(t, ReportFreeBusyQuery, report.Type)
+		assert.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), report.TimeRange.Start)
+		assert.Equal(t, time.Date(2026, 10, 27, 0, 0, 0, 0, time.UTC), report.TimeRange.End)
+	})
+
+	t.Run("calendar-queryはVEVENTのtime-rangeを条件にする", func(t *testing.T) {
+		report, err := ParseReport(strings.NewReader(`<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+<D:prop><D:getetag/></D:prop>
+<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT"><C:time-range start="20261020T000000Z"/></C:comp-filter></C:comp-filter></C:filter>
+</C:calendar-query>`))
+		require.NoError(t, err)
+		assert.Equal(t, ReportCalendarQuery, report.Type)
+		assert.Equal(t, []xml.Name{DAV("getetag")}, report.Props)
+		require.NotNil(t, report.TimeRange)
+		assert.True(t, report.TimeRange.End.IsZero())
+	})
+
+	t.Run("calendar-multiget", func(t *testing.T) {
+		report, err := ParseReport(strings.NewReader(`<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+<D:prop><C:calendar-data/></D:prop><D:href>/a.ics</D:href><D:href>/b.ics</D:href></C:calendar-multiget>`))
+		require.NoError(t, err)
+		assert.Equal(t, ReportCalendarMultiget, report.Type)
+		assert.Equal(t, []string{"/a.ics", "/b.ics"}, report.Hrefs)
+	})
+
+	t.Run("対応していないREPORTと不正な本文", func(t *testing.T) {
+		_, err := ParseReport(strings.NewReader(`<D:sync-collection xmlns:D="DAV:"/>`))
+		assert.ErrorIs(t, err, ErrUnsupportedReport)
+
+		for _, body := range []string{
+			"",
+			`<C:free-busy-query xmlns:C="urn:ietf:params:xml:ns:caldav"/>`,
+			`<C:free-busy-query xmlns:C="urn:ietf:params:xml:ns:caldav"><C:time-range start="2026-10-20"/></C:free-busy-query>`,
+			`<C:free-busy-query xmlns:C="urn:ietf:params:xml:ns:caldav"><C:time-range start="20261021T000000Z" end="20261020T000000Z"/></C:free-busy-query>`,
+		} {
+			_, err := ParseReport(strings.NewReader(body))
+			assert.ErrorIs(t, err, ErrInvalidRequest, body)
+		}
+	})
+}
+
+func TestTimeRange_Overlaps(t *testing.T) {
+	base := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	tr := &TimeRange{Start: base, End: base.Add(2 * time.Hour)}
+
+	assert.True(t, tr.Overlaps(base.Add(-time.Hour), base.Add(time.Minute)))
+	assert.False(t, tr.Overlaps(base.Add(-time.Hour), base))
+	assert.False(t, tr.Overlaps(base.Add(2*time.Hour), base.Add(3*time.Hour)))
+	assert.True(t, (&TimeRange{Start: base}).Overlaps(base.AddDate(1, 0, 0), base.AddDate(1, 0, 1)))
+
+	var none *TimeRange
+	assert.True(t, none.Overlaps(base, base.Add(time.Hour)))
+}
+
+func TestWriteMultistatus(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteMultistatus(&b, []Response{
+		{
+			Href: "/caldav/schedules/uuid/",
+			Props: []Property{
+				XMLProperty(DAV("resourcetype"), Element(DAV("collection"), "")),
+				TextProperty(DAV("displayname"), "候補 <A&B>"),
+			},
+			Missing: []xml.Name{{Space: "http://example.com/ns", Local: "color"}},
+		},
+		{Href: "/caldav/schedules/uuid/missing.ics", Status: http.StatusNotFound},
+	}))
+
+	body := b.String()
+	assert.Contains(t, body, `<D:resourcetype><D:collection/></D:resourcetype>`)
+	assert.Contains(t, body, `<D:displayname>候補 &lt;A&amp;B&gt;</D:displayname>`)
+	assert.Contains(t, body, `<X:color xmlns:X="http://example.com/ns"/></D:prop><D:status>HTTP/1.1 404 Not Found</D:status>`)
+	assert.Contains(t, body, `<D:href>/caldav/schedules/uuid/missing.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>`)
+
+	// 出力は整形式のXMLになる
+	decoder := xml.NewDecoder(&b)
+	for {
+		if _, err := decoder.Token(); err != nil {
+			assert.EqualError(t, err, "EOF")
+			break
+		}
+	}
+}
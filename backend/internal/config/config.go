@@ -0,0 +1,909 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"kareru-backend/internal/qr"
+)
+
+// 利用可能なトレースのエクスポーター
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// 実行環境
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
+// DevelopmentCORSOrigins は開発環境でCORSのオリジンを指定しなかった場合に許可するオリジン
+var DevelopmentCORSOrigins = []string{"http://localhost:3000"}
+
+// 利用可能なストレージバックエンド
+const (
+	StorageMemory    = "memory"
+	StorageFirestore = "firestore"
+	StorageRedis     = "redis"
+)
+
+// Config はサーバー全体の設定
+// デフォルト値 < 設定ファイル < 環境変数 < コマンドラインフラグ の順に上書きされる
+type Config struct {
+	// Environment は development または production
+	// 環境によってCORSのデフォルトと許可される設定が変わる
+	Environment string `yaml:"environment"`
+
+	Server    ServerConfig    `yaml:"server"`
+	Storage   StorageConfig   `yaml:"storage"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Schedule  ScheduleConfig  `yaml:"schedule"`
+	Log       LogConfig       `yaml:"log"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	RateLimit RateLimitConfig `yaml:"rateLimit"`
+	Lockout   LockoutConfig   `yaml:"lockout"`
+	Security  SecurityConfig  `yaml:"security"`
+	Share     ShareConfig     `yaml:"share"`
+	Webhook   WebhookConfig   `yaml:"webhook"`
+	Reminder  ReminderConfig  `yaml:"reminder"`
+	Mail      MailConfig      `yaml:"mail"`
+}
+
+type ServerConfig struct {
+	// Addr は待ち受けアドレス（例: ":8080"）
+	Addr string `yaml:"addr"`
+	// ReadTimeout はリクエスト全体（ボディを含む）の読み込みタイムアウト
+	ReadTimeout time.Duration `yaml:"readTimeout"`
+	// ReadHeaderTimeout はリクエストヘッダーの読み込みタイムアウト（slowloris対策）
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	// WriteTimeout はレスポンス書き込みのタイムアウト
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	// IdleTimeout はKeep-Alive接続の待機タイムアウト
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+	// ShutdownTimeout はシャットダウン時に処理中のリクエストを待つ最大時間
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// DrainDelay はシグナル受信後、readinessを落としてから新規接続の受け付けを止めるまでの待ち時間
+	// ロードバランサーが振り分け先から外すまでの間もリクエストを処理し続けるために使う
+	DrainDelay time.Duration `yaml:"drainDelay"`
+	// TrustedProxies はX-Forwarded-Forを信頼するプロキシのIPまたはCIDR
+	// 空の場合はX-Forwarded-Forを無視し、接続元のIPをクライアントIPとする
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+type StorageConfig struct {
+	// Backend は memory, firestore, redis のいずれか
+	Backend   string          `yaml:"backend"`
+	Timeout   time.Duration   `yaml:"timeout"`
+	Firestore FirestoreConfig `yaml:"firestore"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Cache     CacheConfig     `yaml:"cache"`
+}
+
+type FirestoreConfig struct {
+	ProjectID    string `yaml:"projectID"`
+	EmulatorHost string `yaml:"emulatorHost"`
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+type CacheConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Capacity    int           `yaml:"capacity"`
+	TTL         time.Duration `yaml:"ttl"`
+	NegativeTTL time.Duration `yaml:"negativeTTL"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins は許可するオリジン。"*" は全てのオリジンを許可する（開発環境のみ）
+	// 空の場合はクロスオリジンのリクエストを許可しない（開発環境ではDevelopmentCORSOrigins）
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+	// AllowCredentials はCookieなどの資格情報付きのリクエストを許可するか（"*" とは併用できない）
+	AllowCredentials bool `yaml:"allowCredentials"`
+}
+
+type SecurityConfig struct {
+	// HSTSMaxAge はHTTPS経由のレスポンスに付与するStrict-Transport-Securityのmax-age（0は付与しない）
+	HSTSMaxAge time.Duration `yaml:"hstsMaxAge"`
+	// HSTSIncludeSubdomains はHSTSをサブドメインにも適用するか
+	HSTSIncludeSubdomains bool `yaml:"hstsIncludeSubdomains"`
+	// ViewTokenSecret はパスワード保護されたスケジュールの閲覧トークンの署名鍵（32文字以上）
+	// 空の場合は起動ごとにランダムな鍵を使うため、再起動や他のインスタンスでは閲覧トークンが無効になる
+	ViewTokenSecret string `yaml:"viewTokenSecret"`
+	// ViewTokenTTL は閲覧トークンの有効期間
+	ViewTokenTTL time.Duration `yaml:"viewTokenTTL"`
+}
+
+type ShareConfig struct {
+	// PublicBaseURL はフロントエンドの公開URL（例: https://kareru.example）で、QRコードに埋め込む共有URLの基点になる
+	// 空の場合はQRコードを生成しない（開発環境ではDevelopmentCORSOriginsの先頭）
+	PublicBaseURL string `yaml:"publicBaseURL"`
+	// ShortLinkBaseURL は短縮URL（/s/:code）の基点で、バックエンドの /s/ に到達できる公開URL
+	// 空の場合はPublicBaseURL（フロントエンドと同じホストで /s/ をバックエンドに振り分けている場合）
+	ShortLinkBaseURL string   `yaml:"shortLinkBaseURL"`
+	QR               QRConfig `yaml:"qr"`
+}
+
+type QRConfig struct {
+	// DefaultSize はサイズを指定しない場合の画像サイズ（px）
+	DefaultSize int `yaml:"defaultSize"`
+	// MaxSize は指定できる画像サイズの上限（px）
+	MaxSize int `yaml:"maxSize"`
+	// ErrorCorrection は指定しない場合の誤り訂正レベル（L, M, Q, H のいずれか）
+	ErrorCorrection string `yaml:"errorCorrection"`
+}
+
+type ScheduleConfig struct {
+	// Expiry は作成からスケジュールが失効するまでの期間
+	Expiry time.Duration `yaml:"expiry"`
+	// MaxTimeSlots は1スケジュールあたりのタイムスロット数の上限（0は無制限）
+	MaxTimeSlots int `yaml:"maxTimeSlots"`
+	// MaxCommentLength はコメントの最大文字数（0は無制限）
+	MaxCommentLength int `yaml:"maxCommentLength"`
+	// LegacyEditRoutes は編集トークンをパスに含む非推奨の /edit/:token ルートを提供するか
+	LegacyEditRoutes bool `yaml:"legacyEditRoutes"`
+}
+
+type LogConfig struct {
+	// Level は debug, info, warn, error のいずれか
+	Level string `yaml:"level"`
+}
+
+type MetricsConfig struct {
+	// Enabled が true の場合 Addr の /metrics でPrometheus形式のメトリクスを公開する（デフォルトは無効）
+	Enabled bool `yaml:"enabled"`
+	// Addr はメトリクス用の待ち受けアドレス
+	// 内部の情報を含むため、公開用の server.addr とは別にし、外部に公開しないネットワークで待ち受ける
+	Addr string `yaml:"addr"`
+}
+
+type TracingConfig struct {
+	// Exporter は none, stdout, otlp のいずれか
+	Exporter string `yaml:"exporter"`
+	// Endpoint はOTLPの送信先URL（空の場合はOTEL_EXPORTER_OTLP_ENDPOINTなどの標準の環境変数に従う）
+	Endpoint    string `yaml:"endpoint"`
+	ServiceName string `yaml:"serviceName"`
+	// SampleRatio は親スパンがないリクエストをサンプリングする割合（0〜1）
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// 利用可能なレート制限のストア
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Store は memory（インスタンスごと）または redis（storage.redis の接続先で共有）
+	Store string `yaml:"store"`
+	// Create はスケジュール作成、Read は閲覧、Edit は更新・削除と編集トークンを使うルートの予算
+	Create RateLimitBudget `yaml:"create"`
+	Read   RateLimitBudget `yaml:"read"`
+	Edit   RateLimitBudget `yaml:"edit"`
+}
+
+// RateLimitBudget はクライアントIPごとのトークンバケットの設定
+type RateLimitBudget struct {
+	// PerMinute は1分あたりに補充されるリクエスト数
+	PerMinute float64 `yaml:"perMinute"`
+	// Burst は連続して受け付けるリクエスト数
+	Burst int `yaml:"burst"`
+}
+
+// 利用可能な編集ロックのストア
+const (
+	LockoutStoreMemory = "memory"
+	LockoutStoreRedis  = "redis"
+)
+
+// LockoutConfig は編集トークンの検証失敗によるスケジュールごとのロックの設定
+type LockoutConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Store は memory（インスタンスごと）または redis（storage.redis の接続先で共有）
+	Store string `yaml:"store"`
+	// MaxAttempts はロックされるまでに許容する連続失敗回数
+	MaxAttempts int `yaml:"maxAttempts"`
+	// BaseDelay は最初のロック時間。以降は失敗するたびに倍になる
+	BaseDelay time.Duration `yaml:"baseDelay"`
+	// MaxDelay はロック時間の上限
+	MaxDelay time.Duration `yaml:"maxDelay"`
+	// ResetAfter は最後の失敗から失敗回数を忘れるまでの時間
+	ResetAfter time.Duration `yaml:"resetAfter"`
+}
+
+// WebhookConfig はスケジュールのイベントを作成者が登録したURLに配信する設定
+type WebhookConfig struct {
+	// Enabled が true の場合、作成者がスケジュールにWebhookのURLを登録できる
+	Enabled bool `yaml:"enabled"`
+	// Workers は同時に配信するリクエスト数、QueueSize は配信待ちにできる件数
+	Workers   int `yaml:"workers"`
+	QueueSize int `yaml:"queueSize"`
+	// MaxAttempts は1件の配信を試みる回数の上限（初回を含む）
+	MaxAttempts int `yaml:"maxAttempts"`
+	// InitialBackoff は最初の再試行までの待ち時間。以降は再試行するたびに倍になる
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	// MaxBackoff は再試行までの待ち時間の上限
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+	// Timeout は1回の配信リクエストのタイムアウト
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxDeadLetters は配信できなかったイベントを保持する件数
+	MaxDeadLetters int `yaml:"maxDeadLetters"`
+	// AllowPrivateNetworks が true の場合、プライベートアドレスやループバックへの配信を許可する
+	// 社内ツールに配信する場合にだけ有効にする（外部の利用者に内部ネットワークへリクエストさせることになる）
+	AllowPrivateNetworks bool `yaml:"allowPrivateNetworks"`
+}
+
+// SMTPサーバーとの接続の暗号化方式
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
+)
+
+// MailConfig は作成者に共有URLと編集URL、失効前のリマインダーをメールで送る設定
+// リマインダーのメールは reminder.enabled も有効な場合だけ送られる
+type MailConfig struct {
+	// Enabled が true の場合、作成者がスケジュールの作成時にメールアドレスを指定できる
+	// メールに載せるURLを作るため share.publicBaseURL が必要
+	Enabled bool `yaml:"enabled"`
+	// From は差出人（"Kareru <noreply@example.com>" のように表示名を含められる）
+	From string     `yaml:"from"`
+	SMTP SMTPConfig `yaml:"smtp"`
+	// QueueSize は送信待ちにできるメールの数
+	QueueSize int `yaml:"queueSize"`
+	// MaxAttempts は一時的な失敗で1通の送信を試みる最大回数（初回を含む）
+	MaxAttempts int `yaml:"maxAttempts"`
+	// RetryDelay は最初の再試行までの待ち時間。以降は再試行のたびに倍になる
+	RetryDelay time.Duration `yaml:"retryDelay"`
+}
+
+type SMTPConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// Username が空の場合は認証しない
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// TLS は starttls, tls, none のいずれか（none は同じホストのリレーや開発用のサーバーに送る場合だけ使う）
+	TLS string `yaml:"tls"`
+	// Timeout は接続から送信完了までのタイムアウト
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ReminderConfig は失効が近づいたスケジュールを作成者に知らせる設定
+// 送信済みかどうかをストレージに記録するため、複数のインスタンスで有効にしても1回だけ送られる
+type ReminderConfig struct {
+	// Enabled はリマインダーを送るか（デフォルトは無効）
+	Enabled bool `yaml:"enabled"`
+	// Lead は失効のどれだけ前に知らせるか
+	Lead time.Duration `yaml:"lead"`
+	// Interval は失効が近づいたスケジュールを探す間隔
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Default はデフォルト設定を返す
+func Default() *Config {
+	return &Config{
+		Environment: EnvironmentProduction,
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Storage: StorageConfig{
+			Backend: StorageMemory,
+			Timeout: 5 * time.Second,
+			Firestore: FirestoreConfig{
+				ProjectID: "kareru-local",
+			},
+			Redis: RedisConfig{
+				Addr: "localhost:6379",
+			},
+			Cache: CacheConfig{
+				Capacity:    1000,
+				TTL:         30 * time.Second,
+				NegativeTTL: 5 * time.Second,
+			},
+		},
+		Schedule: ScheduleConfig{
+			Expiry:           7 * 24 * time.Hour,
+			MaxTimeSlots:     500,
+			MaxCommentLength: 1000,
+			LegacyEditRoutes: true,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Metrics: MetricsConfig{
+			Addr: "127.0.0.1:9090",
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			ServiceName: "kareru-backend",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   RateLimitStoreMemory,
+			Create:  RateLimitBudget{PerMinute: 10, Burst: 5},
+			Read:    RateLimitBudget{PerMinute: 120, Burst: 60},
+			Edit:    RateLimitBudget{PerMinute: 30, Burst: 10},
+		},
+		Lockout: LockoutConfig{
+			Enabled:     true,
+			Store:       LockoutStoreMemory,
+			MaxAttempts: 5,
+			BaseDelay:   time.Minute,
+			MaxDelay:    time.Hour,
+			ResetAfter:  24 * time.Hour,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:   180 * 24 * time.Hour,
+			ViewTokenTTL: 30 * time.Minute,
+		},
+		Share: ShareConfig{
+			QR: QRConfig{
+				DefaultSize:     256,
+				MaxSize:         1024,
+				ErrorCorrection: "M",
+			},
+		},
+		Webhook: WebhookConfig{
+			Workers:        4,
+			QueueSize:      1000,
+			MaxAttempts:    6,
+			InitialBackoff: 5 * time.Second,
+			MaxBackoff:     10 * time.Minute,
+			Timeout:        10 * time.Second,
+			MaxDeadLetters: 1000,
+		},
+		Reminder: ReminderConfig{
+			Lead:     24 * time.Hour,
+			Interval: 5 * time.Minute,
+		},
+		Mail: MailConfig{
+			SMTP: SMTPConfig{
+				Port:    587,
+				TLS:     SMTPTLSStartTLS,
+				Timeout: 30 * time.Second,
+			},
+			QueueSize:   100,
+			MaxAttempts: 3,
+			RetryDelay:  30 * time.Second,
+		},
+	}
+}
+
+// Load はコマンドライン引数・環境変数・設定ファイルから設定を読み込み、検証する
+// 設定ファイルは -config フラグまたは KARERU_CONFIG 環境変数で指定する
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	fs := flag.NewFlagSet("kareru", flag.ContinueOnError)
+	configPath := fs.String("config", getenv("KARERU_CONFIG"), "設定ファイル (YAML)")
+	environment := fs.String("env", "", "実行環境 (development, production)")
+	addr := fs.String("addr", "", "待ち受けアドレス")
+	backend := fs.String("storage", "", "ストレージバックエンド (memory, firestore, redis)")
+	origins := fs.String("cors-origins", "", "許可するCORSオリジン (カンマ区切り)")
+	logLevel := fs.String("log-level", "", "ログレベル (debug, info, warn, error)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(getenv); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			cfg.Environment = *environment
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "storage":
+			cfg.Storage.Backend = *backend
+		case "cors-origins":
+			cfg.CORS.AllowedOrigins = splitList(*origins)
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		}
+	})
+
+	// 開発環境ではオリジンを指定しなくてもローカルのフロントエンドから呼び出せるようにする
+	if cfg.Environment == EnvironmentDevelopment && len(cfg.CORS.AllowedOrigins) == 0 {
+		cfg.CORS.AllowedOrigins = DevelopmentCORSOrigins
+	}
+	if cfg.Environment == EnvironmentDevelopment && cfg.Share.PublicBaseURL == "" {
+		cfg.Share.PublicBaseURL = DevelopmentCORSOrigins[0]
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv(getenv func(string) string) error {
+	var errs []error
+
+	setString := func(key string, dst *string) {
+		if v := getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	setInt := func(key string, dst *int) {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not an integer", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	setBool := func(key string, dst *bool) {
+		if v := getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not a boolean", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	setFloat := func(key string, dst *float64) {
+		if v := getenv(key); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not a number", key, v))
+				return
+			}
+			*dst = f
+		}
+	}
+	setDuration := func(key string, dst *time.Duration) {
+		if v := getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not a duration (e.g. 30s, 168h)", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+
+	setString("KARERU_ENV", &c.Environment)
+
+	// Cloud RunなどのPaaSが設定するPORTも受け付ける
+	if port := getenv("PORT"); port != "" {
+		c.Server.Addr = ":" + port
+	}
+	setString("KARERU_ADDR", &c.Server.Addr)
+	setDuration("KARERU_READ_TIMEOUT", &c.Server.ReadTimeout)
+	setDuration("KARERU_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	setDuration("KARERU_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	setDuration("KARERU_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	setDuration("KARERU_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	setDuration("KARERU_DRAIN_DELAY", &c.Server.DrainDelay)
+	if v := getenv("KARERU_TRUSTED_PROXIES"); v != "" {
+		c.Server.TrustedProxies = splitList(v)
+	}
+
+	setString("KARERU_STORAGE_BACKEND", &c.Storage.Backend)
+	setDuration("KARERU_STORAGE_TIMEOUT", &c.Storage.Timeout)
+	setString("FIRESTORE_PROJECT_ID", &c.Storage.Firestore.ProjectID)
+	setString("FIRESTORE_EMULATOR_HOST", &c.Storage.Firestore.EmulatorHost)
+	setString("REDIS_ADDR", &c.Storage.Redis.Addr)
+	setString("REDIS_PASSWORD", &c.Storage.Redis.Password)
+	setInt("REDIS_DB", &c.Storage.Redis.DB)
+	setBool("KARERU_CACHE_ENABLED", &c.Storage.Cache.Enabled)
+	setInt("KARERU_CACHE_CAPACITY", &c.Storage.Cache.Capacity)
+	setDuration("KARERU_CACHE_TTL", &c.Storage.Cache.TTL)
+	setDuration("KARERU_CACHE_NEGATIVE_TTL", &c.Storage.Cache.NegativeTTL)
+
+	if v := getenv("KARERU_CORS_ORIGINS"); v != "" {
+		c.CORS.AllowedOrigins = splitList(v)
+	}
+	setBool("KARERU_CORS_ALLOW_CREDENTIALS", &c.CORS.AllowCredentials)
+	setDuration("KARERU_HSTS_MAX_AGE", &c.Security.HSTSMaxAge)
+	setBool("KARERU_HSTS_INCLUDE_SUBDOMAINS", &c.Security.HSTSIncludeSubdomains)
+	setString("KARERU_VIEW_TOKEN_SECRET", &c.Security.ViewTokenSecret)
+	setDuration("KARERU_VIEW_TOKEN_TTL", &c.Security.ViewTokenTTL)
+
+	setString("KARERU_PUBLIC_BASE_URL", &c.Share.PublicBaseURL)
+	setString("KARERU_SHORT_LINK_BASE_URL", &c.Share.ShortLinkBaseURL)
+	setInt("KARERU_QR_DEFAULT_SIZE", &c.Share.QR.DefaultSize)
+	setInt("KARERU_QR_MAX_SIZE", &c.Share.QR.MaxSize)
+	setString("KARERU_QR_ERROR_CORRECTION", &c.Share.QR.ErrorCorrection)
+
+	setDuration("KARERU_SCHEDULE_EXPIRY", &c.Schedule.Expiry)
+	setInt("KARERU_MAX_TIME_SLOTS", &c.Schedule.MaxTimeSlots)
+	setInt("KARERU_MAX_COMMENT_LENGTH", &c.Schedule.MaxCommentLength)
+	setBool("KARERU_LEGACY_EDIT_ROUTES", &c.Schedule.LegacyEditRoutes)
+
+	setBool("KARERU_WEBHOOK_ENABLED", &c.Webhook.Enabled)
+	setInt("KARERU_WEBHOOK_WORKERS", &c.Webhook.Workers)
+	setInt("KARERU_WEBHOOK_QUEUE_SIZE", &c.Webhook.QueueSize)
+	setInt("KARERU_WEBHOOK_MAX_ATTEMPTS", &c.Webhook.MaxAttempts)
+	setDuration("KARERU_WEBHOOK_INITIAL_BACKOFF", &c.Webhook.InitialBackoff)
+	setDuration("KARERU_WEBHOOK_MAX_BACKOFF", &c.Webhook.MaxBackoff)
+	setDuration("KARERU_WEBHOOK_TIMEOUT", &c.Webhook.Timeout)
+	setInt("KARERU_WEBHOOK_MAX_DEAD_LETTERS", &c.Webhook.MaxDeadLetters)
+	setBool("KARERU_WEBHOOK_ALLOW_PRIVATE_NETWORKS", &c.Webhook.AllowPrivateNetworks)
+
+	setBool("KARERU_REMINDER_ENABLED", &c.Reminder.Enabled)
+	setDuration("KARERU_REMINDER_LEAD", &c.Reminder.Lead)
+	setDuration("KARERU_REMINDER_INTERVAL", &c.Reminder.Interval)
+
+	setBool("KARERU_MAIL_ENABLED", &c.Mail.Enabled)
+	setString("KARERU_MAIL_FROM", &c.Mail.From)
+	setInt("KARERU_MAIL_QUEUE_SIZE", &c.Mail.QueueSize)
+	setInt("KARERU_MAIL_MAX_ATTEMPTS", &c.Mail.MaxAttempts)
+	setDuration("KARERU_MAIL_RETRY_DELAY", &c.Mail.RetryDelay)
+	setString("KARERU_SMTP_HOST", &c.Mail.SMTP.Host)
+	setInt("KARERU_SMTP_PORT", &c.Mail.SMTP.Port)
+	setString("KARERU_SMTP_USERNAME", &c.Mail.SMTP.Username)
+	setString("KARERU_SMTP_PASSWORD", &c.Mail.SMTP.Password)
+	setString("KARERU_SMTP_TLS", &c.Mail.SMTP.TLS)
+	setDuration("KARERU_SMTP_TIMEOUT", &c.Mail.SMTP.Timeout)
+
+	setString("KARERU_LOG_LEVEL", &c.Log.Level)
+	setBool("KARERU_METRICS_ENABLED", &c.Metrics.Enabled)
+	setString("KARERU_METRICS_ADDR", &c.Metrics.Addr)
+
+	setString("KARERU_TRACING_EXPORTER", &c.Tracing.Exporter)
+	setString("KARERU_TRACING_ENDPOINT", &c.Tracing.Endpoint)
+	setString("OTEL_SERVICE_NAME", &c.Tracing.ServiceName)
+	setFloat("KARERU_TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+
+	setBool("KARERU_RATE_LIMIT_ENABLED", &c.RateLimit.Enabled)
+	setString("KARERU_RATE_LIMIT_STORE", &c.RateLimit.Store)
+	setFloat("KARERU_RATE_LIMIT_CREATE_PER_MINUTE", &c.RateLimit.Create.PerMinute)
+	setInt("KARERU_RATE_LIMIT_CREATE_BURST", &c.RateLimit.Create.Burst)
+	setFloat("KARERU_RATE_LIMIT_READ_PER_MINUTE", &c.RateLimit.Read.PerMinute)
+	setInt("KARERU_RATE_LIMIT_READ_BURST", &c.RateLimit.Read.Burst)
+	setFloat("KARERU_RATE_LIMIT_EDIT_PER_MINUTE", &c.RateLimit.Edit.PerMinute)
+	setInt("KARERU_RATE_LIMIT_EDIT_BURST", &c.RateLimit.Edit.Burst)
+
+	setBool("KARERU_LOCKOUT_ENABLED", &c.Lockout.Enabled)
+	setString("KARERU_LOCKOUT_STORE", &c.Lockout.Store)
+	setInt("KARERU_LOCKOUT_MAX_ATTEMPTS", &c.Lockout.MaxAttempts)
+	setDuration("KARERU_LOCKOUT_BASE_DELAY", &c.Lockout.BaseDelay)
+	setDuration("KARERU_LOCKOUT_MAX_DELAY", &c.Lockout.MaxDelay)
+	setDuration("KARERU_LOCKOUT_RESET_AFTER", &c.Lockout.ResetAfter)
+
+	return errors.Join(errs...)
+}
+
+// Validate は設定値を検証し、問題を全てまとめたエラーを返す
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("config: %s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	switch c.Environment {
+	case EnvironmentDevelopment, EnvironmentProduction:
+	default:
+		invalid("environment", "unknown environment %q (want %s or %s)", c.Environment, EnvironmentDevelopment, EnvironmentProduction)
+	}
+
+	if c.Server.Addr == "" {
+		invalid("server.addr", "must not be empty")
+	}
+	for _, timeout := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.readHeaderTimeout", c.Server.ReadHeaderTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.drainDelay", c.Server.DrainDelay},
+	} {
+		if timeout.value < 0 {
+			invalid(timeout.field, "must not be negative (0 disables the timeout)")
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("server.shutdownTimeout", "must be positive")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				invalid("server.trustedProxies", "%q is not an IP address or CIDR", proxy)
+			}
+		}
+	}
+
+	switch c.Storage.Backend {
+	case StorageMemory:
+	case StorageFirestore:
+		if c.Storage.Firestore.ProjectID == "" {
+			invalid("storage.firestore.projectID", "is required for the firestore backend")
+		}
+	case StorageRedis:
+		if c.Storage.Redis.Addr == "" {
+			invalid("storage.redis.addr", "is required for the redis backend")
+		}
+		if c.Storage.Redis.DB < 0 {
+			invalid("storage.redis.db", "must not be negative")
+		}
+	default:
+		invalid("storage.backend", "unknown backend %q (want %s, %s or %s)", c.Storage.Backend, StorageMemory, StorageFirestore, StorageRedis)
+	}
+	if c.Storage.Timeout < 0 {
+		invalid("storage.timeout", "must not be negative")
+	}
+	if c.Storage.Cache.Enabled {
+		if c.Storage.Cache.Capacity <= 0 {
+			invalid("storage.cache.capacity", "must be positive")
+		}
+		if c.Storage.Cache.TTL <= 0 {
+			invalid("storage.cache.ttl", "must be positive")
+		}
+		if c.Storage.Cache.NegativeTTL <= 0 {
+			invalid("storage.cache.negativeTTL", "must be positive")
+		}
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			// 全てのオリジンに資格情報付きのリクエストを許可すると、任意のサイトから利用者として操作できてしまう
+			if c.CORS.AllowCredentials {
+				invalid("cors.allowedOrigins", `"*" cannot be combined with cors.allowCredentials`)
+			}
+			if c.Environment == EnvironmentProduction {
+				invalid("cors.allowedOrigins", `"*" is not allowed in production; list the frontend origins explicitly`)
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			invalid("cors.allowedOrigins", "%q is not a valid origin (want scheme://host[:port])", origin)
+		}
+	}
+
+	if c.Security.HSTSMaxAge < 0 {
+		invalid("security.hstsMaxAge", "must not be negative (0 disables HSTS)")
+	}
+	if c.Security.ViewTokenSecret != "" && len(c.Security.ViewTokenSecret) < 32 {
+		invalid("security.viewTokenSecret", "must be at least 32 characters")
+	}
+	if c.Security.ViewTokenTTL <= 0 {
+		invalid("security.viewTokenTTL", "must be positive")
+	}
+
+	for _, base := range []struct {
+		field string
+		value string
+	}{
+		{"share.publicBaseURL", c.Share.PublicBaseURL},
+		{"share.shortLinkBaseURL", c.Share.ShortLinkBaseURL},
+	} {
+		if base.value == "" {
+			continue
+		}
+		u, err := url.Parse(base.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			invalid(base.field, "%q is not a valid base URL (want scheme://host[:port][/path])", base.value)
+		}
+	}
+	if c.Share.QR.DefaultSize < qr.MinSize {
+		invalid("share.qr.defaultSize", "must be at least %d", qr.MinSize)
+	}
+	if c.Share.QR.MaxSize < c.Share.QR.DefaultSize {
+		invalid("share.qr.maxSize", "must not be less than share.qr.defaultSize")
+	}
+	if _, err := qr.ParseLevel(c.Share.QR.ErrorCorrection); err != nil {
+		invalid("share.qr.errorCorrection", "unknown level %q (want L, M, Q or H)", c.Share.QR.ErrorCorrection)
+	}
+
+	if c.Schedule.Expiry <= 0 {
+		invalid("schedule.expiry", "must be positive")
+	}
+	if c.Schedule.MaxTimeSlots < 0 {
+		invalid("schedule.maxTimeSlots", "must not be negative")
+	}
+	if c.Schedule.MaxCommentLength < 0 {
+		invalid("schedule.maxCommentLength", "must not be negative")
+	}
+
+	if c.Webhook.Enabled {
+		for _, positive := range []struct {
+			field string
+			value int
+		}{
+			{"webhook.workers", c.Webhook.Workers},
+			{"webhook.queueSize", c.Webhook.QueueSize},
+			{"webhook.maxAttempts", c.Webhook.MaxAttempts},
+			{"webhook.maxDeadLetters", c.Webhook.MaxDeadLetters},
+		} {
+			if positive.value <= 0 {
+				invalid(positive.field, "must be positive")
+			}
+		}
+		if c.Webhook.InitialBackoff <= 0 {
+			invalid("webhook.initialBackoff", "must be positive")
+		}
+		if c.Webhook.MaxBackoff < c.Webhook.InitialBackoff {
+			invalid("webhook.maxBackoff", "must not be less than webhook.initialBackoff")
+		}
+		if c.Webhook.Timeout <= 0 {
+			invalid("webhook.timeout", "must be positive")
+		}
+	}
+
+	if c.Reminder.Enabled {
+		if c.Reminder.Lead <= 0 {
+			invalid("reminder.lead", "must be positive")
+		}
+		if c.Reminder.Interval <= 0 {
+			invalid("reminder.interval", "must be positive")
+		}
+	}
+
+	if c.Mail.Enabled {
+		if c.Share.PublicBaseURL == "" {
+			invalid("share.publicBaseURL", "is required to send schedule links by email")
+		}
+		if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+			invalid("mail.from", "%q is not an email address", c.Mail.From)
+		}
+		if c.Mail.SMTP.Host == "" {
+			invalid("mail.smtp.host", "must not be empty")
+		}
+		if c.Mail.SMTP.Port <= 0 || c.Mail.SMTP.Port > 65535 {
+			invalid("mail.smtp.port", "must be between 1 and 65535")
+		}
+		switch c.Mail.SMTP.TLS {
+		case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+		default:
+			invalid("mail.smtp.tls", "unknown mode %q (want %s, %s or %s)", c.Mail.SMTP.TLS, SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone)
+		}
+		if c.Mail.SMTP.Timeout <= 0 {
+			invalid("mail.smtp.timeout", "must be positive")
+		}
+		if c.Mail.QueueSize <= 0 {
+			invalid("mail.queueSize", "must be positive")
+		}
+		if c.Mail.MaxAttempts <= 0 {
+			invalid("mail.maxAttempts", "must be positive")
+		}
+		if c.Mail.RetryDelay <= 0 {
+			invalid("mail.retryDelay", "must be positive")
+		}
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log.level", "unknown level %q (want debug, info, warn or error)", c.Log.Level)
+	}
+
+	if c.Metrics.Enabled {
+		switch c.Metrics.Addr {
+		case "":
+			invalid("metrics.addr", "must not be empty when metrics are enabled")
+		case c.Server.Addr:
+			invalid("metrics.addr", "must differ from server.addr so that metrics are not served publicly")
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		invalid("tracing.exporter", "unknown exporter %q (want %s, %s or %s)", c.Tracing.Exporter, TracingExporterNone, TracingExporterStdout, TracingExporterOTLP)
+	}
+	if c.Tracing.Exporter != TracingExporterNone && c.Tracing.ServiceName == "" {
+		invalid("tracing.serviceName", "must not be empty")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sampleRatio", "must be between 0 and 1")
+	}
+
+	if c.RateLimit.Enabled {
+		switch c.RateLimit.Store {
+		case RateLimitStoreMemory:
+		case RateLimitStoreRedis:
+			if c.Storage.Redis.Addr == "" {
+				invalid("storage.redis.addr", "is required for the redis rate limit store")
+			}
+		default:
+			invalid("rateLimit.store", "unknown store %q (want %s or %s)", c.RateLimit.Store, RateLimitStoreMemory, RateLimitStoreRedis)
+		}
+		for _, budget := range []struct {
+			name   string
+			budget RateLimitBudget
+		}{
+			{"create", c.RateLimit.Create},
+			{"read", c.RateLimit.Read},
+			{"edit", c.RateLimit.Edit},
+		} {
+			if budget.budget.PerMinute <= 0 {
+				invalid("rateLimit."+budget.name+".perMinute", "must be positive")
+			}
+			if budget.budget.Burst <= 0 {
+				invalid("rateLimit."+budget.name+".burst", "must be positive")
+			}
+		}
+	}
+
+	if c.Lockout.Enabled {
+		switch c.Lockout.Store {
+		case LockoutStoreMemory:
+		case LockoutStoreRedis:
+			if c.Storage.Redis.Addr == "" {
+				invalid("storage.redis.addr", "is required for the redis lockout store")
+			}
+		default:
+			invalid("lockout.store", "unknown store %q (want %s or %s)", c.Lockout.Store, LockoutStoreMemory, LockoutStoreRedis)
+		}
+		if c.Lockout.MaxAttempts <= 0 {
+			invalid("lockout.maxAttempts", "must be positive")
+		}
+		if c.Lockout.BaseDelay <= 0 {
+			invalid("lockout.baseDelay", "must be positive")
+		}
+		if c.Lockout.MaxDelay < c.Lockout.BaseDelay {
+			invalid("lockout.maxDelay", "must not be less than lockout.baseDelay")
+		}
+		// 失敗回数はロック中に忘れられてはいけない
+		if c.Lockout.ResetAfter < c.Lockout.MaxDelay {
+			invalid("lockout.resetAfter", "must not be less than lockout.maxDelay")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
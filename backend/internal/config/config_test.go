@@ -0,0 +1,382 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envFrom(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kareru.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("何も指定しない場合はデフォルト設定になる", func(t *testing.T) {
+		cfg, err := Load(nil, envFrom(nil))
+		require.NoError(t, err)
+		assert.Equal(t, Default(), cfg)
+	})
+
+	t.Run("設定ファイル・環境変数・フラグの順に上書きされる", func(t *testing.T) {
+		path := writeConfigFile(t, `
+server:
+  addr: ":9000"
+storage:
+  backend: redis
+  redis:
+    addr: "redis:6379"
+cors:
+  allowedOrigins:
+    - "https://file.example.com"
+schedule:
+  expiry: 72h
+  maxTimeSlots: 10
+log:
+  level: debug
+`)
+
+		cfg, err := Load(
+			[]string{"-config", path, "-log-level", "warn"},
+			envFrom(map[string]string{
+				"KARERU_ADDR":             ":9100",
+				"KARERU_CORS_ORIGINS":     "https://a.example.com, https://b.example.com",
+				"KARERU_MAX_TIME_SLOTS":   "20",
+				"KARERU_LOG_LEVEL":        "error",
+				"KARERU_SHUTDOWN_TIMEOUT": "45s",
+			}),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, ":9100", cfg.Server.Addr)
+		assert.Equal(t, 45*time.Second, cfg.Server.ShutdownTimeout)
+		assert.Equal(t, StorageRedis, cfg.Storage.Backend)
+		assert.Equal(t, "redis:6379", cfg.Storage.Redis.Addr)
+		assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORS.AllowedOrigins)
+		assert.Equal(t, 72*time.Hour, cfg.Schedule.Expiry)
+		assert.Equal(t, 20, cfg.Schedule.MaxTimeSlots)
+		assert.Equal(t, "warn", cfg.Log.Level)
+	})
+
+	t.Run("KARERU_CONFIGで設定ファイルを指定できる", func(t *testing.T) {
+		path := writeConfigFile(t, "storage:\n  backend: firestore\n")
+
+		cfg, err := Load(nil, envFrom(map[string]string{"KARERU_CONFIG": path}))
+		require.NoError(t, err)
+		assert.Equal(t, StorageFirestore, cfg.Storage.Backend)
+	})
+
+	t.Run("PORTが設定されている場合は待ち受けアドレスに使う", func(t *testing.T) {
+		cfg, err := Load(nil, envFrom(map[string]string{"PORT": "3001"}))
+		require.NoError(t, err)
+		assert.Equal(t, ":3001", cfg.Server.Addr)
+	})
+
+	t.Run("設定ファイルの未知のキーはエラーになる", func(t *testing.T) {
+		path := writeConfigFile(t, "server:\n  adress: \":9000\"\n")
+
+		_, err := Load([]string{"-config", path}, envFrom(nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "adress")
+	})
+
+	t.Run("存在しない設定ファイルはエラーになる", func(t *testing.T) {
+		_, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, envFrom(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("環境変数の型が不正な場合は変数名を含むエラーになる", func(t *testing.T) {
+		_, err := Load(nil, envFrom(map[string]string{
+			"KARERU_SCHEDULE_EXPIRY": "7days",
+			"REDIS_DB":               "zero",
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "KARERU_SCHEDULE_EXPIRY")
+		assert.Contains(t, err.Error(), "REDIS_DB")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("デフォルト設定は有効", func(t *testing.T) {
+		assert.NoError(t, Default().Validate())
+	})
+
+	t.Run("複数の問題をまとめて報告する", func(t *testing.T) {
+		cfg := Default()
+		cfg.Server.Addr = ""
+		cfg.Storage.Backend = "mysql"
+		cfg.CORS.AllowedOrigins = []string{"example.com"}
+		cfg.Schedule.Expiry = 0
+		cfg.Log.Level = "verbose"
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		for _, field := range []string{"server.addr", "storage.backend", "cors.allowedOrigins", "schedule.expiry", "log.level"} {
+			assert.Contains(t, err.Error(), field)
+		}
+	})
+
+	t.Run("サーバーのタイムアウトは負の値を許可しない", func(t *testing.T) {
+		cfg := Default()
+		cfg.Server.WriteTimeout = -time.Second
+		cfg.Server.ShutdownTimeout = 0
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server.writeTimeout")
+		assert.Contains(t, err.Error(), "server.shutdownTimeout")
+	})
+
+	t.Run("トレースのエクスポーターとサンプリング率を検証する", func(t *testing.T) {
+		cfg := Default()
+		cfg.Tracing.Exporter = "jaeger"
+		cfg.Tracing.SampleRatio = 1.5
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tracing.exporter")
+		assert.Contains(t, err.Error(), "tracing.sampleRatio")
+	})
+
+	t.Run("レート制限の予算と信頼するプロキシを検証する", func(t *testing.T) {
+		cfg := Default()
+		cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1", "proxy.local"}
+		cfg.RateLimit.Store = "memcached"
+		cfg.RateLimit.Edit.Burst = 0
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"proxy.local"`)
+		assert.NotContains(t, err.Error(), "10.0.0.0/8")
+		assert.Contains(t, err.Error(), "rateLimit.store")
+		assert.Contains(t, err.Error(), "rateLimit.edit.burst")
+
+		cfg.RateLimit.Enabled = false
+		cfg.Server.TrustedProxies = nil
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("編集ロックの時間はBaseDelay <= MaxDelay <= ResetAfterである必要がある", func(t *testing.T) {
+		cfg := Default()
+		cfg.Lockout.MaxAttempts = 0
+		cfg.Lockout.ResetAfter = 30 * time.Minute
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "lockout.maxAttempts")
+		assert.Contains(t, err.Error(), "lockout.resetAfter")
+
+		cfg.Lockout.Enabled = false
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("キャッシュ有効時は容量とTTLが必要", func(t *testing.T) {
+		cfg := Default()
+		cfg.Storage.Cache.Enabled = true
+		cfg.Storage.Cache.TTL = 0
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "storage.cache.ttl")
+	})
+
+	t.Run("本番環境では*を許可せず、資格情報付きの*はどの環境でも許可しない", func(t *testing.T) {
+		cfg := Default()
+		cfg.CORS.AllowedOrigins = []string{"*"}
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not allowed in production")
+
+		cfg.Environment = EnvironmentDevelopment
+		assert.NoError(t, cfg.Validate())
+
+		cfg.CORS.AllowCredentials = true
+		err = cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cors.allowCredentials")
+
+		cfg.Environment = "staging"
+		assert.Contains(t, cfg.Validate().Error(), "environment")
+	})
+
+	t.Run("本番環境のデフォルトではクロスオリジンを許可しない", func(t *testing.T) {
+		cfg, err := Load(nil, envFrom(nil))
+		require.NoError(t, err)
+		assert.Equal(t, EnvironmentProduction, cfg.Environment)
+		assert.Empty(t, cfg.CORS.AllowedOrigins)
+		assert.False(t, cfg.CORS.AllowCredentials)
+	})
+
+	t.Run("開発環境でオリジンを指定しない場合はローカルのフロントエンドを許可する", func(t *testing.T) {
+		cfg, err := Load([]string{"-env", "development"}, envFrom(nil))
+		require.NoError(t, err)
+		assert.Equal(t, DevelopmentCORSOrigins, cfg.CORS.AllowedOrigins)
+
+		cfg, err = Load(nil, envFrom(map[string]string{
+			"KARERU_ENV":          EnvironmentDevelopment,
+			"KARERU_CORS_ORIGINS": "http://localhost:4000",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://localhost:4000"}, cfg.CORS.AllowedOrigins)
+	})
+
+	t.Run("閲覧トークンの署名鍵は32文字以上である必要がある", func(t *testing.T) {
+		cfg := Default()
+		cfg.Security.ViewTokenSecret = "short"
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "security.viewTokenSecret")
+
+		cfg.Security.ViewTokenSecret = strings.Repeat("k", 32)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("共有URLの基点とQRコードの設定を検証する", func(t *testing.T) {
+		cfg := Default()
+		cfg.Share.PublicBaseURL = "https://kareru.example.com/app"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Share.PublicBaseURL = "kareru.example.com"
+		cfg.Share.ShortLinkBaseURL = "https://k.example.com/?ref=qr"
+		cfg.Share.QR.DefaultSize = 32
+		cfg.Share.QR.ErrorCorrection = "X"
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "share.publicBaseURL")
+		assert.Contains(t, err.Error(), "share.shortLinkBaseURL")
+		assert.Contains(t, err.Error(), "share.qr.defaultSize")
+		assert.Contains(t, err.Error(), "share.qr.errorCorrection")
+
+		cfg = Default()
+		cfg.Share.QR.MaxSize = 128
+		assert.ErrorContains(t, cfg.Validate(), "share.qr.maxSize")
+	})
+
+	t.Run("開発環境で共有URLの基点を指定しない場合はローカルのフロントエンドを使う", func(t *testing.T) {
+		cfg, err := Load([]string{"-env", "development"}, envFrom(nil))
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:3000", cfg.Share.PublicBaseURL)
+
+		cfg, err = Load(nil, envFrom(map[string]string{
+			"KARERU_PUBLIC_BASE_URL":     "https://kareru.example.com",
+			"KARERU_SHORT_LINK_BASE_URL": "https://k.example.com",
+			"KARERU_QR_ERROR_CORRECTION": "H",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "https://kareru.example.com", cfg.Share.PublicBaseURL)
+		assert.Equal(t, "https://k.example.com", cfg.Share.ShortLinkBaseURL)
+		assert.Equal(t, "H", cfg.Share.QR.ErrorCorrection)
+	})
+
+	t.Run("Webhookは有効な場合だけ配信の設定を検証する", func(t *testing.T) {
+		cfg := Default()
+		cfg.Webhook.Workers = 0
+		cfg.Webhook.MaxBackoff = time.Second
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Webhook.Enabled = true
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "webhook.workers")
+		assert.Contains(t, err.Error(), "webhook.maxBackoff")
+
+		cfg, err = Load(nil, envFrom(map[string]string{
+			"KARERU_WEBHOOK_ENABLED":                "true",
+			"KARERU_WEBHOOK_MAX_ATTEMPTS":           "3",
+			"KARERU_WEBHOOK_ALLOW_PRIVATE_NETWORKS": "true",
+			"KARERU_REMINDER_LEAD":                  "12h",
+		}))
+		require.NoError(t, err)
+		assert.True(t, cfg.Webhook.Enabled)
+		assert.Equal(t, 3, cfg.Webhook.MaxAttempts)
+		assert.True(t, cfg.Webhook.AllowPrivateNetworks)
+		assert.Equal(t, 12*time.Hour, cfg.Reminder.Lead)
+	})
+
+	t.Run("メトリクスはデフォルトで無効で、公開用とは別のアドレスで待ち受ける", func(t *testing.T) {
+		cfg, err := Load(nil, envFrom(nil))
+		require.NoError(t, err)
+		assert.False(t, cfg.Metrics.Enabled)
+
+		cfg, err = Load(nil, envFrom(map[string]string{
+			"KARERU_METRICS_ENABLED": "true",
+			"KARERU_METRICS_ADDR":    ":9464",
+		}))
+		require.NoError(t, err)
+		assert.True(t, cfg.Metrics.Enabled)
+		assert.Equal(t, ":9464", cfg.Metrics.Addr)
+
+		cfg = Default()
+		cfg.Metrics.Enabled = true
+		cfg.Metrics.Addr = cfg.Server.Addr
+		assert.ErrorContains(t, cfg.Validate(), "metrics.addr")
+		cfg.Metrics.Addr = ""
+		assert.ErrorContains(t, cfg.Validate(), "metrics.addr")
+	})
+
+	t.Run("リマインダーはデフォルトで無効", func(t *testing.T) {
+		cfg, err := Load(nil, envFrom(nil))
+		require.NoError(t, err)
+		assert.False(t, cfg.Reminder.Enabled)
+
+		cfg, err = Load(nil, envFrom(map[string]string{"KARERU_REMINDER_ENABLED": "true"}))
+		require.NoError(t, err)
+		assert.True(t, cfg.Reminder.Enabled)
+	})
+
+	t.Run("リマインダーは有効な場合だけ間隔を検証する", func(t *testing.T) {
+		cfg := Default()
+		cfg.Reminder.Interval = 0
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Reminder.Enabled = true
+		assert.ErrorContains(t, cfg.Validate(), "reminder.interval")
+	})
+
+	t.Run("メール通知は共有URLの基点とSMTPサーバーが必要", func(t *testing.T) {
+		cfg := Default()
+		cfg.Mail.Enabled = true
+		cfg.Mail.SMTP.TLS = "ssl"
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		for _, field := range []string{"share.publicBaseURL", "mail.from", "mail.smtp.host", "mail.smtp.tls"} {
+			assert.Contains(t, err.Error(), field)
+		}
+
+		cfg, err = Load(nil, envFrom(map[string]string{
+			"KARERU_MAIL_ENABLED":    "true",
+			"KARERU_MAIL_FROM":       "Kareru <noreply@kareru.example.com>",
+			"KARERU_SMTP_HOST":       "smtp.example.com",
+			"KARERU_SMTP_PORT":       "465",
+			"KARERU_SMTP_TLS":        "tls",
+			"KARERU_PUBLIC_BASE_URL": "https://kareru.example.com",
+		}))
+		require.NoError(t, err)
+		assert.True(t, cfg.Mail.Enabled)
+		assert.Equal(t, 465, cfg.Mail.SMTP.Port)
+		assert.Equal(t, SMTPTLSImplicit, cfg.Mail.SMTP.TLS)
+	})
+
+	t.Run("オリジンはscheme://host形式である必要がある", func(t *testing.T) {
+		cfg := Default()
+		cfg.CORS.AllowedOrigins = []string{"https://kareru.example.com", "http://localhost:3000"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.CORS.AllowedOrigins = []string{"https://kareru.example.com/path"}
+		assert.Error(t, cfg.Validate())
+	})
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Languages of the notification emails
+const (
+	LocaleJapanese = "ja"
+	LocaleEnglish  = "en"
+)
+
+// maxEmailLength is the longest address accepted by SMTP (RFC 5321)
+const maxEmailLength = 254
+
+// ErrInvalidEmail is returned when a notification address or language cannot be used
+var ErrInvalidEmail = errors.New("invalid email")
+
+// SetNotifyEmail validates and sets the address that receives notification
+// emails and their language. An empty address turns the emails off, and an
+// empty locale means Japanese
+func (s *Schedule) SetNotifyEmail(address, locale string) error {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		s.NotifyEmail = ""
+		s.NotifyLocale = ""
+		return nil
+	}
+	normalized, err := ValidateEmail(address)
+	if err != nil {
+		return err
+	}
+	switch locale {
+	case "":
+		locale = LocaleJapanese
+	case LocaleJapanese, LocaleEnglish:
+	default:
+		return fmt.Errorf("%w: unknown locale %q (want %s or %s)", ErrInvalidEmail, locale, LocaleJapanese, LocaleEnglish)
+	}
+	s.NotifyEmail = normalized
+	s.NotifyLocale = locale
+	return nil
+}
+
+// ValidateEmail checks that raw is a bare address such as user@example.com.
+// Display names and angle brackets are rejected so that the address can be
+// written into a mail header as is
+func ValidateEmail(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > maxEmailLength {
+		return "", fmt.Errorf("%w: address is too long (max %d characters)", ErrInvalidEmail, maxEmailLength)
+	}
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Name != "" || addr.Address != raw {
+		return "", fmt.Errorf("%w: %q is not an email address", ErrInvalidEmail, raw)
+	}
+	return addr.Address, nil
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetNotifyEmail(t *testing.T) {
+	t.Run("言語を省略した場合は日本語になる", func(t *testing.T) {
+		schedule := &Schedule{}
+		require.NoError(t, schedule.SetNotifyEmail(" taro@example.com ", ""))
+		assert.Equal(t, "taro@example.com", schedule.NotifyEmail)
+		assert.Equal(t, LocaleJapanese, schedule.NotifyLocale)
+
+		require.NoError(t, schedule.SetNotifyEmail("taro@example.com", LocaleEnglish))
+		assert.Equal(t, LocaleEnglish, schedule.NotifyLocale)
+
+		require.NoError(t, schedule.SetNotifyEmail("", LocaleEnglish))
+		assert.Empty(t, schedule.NotifyEmail)
+		assert.Empty(t, schedule.NotifyLocale)
+	})
+
+	t.Run("不正なアドレスと言語", func(t *testing.T) {
+		for _, raw := range []string{
+			"taro",
+			"Taro <taro@example.com>",
+			"<taro@example.com>",
+			"taro@example.com, hanako@example.com",
+			"taro@example.com\r\nBcc: victim@example.com",
+			strings.Repeat("a", 250) + "@example.com",
+		} {
+			err := (&Schedule{}).SetNotifyEmail(raw, "")
+			assert.ErrorIs(t, err, ErrInvalidEmail, raw)
+		}
+		assert.ErrorIs(t, (&Schedule{}).SetNotifyEmail("taro@example.com", "fr"), ErrInvalidEmail)
+	})
+}
@@ -0,0 +1,21 @@
+package model
+
+import "errors"
+
+// ErrScheduleNotFound is returned by repositories when no schedule matches the lookup
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ErrShortCodeTaken is returned by repositories when creating a schedule whose
+// short code is already used by another schedule
+var ErrShortCodeTaken = errors.New("short code already taken")
+
+// ErrInvalidShortCode is returned when a short code is not 8 Crockford base32 characters
+var ErrInvalidShortCode = errors.New("invalid short code")
+
+// ErrScheduleExists is returned by repositories when creating a schedule whose ID
+// is already stored
+var ErrScheduleExists = errors.New("schedule already exists")
+
+// ErrScheduleExpired is returned by repositories when creating a schedule whose
+// expiry has already passed
+var ErrScheduleExpired = errors.New("schedule already expired")
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters (OWASP recommendation: 19 MiB memory, 2 iterations, 1 thread)
+const (
+	argon2Time    = 2
+	argon2Memory  = 19 * 1024
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// ErrInvalidPasswordHash is returned when a stored password hash cannot be parsed
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// HashViewPassword hashes a view password with argon2id and returns it in PHC string format
+// ($argon2id$v=19$m=...,t=...,p=...$salt$hash) so the parameters can be changed later
+func HashViewPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// verifyPasswordHash checks a password against a PHC formatted argon2id hash
+func verifyPasswordHash(password, encoded string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrInvalidPasswordHash
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, ErrInvalidPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrInvalidPasswordHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false, ErrInvalidPasswordHash
+	}
+
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// SetViewPassword protects viewing the schedule with a password (an empty password removes it)
+func (s *Schedule) SetViewPassword(password string) error {
+	if password == "" {
+		s.ViewPasswordHash = ""
+		return nil
+	}
+	hash, err := HashViewPassword(password)
+	if err != nil {
+		return err
+	}
+	s.ViewPasswordHash = hash
+	return nil
+}
+
+// HasViewPassword reports whether viewing the schedule requires a password
+func (s *Schedule) HasViewPassword() bool {
+	return s.ViewPasswordHash != ""
+}
+
+// VerifyViewPassword verifies the provided password against the stored hash
+func (s *Schedule) VerifyViewPassword(password string) error {
+	if !s.HasViewPassword() {
+		return nil
+	}
+	ok, err := verifyPasswordHash(password, s.ViewPasswordHash)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid view password")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewPassword(t *testing.T) {
+	t.Run("argon2idでハッシュ化され、正しいパスワードだけを受け付ける", func(t *testing.T) {
+		schedule := &Schedule{}
+		require.NoError(t, schedule.SetViewPassword("team-meeting"))
+
+		assert.True(t, schedule.HasViewPassword())
+		assert.True(t, strings.HasPrefix(schedule.ViewPasswordHash, "$argon2id$v=19$m=19456,t=2,p=1$"))
+		assert.NotContains(t, schedule.ViewPasswordHash, "team-meeting")
+
+		assert.NoError(t, schedule.VerifyViewPassword("team-meeting"))
+		assert.Error(t, schedule.VerifyViewPassword("team-meeting "))
+		assert.Error(t, schedule.VerifyViewPassword(""))
+	})
+
+	t.Run("同じパスワードでもソルトによりハッシュは異なる", func(t *testing.T) {
+		first, err := HashViewPassword("password")
+		require.NoError(t, err)
+		second, err := HashViewPassword("password")
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("空のパスワードを設定すると保護が解除される", func(t *testing.T) {
+		schedule := &Schedule{}
+		require.NoError(t, schedule.SetViewPassword("secret"))
+		require.NoError(t, schedule.SetViewPassword(""))
+
+		assert.False(t, schedule.HasViewPassword())
+		assert.NoError(t, schedule.VerifyViewPassword("anything"))
+	})
+
+	t.Run("壊れたハッシュはエラーを返す", func(t *testing.T) {
+		schedule := &Schedule{ViewPasswordHash: "$bcrypt$broken"}
+		assert.ErrorIs(t, schedule.VerifyViewPassword("secret"), ErrInvalidPasswordHash)
+	})
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits of a viewer's response
+const (
+	maxResponseNameLength    = 100
+	maxResponseMessageLength = 1000
+)
+
+// ErrInvalidResponse is returned when a viewer's response cannot be accepted
+var ErrInvalidResponse = errors.New("invalid response")
+
+// Response is a viewer's answer saying which time slots suit them.
+// Responses are not stored; they are handed to the notifiers of the schedule
+// (such as its webhooks) when they are submitted
+type Response struct {
+	Name    string
+	Message string
+	// TimeSlots are the indexes of the chosen time slots in ascending order
+	TimeSlots   []int
+	SubmittedAt time.Time
+}
+
+// NewResponse validates a viewer's answer to the schedule. The name is
+// required, and every index must refer to a time slot of the schedule.
+// Duplicate indexes are removed
+func (s *Schedule) NewResponse(name, message string, timeSlots []int, now time.Time) (*Response, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidResponse)
+	}
+	if utf8.RuneCountInString(name) > maxResponseNameLength {
+		return nil, fmt.Errorf("%w: name is too long (max %d characters)", ErrInvalidResponse, maxResponseNameLength)
+	}
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > maxResponseMessageLength {
+		return nil, fmt.Errorf("%w: message is too long (max %d characters)", ErrInvalidResponse, maxResponseMessageLength)
+	}
+
+	chosen := make([]int, 0, len(timeSlots))
+	for _, i := range timeSlots {
+		if i < 0 || i >= len(s.TimeSlots) {
+			return nil, fmt.Errorf("%w: time slot %d does not exist", ErrInvalidResponse, i)
+		}
+		chosen = append(chosen, i)
+	}
+	slices.Sort(chosen)
+
+	return &Response{
+		Name:        name,
+		Message:     message,
+		TimeSlots:   slices.Compact(chosen),
+		SubmittedAt: now.UTC(),
+	}, nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResponse(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	schedule := &Schedule{TimeSlots: []TimeSlot{
+		{StartTime: now, EndTime: now.Add(time.Hour), Available: true},
+		{StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)},
+		{StartTime: now.Add(2 * time.Hour), EndTime: now.Add(3 * time.Hour)},
+	}}
+
+	t.Run("名前とメッセージの空白を取り除き、重複した添字をまとめる", func(t *testing.T) {
+		response, err := schedule.NewResponse(" 山田 ", " よろしくお願いします\n", []int{2, 0, 2}, now)
+		require.NoError(t, err)
+		assert.Equal(t, "山田", response.Name)
+		assert.Equal(t, "よろしくお願いします", response.Message)
+		assert.Equal(t, []int{0, 2}, response.TimeSlots)
+		assert.Equal(t, now.UTC(), response.SubmittedAt)
+	})
+
+	t.Run("都合の良い候補がない回答も受け付ける", func(t *testing.T) {
+		response, err := schedule.NewResponse("山田", "", nil, now)
+		require.NoError(t, err)
+		assert.Empty(t, response.TimeSlots)
+	})
+
+	t.Run("不正な回答", func(t *testing.T) {
+		for name, args := range map[string]struct {
+			name, message string
+			slots         []int
+		}{
+			"名前がない":      {name: "  "},
+			"名前が長すぎる":    {name: strings.Repeat("あ", maxResponseNameLength+1)},
+			"メッセージが長すぎる": {name: "山田", message: strings.Repeat("あ", maxResponseMessageLength+1)},
+			"存在しない候補":    {name: "山田", slots: []int{3}},
+			"負の添字":       {name: "山田", slots: []int{-1}},
+		} {
+			_, err := schedule.NewResponse(args.name, args.message, args.slots, now)
+			assert.True(t, errors.Is(err, ErrInvalidResponse), name)
+		}
+	})
+}
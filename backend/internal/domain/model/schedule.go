@@ -5,16 +5,36 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Schedule struct {
 	ID        string
 	EditToken string
+	// ShortCode is a short human-friendly alias of ID used in share URLs
+	// (empty for schedules created before short codes were introduced)
+	ShortCode string
 	TimeSlots []TimeSlot
 	Comment   string
 	CreatedAt time.Time
+	// UpdatedAt is when the time slots or comment were last changed
+	// (zero for schedules stored before it was tracked; see LastModified)
+	UpdatedAt time.Time
 	ExpiresAt time.Time
+	// ViewPasswordHash is the argon2id hash of the view password (empty if not protected)
+	ViewPasswordHash string
+	// WebhookURLs are the endpoints notified of the schedule's lifecycle events
+	WebhookURLs []string
+	// WebhookSecret is the HMAC key used to sign webhook payloads (empty until
+	// webhooks are registered); only the holder of the edit token may see it
+	WebhookSecret string
+	// NotifyEmail is the creator's address that receives the share and edit
+	// links and the expiry reminder (empty if the creator did not give one)
+	NotifyEmail string
+	// NotifyLocale is the language of the emails sent to NotifyEmail
+	NotifyLocale string
 }
 
 type TimeSlot struct {
@@ -49,6 +69,60 @@ func GenerateEditToken() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
+// ShortCodeLength is the number of characters in a short code
+const ShortCodeLength = 8
+
+// crockfordAlphabet is the Crockford base32 alphabet, which excludes I, L, O and U
+// so that codes are hard to misread when spoken or typed
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// GenerateShortCode generates a random short code of ShortCodeLength Crockford base32 characters
+func GenerateShortCode() (string, error) {
+	// 5 bytes hold exactly 8 base32 characters (40 bits)
+	b := make([]byte, ShortCodeLength*5/8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	var bits uint64
+	for _, v := range b {
+		bits = bits<<8 | uint64(v)
+	}
+
+	code := make([]byte, ShortCodeLength)
+	for i := ShortCodeLength - 1; i >= 0; i-- {
+		code[i] = crockfordAlphabet[bits&0x1f]
+		bits >>= 5
+	}
+	return string(code), nil
+}
+
+// NormalizeShortCode converts user input into the canonical form of a short code.
+// It is case-insensitive, ignores hyphens and spaces, and maps the ambiguous
+// characters I and L to 1 and O to 0 as Crockford base32 specifies.
+func NormalizeShortCode(input string) (string, error) {
+	code := make([]byte, 0, ShortCodeLength)
+	for _, r := range strings.ToUpper(input) {
+		switch {
+		case r == '-' || r == ' ':
+			continue
+		case r == 'I' || r == 'L':
+			r = '1'
+		case r == 'O':
+			r = '0'
+		}
+		if r > unicode.MaxASCII || strings.IndexByte(crockfordAlphabet, byte(r)) < 0 {
+			return "", ErrInvalidShortCode
+		}
+		code = append(code, byte(r))
+	}
+	if len(code) != ShortCodeLength {
+		return "", ErrInvalidShortCode
+	}
+	return string(code), nil
+}
+
 // VerifyEditToken verifies if the provided token matches the schedule's edit token
 func (s *Schedule) VerifyEditToken(token string) error {
 	if token == "" || s.EditToken != token {
@@ -126,6 +200,34 @@ func (s *Schedule) GetDaysUntilExpiry() int {
 	return days
 }
 
+// LastModified returns when the schedule content last changed,
+// falling back to CreatedAt for schedules that predate UpdatedAt
+func (s *Schedule) LastModified() time.Time {
+	if s.UpdatedAt.IsZero() {
+		return s.CreatedAt
+	}
+	return s.UpdatedAt
+}
+
+// Clone returns a deep copy of the schedule so callers can mutate it
+// without affecting the original
+func (s *Schedule) Clone() *Schedule {
+	if s == nil {
+		return nil
+	}
+
+	clone := *s
+	if s.TimeSlots != nil {
+		clone.TimeSlots = make([]TimeSlot, len(s.TimeSlots))
+		copy(clone.TimeSlots, s.TimeSlots)
+	}
+	if s.WebhookURLs != nil {
+		clone.WebhookURLs = make([]string, len(s.WebhookURLs))
+		copy(clone.WebhookURLs, s.WebhookURLs)
+	}
+	return &clone
+}
+
 // NewSchedule creates a new schedule with generated ID and edit token
 func NewSchedule() (*Schedule, error) {
 	id, err := GenerateUUID()
@@ -138,11 +240,18 @@ func NewSchedule() (*Schedule, error) {
 		return nil, err
 	}
 
+	code, err := GenerateShortCode()
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	return &Schedule{
 		ID:        id,
 		EditToken: token,
+		ShortCode: code,
 		CreatedAt: now,
+		UpdatedAt: now,
 		ExpiresAt: now.Add(7 * 24 * time.Hour),
 		TimeSlots: []TimeSlot{},
 	}, nil
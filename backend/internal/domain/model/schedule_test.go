@@ -59,6 +59,54 @@ func TestGenerateEditToken(t *testing.T) {
 	})
 }
 
+func TestGenerateShortCode(t *testing.T) {
+	t.Run("8文字のCrockford base32で生成される", func(t *testing.T) {
+		code, err := GenerateShortCode()
+		assert.NoError(t, err)
+		assert.Regexp(t, `^[0-9ABCDEFGHJKMNPQRSTVWXYZ]{8}$`, code)
+	})
+
+	t.Run("生成されるコードは毎回異なる", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			code, err := GenerateShortCode()
+			assert.NoError(t, err)
+			assert.False(t, seen[code], "duplicate code %s", code)
+			seen[code] = true
+		}
+	})
+}
+
+func TestNormalizeShortCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "正規の形式", input: "7K3M9QX2", want: "7K3M9QX2"},
+		{name: "小文字を大文字にする", input: "7k3m9qx2", want: "7K3M9QX2"},
+		{name: "ハイフンと空白を無視する", input: "7K3M-9QX2", want: "7K3M9QX2"},
+		{name: "紛らわしい文字を読み替える", input: "IL0O-abcd", want: "1100ABCD"},
+		{name: "Uは使えない", input: "7K3M9QXU", wantErr: true},
+		{name: "長さが足りない", input: "7K3M9QX", wantErr: true},
+		{name: "長すぎる", input: "7K3M9QX22", wantErr: true},
+		{name: "ASCII以外", input: "7K3M9QXあ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeShortCode(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidShortCode)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestVerifyEditToken(t *testing.T) {
 	// 編集トークン検証のテストケース
 	t.Run("正しいトークンで検証成功", func(t *testing.T) {
@@ -216,6 +264,7 @@ func TestNewSchedule(t *testing.T) {
 		assert.NotEmpty(t, schedule.ID)
 		assert.NotEmpty(t, schedule.EditToken)
 		assert.NotZero(t, schedule.CreatedAt)
+		assert.Equal(t, schedule.CreatedAt, schedule.UpdatedAt)
 		assert.NotZero(t, schedule.ExpiresAt)
 
 		// UUIDの形式チェック
@@ -224,6 +273,9 @@ func TestNewSchedule(t *testing.T) {
 		// 編集トークンの長さチェック
 		assert.Equal(t, 64, len(schedule.EditToken))
 
+		// 短縮コードが割り当てられている
+		assert.Len(t, schedule.ShortCode, ShortCodeLength)
+
 		// 有効期限が7日後であることを確認
 		expectedExpiry := schedule.CreatedAt.Add(7 * 24 * time.Hour)
 		assert.WithinDuration(t, expectedExpiry, schedule.ExpiresAt, 1*time.Second)
@@ -291,3 +343,46 @@ func TestSchedule_GetDaysUntilExpiry(t *testing.T) {
 		assert.Equal(t, 0, days)
 	})
 }
+
+func TestSchedule_Clone(t *testing.T) {
+	t.Run("コピーを変更しても元のスケジュールに影響しない", func(t *testing.T) {
+		now := time.Now()
+		original := &Schedule{
+			ID:        "test-id",
+			EditToken: "test-token",
+			Comment:   "元のコメント",
+			TimeSlots: []TimeSlot{
+				{StartTime: now, EndTime: now.Add(1 * time.Hour), Available: true},
+			},
+			CreatedAt: now,
+			ExpiresAt: now.Add(7 * 24 * time.Hour),
+		}
+
+		clone := original.Clone()
+		assert.Equal(t, original, clone)
+
+		clone.Comment = "変更後のコメント"
+		clone.TimeSlots[0].Available = false
+		clone.TimeSlots = append(clone.TimeSlots, TimeSlot{StartTime: now, EndTime: now})
+
+		assert.Equal(t, "元のコメント", original.Comment)
+		assert.True(t, original.TimeSlots[0].Available)
+		assert.Len(t, original.TimeSlots, 1)
+	})
+
+	t.Run("nilのスケジュールはnilを返す", func(t *testing.T) {
+		var schedule *Schedule
+		assert.Nil(t, schedule.Clone())
+	})
+}
+
+func TestSchedule_LastModified(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	// 更新日時が記録される前のスケジュールは作成日時を使う
+	schedule := &Schedule{CreatedAt: createdAt}
+	assert.Equal(t, createdAt, schedule.LastModified())
+
+	schedule.UpdatedAt = createdAt.Add(time.Hour)
+	assert.Equal(t, createdAt.Add(time.Hour), schedule.LastModified())
+}
@@ -2,11 +2,14 @@ package model
 
 import (
 	"fmt"
+	"sort"
 	"time"
 )
 
 type TimeSlotManager struct {
 	businessHours BusinessHours
+	// includeWeekends makes FreeSlots offer Saturdays and Sundays as well
+	includeWeekends bool
 }
 
 type ManagedTimeSlot struct {
@@ -78,4 +81,66 @@ func (tsm *TimeSlotManager) FilterBusinessHours(slots []ManagedTimeSlot) []Manag
 	}
 	
 	return filtered
-}
\ No newline at end of file
+}
+
+// SetIncludeWeekends controls whether FreeSlots offers Saturdays and Sundays (excluded by default)
+func (tsm *TimeSlotManager) SetIncludeWeekends(include bool) {
+	tsm.includeWeekends = include
+}
+
+// BusinessHoursOn returns the business hours of the given day in the day's location
+func (tsm *TimeSlotManager) BusinessHoursOn(day time.Time) ManagedTimeSlot {
+	y, m, d := day.Date()
+	loc := day.Location()
+	start := tsm.businessHours.Start
+	end := tsm.businessHours.End
+	return ManagedTimeSlot{
+		StartTime: time.Date(y, m, d, start.Hour(), start.Minute(), 0, 0, loc),
+		EndTime:   time.Date(y, m, d, end.Hour(), end.Minute(), 0, 0, loc),
+	}
+}
+
+// FreeSlots inverts busy periods into free slots within business hours for each day
+// between from and to (days are taken in from's location; busy periods may overlap)
+// Weekends are skipped unless the manager is set to include them
+func (tsm *TimeSlotManager) FreeSlots(busy []ManagedTimeSlot, from, to time.Time) []ManagedTimeSlot {
+	sorted := make([]ManagedTimeSlot, len(busy))
+	copy(sorted, busy)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	var free []ManagedTimeSlot
+	y, m, d := from.Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, from.Location()); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !tsm.includeWeekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		hours := tsm.BusinessHoursOn(day)
+		// Clip the business hours to the requested range
+		if hours.StartTime.Before(from) {
+			hours.StartTime = from
+		}
+		if hours.EndTime.After(to) {
+			hours.EndTime = to
+		}
+
+		cursor := hours.StartTime
+		for _, b := range sorted {
+			if !b.EndTime.After(cursor) {
+				continue
+			}
+			if !b.StartTime.Before(hours.EndTime) {
+				break
+			}
+			if b.StartTime.After(cursor) {
+				free = append(free, ManagedTimeSlot{StartTime: cursor, EndTime: b.StartTime})
+			}
+			cursor = b.EndTime
+		}
+		if cursor.Before(hours.EndTime) {
+			free = append(free, ManagedTimeSlot{StartTime: cursor, EndTime: hours.EndTime})
+		}
+	}
+	return free
+}
@@ -136,4 +136,52 @@ func TestTimeSlotManager_FilterBusinessHours(t *testing.T) {
 		filtered := manager.FilterBusinessHours(slots)
 		assert.Equal(t, 0, len(filtered))
 	})
-}
\ No newline at end of file
+}
+
+func TestTimeSlotManager_FreeSlots(t *testing.T) {
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, jst)
+	}
+	formats := func(slots []ManagedTimeSlot) []string {
+		var result []string
+		for _, slot := range slots {
+			result = append(result, slot.StartTime.Format("02 15:04")+"-"+slot.EndTime.Format("15:04"))
+		}
+		return result
+	}
+
+	t.Run("予定の無い日は営業時間全体が空きになる", func(t *testing.T) {
+		manager := NewTimeSlotManager()
+		slots := manager.FreeSlots(nil, at(20, 0, 0), at(22, 0, 0))
+		assert.Equal(t, []string{"20 09:00-18:00", "21 09:00-18:00"}, formats(slots))
+	})
+
+	t.Run("重なる予定や営業時間外にはみ出す予定を差し引く", func(t *testing.T) {
+		manager := NewTimeSlotManager()
+		busy := []ManagedTimeSlot{
+			{StartTime: at(20, 13, 0), EndTime: at(20, 14, 0)},
+			{StartTime: at(20, 8, 0), EndTime: at(20, 10, 0)},
+			{StartTime: at(20, 13, 30), EndTime: at(20, 15, 0)},
+			{StartTime: at(20, 17, 30), EndTime: at(21, 9, 30)},
+		}
+		slots := manager.FreeSlots(busy, at(20, 0, 0), at(22, 0, 0))
+		assert.Equal(t, []string{"20 10:00-13:00", "20 15:00-17:30", "21 09:30-18:00"}, formats(slots))
+	})
+
+	t.Run("範囲の途中から始まる場合は開始時刻で切り詰める", func(t *testing.T) {
+		manager := NewTimeSlotManager()
+		slots := manager.FreeSlots(nil, at(20, 12, 0), at(21, 12, 0))
+		assert.Equal(t, []string{"20 12:00-18:00", "21 09:00-12:00"}, formats(slots))
+	})
+
+	t.Run("土日は設定しない限り空きにしない", func(t *testing.T) {
+		manager := NewTimeSlotManager()
+		slots := manager.FreeSlots(nil, at(23, 0, 0), at(27, 0, 0))
+		assert.Equal(t, []string{"23 09:00-18:00", "26 09:00-18:00"}, formats(slots))
+
+		manager.SetIncludeWeekends(true)
+		slots = manager.FreeSlots(nil, at(23, 0, 0), at(27, 0, 0))
+		assert.Equal(t, []string{"23 09:00-18:00", "24 09:00-18:00", "25 09:00-18:00", "26 09:00-18:00"}, formats(slots))
+	})
+}
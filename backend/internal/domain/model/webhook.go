@@ -0,0 +1,94 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxWebhooks is the number of webhook URLs that can be registered on a schedule
+const MaxWebhooks = 5
+
+// maxWebhookURLLength bounds the length of a registered webhook URL
+const maxWebhookURLLength = 2048
+
+// ErrInvalidWebhook is returned when a webhook URL cannot be registered
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// ScheduleEvent is a lifecycle event of a schedule delivered to its webhooks
+type ScheduleEvent string
+
+const (
+	// EventScheduleCreated is emitted when a schedule is created
+	EventScheduleCreated ScheduleEvent = "schedule.created"
+	// EventScheduleUpdated is emitted when the time slots or comment are changed
+	EventScheduleUpdated ScheduleEvent = "schedule.updated"
+	// EventResponseSubmitted is emitted when a viewer submits a response to the schedule
+	EventResponseSubmitted ScheduleEvent = "schedule.response_submitted"
+	// EventScheduleExpiringSoon is emitted once when the schedule is about to expire
+	EventScheduleExpiringSoon ScheduleEvent = "schedule.expiring_soon"
+	// EventScheduleDeleted is emitted when the schedule is deleted by its creator
+	EventScheduleDeleted ScheduleEvent = "schedule.deleted"
+)
+
+// GenerateWebhookSecret generates a random key for signing webhook payloads
+func GenerateWebhookSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
+
+// SetWebhookURLs validates and replaces the webhook URLs of the schedule.
+// Duplicates are removed, and a signing secret is generated the first time
+// webhooks are registered so that it stays the same when the URLs change
+func (s *Schedule) SetWebhookURLs(urls []string) error {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, raw := range urls {
+		u, err := ValidateWebhookURL(raw)
+		if err != nil {
+			return err
+		}
+		if !seen[u] {
+			seen[u] = true
+			normalized = append(normalized, u)
+		}
+	}
+	if len(normalized) > MaxWebhooks {
+		return fmt.Errorf("%w: too many webhooks (max %d)", ErrInvalidWebhook, MaxWebhooks)
+	}
+
+	if len(normalized) > 0 && s.WebhookSecret == "" {
+		secret, err := GenerateWebhookSecret()
+		if err != nil {
+			return err
+		}
+		s.WebhookSecret = secret
+	}
+	s.WebhookURLs = normalized
+	return nil
+}
+
+// ValidateWebhookURL checks that raw is an absolute http(s) URL without
+// credentials and returns it in canonical form. Whether the host may be
+// reached is decided when the webhook is delivered
+func ValidateWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > maxWebhookURLLength {
+		return "", fmt.Errorf("%w: URL is too long (max %d characters)", ErrInvalidWebhook, maxWebhookURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return "", fmt.Errorf("%w: %q is not an absolute http or https URL", ErrInvalidWebhook, raw)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("%w: URL must not contain credentials", ErrInvalidWebhook)
+	}
+	u.Fragment = ""
+	return u.String(), nil
+}
@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/caldav"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/ical"
+)
+
+// maxCalDAVRequestSize はPROPFINDとREPORTの本文の最大サイズ
+const maxCalDAVRequestSize = 64 << 10
+
+// calDAVResourceSuffix はタイムスロットごとのカレンダーオブジェクトの名前の拡張子
+const calDAVResourceSuffix = ".ics"
+
+// calDAVAllow はCalDAVのルートで受け付けるメソッド
+const calDAVAllow = "OPTIONS, GET, HEAD, PROPFIND, REPORT"
+
+// CalDAVOptions はCalDAVに対応していることをクライアントに伝えるハンドラー
+func (h *ScheduleHandler) CalDAVOptions(c *gin.Context) {
+	c.Header("DAV", "1, calendar-access")
+	c.Header("Allow", calDAVAllow)
+	c.Status(http.StatusOK)
+}
+
+// CalDAVPropfind はスケジュールを読み取り専用のカレンダーコレクションとして見せるPROPFINDのハンドラー
+// コレクションの中には空き時間のタイムスロットごとに1件のカレンダーオブジェクトがある
+// Depthが0以外の場合はコレクションの中のカレンダーオブジェクトも返す
+func (h *ScheduleHandler) CalDAVPropfind(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalDAVRequestSize)
+	propfind, err := caldav.ParsePropfind(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	schedule, expired, ok := h.subscribableSchedule(c)
+	if !ok {
+		return
+	}
+
+	collection := calDAVCollectionHref(c)
+	slots := calDAVSlots(schedule, expired)
+	shareURL := h.ShareURL(schedule)
+
+	var responses []caldav.Response
+	if resource := c.Param("resource"); resource != "" {
+		slot, found := findSlotResource(slots, resource)
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "calendar object not found",
+			})
+			return
+		}
+		body := slotCalendar(schedule, slot, shareURL)
+		responses = append(responses, selectProps(collection+resource, calDAVResourceProps(body), propfind))
+	} else {
+		body := h.scheduleCalendar(schedule, expired)
+		responses = append(responses, selectProps(collection, calDAVCollectionProps(schedule, body), propfind))
+		if c.GetHeader("Depth") != "0" {
+			for _, slot := range slots {
+				body := slotCalendar(schedule, slot, shareURL)
+				responses = append(responses, selectProps(collection+slotResourceName(slot), calDAVResourceProps(body), propfind))
+			}
+		}
+	}
+
+	writeMultistatus(c, responses)
+}
+
+// CalDAVReport はカレンダーコレクションに対するREPORTのハンドラー
+// free-busy-queryは指定された期間の空き時間をVFREEBUSYで返し、
+// calendar-queryとcalendar-multigetは該当するカレンダーオブジェクトをmultistatusで返す
+func (h *ScheduleHandler) CalDAVReport(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalDAVRequestSize)
+	report, err := caldav.ParseReport(c.Request.Body)
+	if errors.Is(err, caldav.ErrUnsupportedReport) {
+		var b bytes.Buffer
+		caldav.WriteError(&b, caldav.DAV("supported-report"))
+		c.Data(http.StatusForbidden, caldav.ContentType, b.Bytes())
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	schedule, expired, ok := h.subscribableSchedule(c)
+	if !ok {
+		return
+	}
+
+	collection := calDAVCollectionHref(c)
+	slots := calDAVSlots(schedule, expired)
+	shareURL := h.ShareURL(schedule)
+
+	switch report.Type {
+	case caldav.ReportFreeBusyQuery:
+		c.Data(http.StatusOK, ical.ContentType, freeBusyCalendar(schedule, slots, report.TimeRange))
+	case caldav.ReportCalendarQuery:
+		var responses []caldav.Response
+		for _, slot := range slots {
+			if !report.TimeRange.Overlaps(slot.StartTime, slot.EndTime) {
+				continue
+			}
+			body := slotCalendar(schedule, slot, shareURL)
+			responses = append(responses, selectProps(collection+slotResourceName(slot), calDAVResourceProps(body), reportProps(report)))
+		}
+		writeMultistatus(c, responses)
+	case caldav.ReportCalendarMultiget:
+		var responses []caldav.Response
+		for _, href := range report.Hrefs {
+			resource, inCollection := strings.CutPrefix(href, collection)
+			slot, found := findSlotResource(slots, resource)
+			if !inCollection || !found {
+				responses = append(responses, caldav.Response{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			body := slotCalendar(schedule, slot, shareURL)
+			responses = append(responses, selectProps(href, calDAVResourceProps(body), reportProps(report)))
+		}
+		writeMultistatus(c, responses)
+	}
+}
+
+// CalDAVGetObject はタイムスロット1件分のカレンダーオブジェクトを返すハンドラー
+func (h *ScheduleHandler) CalDAVGetObject(c *gin.Context) {
+	schedule, expired, ok := h.subscribableSchedule(c)
+	if !ok {
+		return
+	}
+
+	slot, found := findSlotResource(calDAVSlots(schedule, expired), c.Param("resource"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "calendar object not found",
+		})
+		return
+	}
+
+	body := slotCalendar(schedule, slot, h.ShareURL(schedule))
+	etag := calendarETag(body)
+	lastModified := schedule.LastModified()
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", feedCacheControl)
+	if notModified(c, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, ical.ContentType, body)
+}
+
+// calDAVCollectionHref はリクエストのパスからコレクションのhrefを求める（末尾は/）
+func calDAVCollectionHref(c *gin.Context) string {
+	href := c.Request.URL.Path
+	if resource := c.Param("resource"); resource != "" {
+		href = strings.TrimSuffix(href, resource)
+	}
+	if !strings.HasSuffix(href, "/") {
+		href += "/"
+	}
+	return href
+}
+
+// calDAVSlots はコレクションに含めるタイムスロットを返す（失効したスケジュールは空）
+func calDAVSlots(schedule *model.Schedule, expired bool) []model.TimeSlot {
+	if expired {
+		return nil
+	}
+	return sortedTimeSlots(schedule.TimeSlots)
+}
+
+// slotResourceName はタイムスロットのカレンダーオブジェクトの名前を返す
+// フィードのUIDと同じく開始日時から作るため、編集されても変わらない枠は同じ名前になる
+func slotResourceName(slot model.TimeSlot) string {
+	return ical.FormatDateTime(slot.StartTime) + calDAVResourceSuffix
+}
+
+// findSlotResource は名前に対応するタイムスロットを探す
+func findSlotResource(slots []model.TimeSlot, resource string) (model.TimeSlot, bool) {
+	for _, slot := range slots {
+		if slotResourceName(slot) == resource {
+			return slot, true
+		}
+	}
+	return model.TimeSlot{}, false
+}
+
+// slotCalendar はタイムスロット1件分のVEVENTを含むカレンダーオブジェクトを作成する
+// CalDAVのカレンダーオブジェクトにはMETHODを含めない（RFC 4791 4.1）
+func slotCalendar(schedule *model.Schedule, slot model.TimeSlot, shareURL string) []byte {
+	w := ical.NewWriter()
+	w.Begin("VCALENDAR")
+	writeCalendarHeader(w)
+	writeSlotEvent(w, schedule, slot, shareURL)
+	w.End("VCALENDAR")
+	return w.Bytes()
+}
+
+// freeBusyCalendar はfree-busy-queryの期間に重なる空き時間を、期間に切り詰めたVFREEBUSYで返す
+// 期間の開始か終了が指定されていない場合は空き時間の範囲で補う
+func freeBusyCalendar(schedule *model.Schedule, slots []model.TimeSlot, tr *caldav.TimeRange) []byte {
+	var free []model.TimeSlot
+	for _, slot := range slots {
+		if !tr.Overlaps(slot.StartTime, slot.EndTime) {
+			continue
+		}
+		if !tr.Start.IsZero() && slot.StartTime.Before(tr.Start) {
+			slot.StartTime = tr.Start
+		}
+		if !tr.End.IsZero() && slot.EndTime.After(tr.End) {
+			slot.EndTime = tr.End
+		}
+		free = append(free, slot)
+	}
+
+	period := ical.Period{Start: tr.Start, End: tr.End}
+	if period.Start.IsZero() {
+		period.Start = time.Now()
+		if len(free) > 0 {
+			period.Start = free[0].StartTime
+		}
+	}
+	if period.End.IsZero() {
+		period.End = period.Start
+		for _, slot := range free {
+			if slot.EndTime.After(period.End) {
+				period.End = slot.EndTime
+			}
+		}
+	}
+
+	w := ical.NewWriter()
+	w.Begin("VCALENDAR")
+	writeCalendarHeader(w)
+	writeFreeBusy(w, schedule.ID, schedule.LastModified(), period, free)
+	w.End("VCALENDAR")
+	return w.Bytes()
+}
+
+// calDAVCollectionProps はカレンダーコレクションのプロパティを返す
+// getctagはカレンダー全体の内容から作るため、スケジュールが編集されると変わる
+// パスワード保護されたスケジュールのコメントは名前にも説明にも含めない
+func calDAVCollectionProps(schedule *model.Schedule, body []byte) []caldav.Property {
+	ctag := calendarETag(body)
+	components := `<C:comp name="VEVENT"/><C:comp name="VFREEBUSY"/>`
+	var reports string
+	for _, name := range []xml.Name{caldav.CalDAV("free-busy-query"), caldav.CalDAV("calendar-query"), caldav.CalDAV("calendar-multiget")} {
+		reports += caldav.Element(caldav.DAV("supported-report"), caldav.Element(caldav.DAV("report"), caldav.Element(name, "")))
+	}
+	privileges := caldav.Element(caldav.DAV("privilege"), caldav.Element(caldav.DAV("read"), "")) +
+		caldav.Element(caldav.DAV("privilege"), caldav.Element(caldav.CalDAV("read-free-busy"), ""))
+
+	return []caldav.Property{
+		caldav.XMLProperty(caldav.DAV("resourcetype"), caldav.Element(caldav.DAV("collection"), "")+caldav.Element(caldav.CalDAV("calendar"), "")),
+		caldav.TextProperty(caldav.DAV("displayname"), feedName(publicComment(schedule))),
+		caldav.TextProperty(caldav.CalDAV("calendar-description"), publicComment(schedule)),
+		caldav.XMLProperty(caldav.CalDAV("supported-calendar-component-set"), components),
+		caldav.XMLProperty(caldav.DAV("supported-report-set"), reports),
+		caldav.XMLProperty(caldav.DAV("current-user-privilege-set"), privileges),
+		caldav.TextProperty(caldav.DAV("getetag"), ctag),
+		caldav.TextProperty(caldav.CalendarServer("getctag"), ctag),
+	}
+}
+
+// calDAVResourceProps はカレンダーオブジェクトのプロパティを返す
+// calendar-dataはallpropでは返さない（RFC 4791 9.6）
+func calDAVResourceProps(body []byte) []caldav.Property {
+	return []caldav.Property{
+		caldav.XMLProperty(caldav.DAV("resourcetype"), ""),
+		caldav.TextProperty(caldav.DAV("getcontenttype"), ical.ContentType),
+		caldav.TextProperty(caldav.DAV("getcontentlength"), strconv.Itoa(len(body))),
+		caldav.TextProperty(caldav.DAV("getetag"), calendarETag(body)),
+		caldav.TextProperty(caldav.CalDAV("calendar-data"), string(body)),
+	}
+}
+
+// selectProps はPROPFINDやREPORTで要求されたプロパティを選び、存在しないものはMissingにする
+func selectProps(href string, props []caldav.Property, propfind *caldav.Propfind) caldav.Response {
+	response := caldav.Response{Href: href}
+	switch {
+	case propfind.PropName:
+		for _, p := range props {
+			response.Props = append(response.Props, caldav.Property{Name: p.Name})
+		}
+	case propfind.AllProp:
+		for _, p := range props {
+			if p.Name != caldav.CalDAV("calendar-data") {
+				response.Props = append(response.Props, p)
+			}
+		}
+	default:
+	next:
+		for _, name := range propfind.Props {
+			for _, p := range props {
+				if p.Name == name {
+					response.Props = append(response.Props, p)
+					continue next
+				}
+			}
+			response.Missing = append(response.Missing, name)
+		}
+	}
+	return response
+}
+
+// reportProps はREPORTで要求されたプロパティをPROPFINDと同じ形で返す
+// propが指定されていない場合はallpropとして扱う
+func reportProps(report *caldav.Report) *caldav.Propfind {
+	if len(report.Props) == 0 {
+		return &caldav.Propfind{AllProp: true}
+	}
+	return &caldav.Propfind{Props: report.Props}
+}
+
+// writeMultistatus は207 Multi-Statusのレスポンスを書き出す
+func writeMultistatus(c *gin.Context, responses []caldav.Response) {
+	var b bytes.Buffer
+	if err := caldav.WriteMultistatus(&b, responses); err != nil {
+		c.Error(err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusMultiStatus, caldav.ContentType, b.Bytes())
+}
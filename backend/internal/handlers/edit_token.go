@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EditTokenHeader は編集トークンを渡すためのヘッダー
+// Authorization: Bearer <token> も同じ意味で受け付ける
+const EditTokenHeader = "X-Edit-Token"
+
+// editTokenFromHeader はヘッダーから編集トークンを取り出す（指定されていない場合は空文字）
+// URLやボディではなくヘッダーで渡すことで、プロキシのログやブラウザの履歴にトークンが残らない
+func editTokenFromHeader(c *gin.Context) string {
+	if token := strings.TrimSpace(c.GetHeader(EditTokenHeader)); token != "" {
+		return token
+	}
+	scheme, token, ok := strings.Cut(strings.TrimSpace(c.GetHeader("Authorization")), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// hasBody はリクエストにボディがあるかを返す
+// ヘッダーでトークンを渡すDELETEはボディなしで送られる
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+)
+
+// errEmailDisabled はメール通知が無効なサーバーで通知先のアドレスを指定した場合に返される
+var errEmailDisabled = errors.New("email notifications are not enabled on this server")
+
+// setNotifyEmail は作成時に指定された通知先のアドレスと言語をスケジュールに設定する
+// 言語を指定しない場合はAccept-Languageが英語なら英語、それ以外は日本語にする
+func (h *ScheduleHandler) setNotifyEmail(c *gin.Context, schedule *model.Schedule, email, locale string) error {
+	if strings.TrimSpace(email) == "" {
+		return nil
+	}
+	if !h.config.EmailEnabled {
+		return errEmailDisabled
+	}
+	if locale == "" {
+		locale = localeFromAcceptLanguage(c.GetHeader("Accept-Language"))
+	}
+	return schedule.SetNotifyEmail(email, locale)
+}
+
+// localeFromAcceptLanguage は最も優先される言語が英語ならLocaleEnglish、それ以外はLocaleJapaneseを返す
+func localeFromAcceptLanguage(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	tag, _, _ := strings.Cut(first, ";")
+	if tag = strings.ToLower(strings.TrimSpace(tag)); tag == "en" || strings.HasPrefix(tag, "en-") {
+		return model.LocaleEnglish
+	}
+	return model.LocaleJapanese
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +14,8 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 	Code    string `json:"code,omitempty"`
+	// RetryAfter は再試行できるまでの秒数（429などで使う）
+	RetryAfter int `json:"retryAfter,omitempty"`
 }
 
 // エラーレスポンス用のヘルパー関数
@@ -61,4 +66,45 @@ func InternalServerError(c *gin.Context, message string) {
 		Message: message,
 		Code:    "INTERNAL_ERROR",
 	})
-}
\ No newline at end of file
+}
+
+// TooManyRequests は429とRetry-Afterヘッダーを返す
+func TooManyRequests(c *gin.Context, message string, retryAfter time.Duration) {
+	retryLater(c, message, "RATE_LIMITED", retryAfter)
+}
+
+// EditLocked は編集トークンの検証失敗が続いてスケジュールがロックされていることを返す
+// 正しいトークンを持つ利用者にも、しばらく待てば編集できることが分かるようにする
+func EditLocked(c *gin.Context, retryAfter time.Duration) {
+	retryLater(c, "編集トークンの検証に繰り返し失敗したため、このスケジュールの編集は一時的にロックされています", "EDIT_LOCKED", retryAfter)
+}
+
+// ViewLocked は閲覧パスワードの検証失敗が続いてパスワード入力がロックされていることを返す
+func ViewLocked(c *gin.Context, retryAfter time.Duration) {
+	retryLater(c, "閲覧パスワードの検証に繰り返し失敗したため、しばらくパスワードを入力できません", "VIEW_LOCKED", retryAfter)
+}
+
+// PasswordRequired はパスワード保護されたスケジュールに閲覧トークンなしでアクセスされたことを返す
+// codeは PASSWORD_REQUIRED（閲覧トークンがない・無効）または INVALID_PASSWORD（パスワードが違う）
+func PasswordRequired(c *gin.Context, code, message string) {
+	c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Error:   "Unauthorized",
+		Message: message,
+		Code:    code,
+	})
+}
+
+// retryLater は429とRetry-Afterヘッダー（秒単位に切り上げ）を返す
+func retryLater(c *gin.Context, message, code string, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, ErrorResponse{
+		Error:      "Too Many Requests",
+		Message:    message,
+		Code:       code,
+		RetryAfter: seconds,
+	})
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/ical"
+)
+
+// feedRefreshInterval はカレンダーアプリに伝える購読フィードの更新間隔
+const feedRefreshInterval = time.Hour
+
+// feedCacheControl は購読フィードのキャッシュ指定
+// 毎回ETagとLast-Modifiedで再検証させ、変更がなければ304で済ませる
+const feedCacheControl = "no-cache"
+
+// feedUIDDomain はフィード内のUIDに付けるドメイン部分
+const feedUIDDomain = "@kareru"
+
+// defaultFeedName はコメントが空のスケジュールのカレンダー名
+const defaultFeedName = "Kareru 空き時間"
+
+// ScheduleFeed はスケジュールの空き時間をiCalendar形式で返す購読用フィードのハンドラー
+// URLはスケジュールIDだけで決まるため、スキームをwebcal://に置き換えればカレンダーアプリで購読できる
+// 編集されると内容とETagが変わり、失効したスケジュールは予定を含まない空のカレンダーを返して購読先から取り除かせる
+func (h *ScheduleHandler) ScheduleFeed(c *gin.Context) {
+	schedule, expired, ok := h.subscribableSchedule(c)
+	if !ok {
+		return
+	}
+
+	body := h.scheduleCalendar(schedule, expired)
+	lastModified := feedLastModified(schedule, expired)
+	etag := calendarETag(body)
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", feedCacheControl)
+	if notModified(c, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, ical.ContentType, body)
+}
+
+// subscribableSchedule はURLのUUIDのスケジュールを取得し、カレンダーアプリに公開できるかを確認する
+// 公開できない場合はエラーを書き込んでokにfalseを返す。失効している場合はexpiredにtrueを返す
+// パスワード保護されたスケジュールは、失効していても存在と失効日時を明かさないよう先に拒否する
+func (h *ScheduleHandler) subscribableSchedule(c *gin.Context) (schedule *model.Schedule, expired bool, ok bool) {
+	uuid := c.Param("uuid")
+	if uuid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "uuid is required",
+		})
+		return nil, false, false
+	}
+
+	// スケジュールを取得
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
+		})
+		return nil, false, false
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
+		})
+		return nil, false, false
+	}
+
+	if schedule.HasViewPassword() {
+		// カレンダーアプリは閲覧トークンを送れないため、パスワード保護されたスケジュールは購読できない
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "Forbidden",
+			Message: "パスワード保護されたスケジュールは購読できません",
+			Code:    "FEED_NOT_AVAILABLE",
+		})
+		return nil, false, false
+	}
+	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
+		return schedule, true, true
+	}
+	return schedule, false, true
+}
+
+// feedLastModified はカレンダーとしての最終更新日時を返す（失効したスケジュールは失効した日時）
+func feedLastModified(schedule *model.Schedule, expired bool) time.Time {
+	if expired {
+		return schedule.ExpiresAt
+	}
+	return schedule.LastModified()
+}
+
+// calendarETag はカレンダーの内容から強いETagを作成する
+func calendarETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified は条件付きリクエストに対して304を返せるかを判定する
+// If-None-Matchが指定された場合はIf-Modified-Sinceより優先する（RFC 9110 13.2.2）
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		return etagMatches(header, etag)
+	}
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// scheduleCalendar はスケジュールの空き時間をVFREEBUSYとVEVENTで表したカレンダーを作成する
+// VFREEBUSYを解釈しないカレンダーアプリもあるため、同じ時間帯をVEVENTとしても含める
+func (h *ScheduleHandler) scheduleCalendar(schedule *model.Schedule, expired bool) []byte {
+	w := ical.NewWriter()
+	w.Begin("VCALENDAR")
+	writeCalendarHeader(w)
+	w.Line("METHOD", "PUBLISH")
+	w.Text("X-WR-CALNAME", feedName(publicComment(schedule)))
+	w.Line("REFRESH-INTERVAL;VALUE=DURATION", ical.FormatDuration(feedRefreshInterval))
+	w.Line("X-PUBLISHED-TTL", ical.FormatDuration(feedRefreshInterval))
+
+	slots := sortedTimeSlots(schedule.TimeSlots)
+	if !expired && len(slots) > 0 {
+		stamp := schedule.LastModified()
+		end := slots[0].EndTime
+		for _, slot := range slots[1:] {
+			if slot.EndTime.After(end) {
+				end = slot.EndTime
+			}
+		}
+		writeFreeBusy(w, schedule.ID, stamp, ical.Period{Start: slots[0].StartTime, End: end}, slots)
+
+		shareURL := h.ShareURL(schedule)
+		for _, slot := range slots {
+			writeSlotEvent(w, schedule, slot, shareURL)
+		}
+	}
+
+	w.End("VCALENDAR")
+	return w.Bytes()
+}
+
+// writeCalendarHeader はこのサービスが公開するカレンダーの共通のプロパティを書き出す
+func writeCalendarHeader(w *ical.Writer) {
+	w.Line("VERSION", "2.0")
+	w.Line("PRODID", ical.ProdID)
+	w.Line("CALSCALE", "GREGORIAN")
+}
+
+// slotUID はタイムスロットのVEVENTのUIDを返す
+// 開始日時は同じスケジュール内で重複しないため、編集されても変わらない枠は同じUIDになる
+func slotUID(id string, slot model.TimeSlot) string {
+	return id + "-" + ical.FormatDateTime(slot.StartTime) + feedUIDDomain
+}
+
+// writeSlotEvent はタイムスロットを時間を占有しないVEVENTとして書き出す
+func writeSlotEvent(w *ical.Writer, schedule *model.Schedule, slot model.TimeSlot, shareURL string) {
+	stamp := schedule.LastModified()
+	w.Begin("VEVENT")
+	w.Line("UID", slotUID(schedule.ID, slot))
+	w.DateTime("DTSTAMP", stamp)
+	w.DateTime("LAST-MODIFIED", stamp)
+	w.DateTime("DTSTART", slot.StartTime)
+	w.DateTime("DTEND", slot.EndTime)
+	w.Text("SUMMARY", "空き時間")
+	if comment := publicComment(schedule); comment != "" {
+		w.Text("DESCRIPTION", comment)
+	}
+	if shareURL != "" {
+		w.Line("URL", shareURL)
+	}
+	w.Line("TRANSP", "TRANSPARENT")
+	w.End("VEVENT")
+}
+
+// writeFreeBusy は開始日時順に並んだ空き時間をperiodの期間のVFREEBUSYとして書き出す
+func writeFreeBusy(w *ical.Writer, id string, stamp time.Time, period ical.Period, slots []model.TimeSlot) {
+	w.Begin("VFREEBUSY")
+	w.Line("UID", id+"-freebusy"+feedUIDDomain)
+	w.DateTime("DTSTAMP", stamp)
+	w.DateTime("DTSTART", period.Start)
+	w.DateTime("DTEND", period.End)
+	for _, slot := range slots {
+		w.Line("FREEBUSY;FBTYPE=FREE", ical.FormatPeriod(slot.StartTime, slot.EndTime))
+	}
+	w.End("VFREEBUSY")
+}
+
+// publicComment はカレンダーアプリに見せるコメントを返す
+// パスワード保護されたスケジュールのコメントは、パスワードを知らない人に見せないよう空にする
+func publicComment(schedule *model.Schedule) string {
+	if schedule.HasViewPassword() {
+		return ""
+	}
+	return schedule.Comment
+}
+
+// feedName はコメントの1行目をカレンダー名にする
+func feedName(comment string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(comment), "\n")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return defaultFeedName
+	}
+	return name
+}
+
+// sortedTimeSlots は開始日時順に並べたタイムスロットのコピーを返す
+func sortedTimeSlots(slots []model.TimeSlot) []model.TimeSlot {
+	sorted := make([]model.TimeSlot, len(slots))
+	copy(sorted, slots)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+	return sorted
+}
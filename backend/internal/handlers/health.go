@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pinger は疎通確認ができる依存先（リポジトリなど）のインターフェース
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ヘルスチェックのステータス
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+	HealthStatusDraining    = "draining"
+)
+
+// DependencyStatus は依存先ごとのチェック結果
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthResponse は /livez, /readyz のレスポンス
+type HealthResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
+type healthCheck struct {
+	name   string
+	pinger Pinger
+}
+
+// HealthHandler はliveness/readinessを返すハンドラー
+type HealthHandler struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	checks []healthCheck
+}
+
+// NewHealthHandler は各依存先のチェックをtimeoutで打ち切るハンドラーを作成する
+func NewHealthHandler(timeout time.Duration) *HealthHandler {
+	return &HealthHandler{timeout: timeout}
+}
+
+// AddCheck はreadinessで確認する依存先を追加する
+func (h *HealthHandler) AddCheck(name string, pinger Pinger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, healthCheck{name: name, pinger: pinger})
+}
+
+// SetDraining はシャットダウン中であることを設定する
+// 以降readinessは503を返し、ロードバランサーからの新規リクエストを止める
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Livez はプロセスが応答できるかのみを返す（依存先は確認しない）
+// GET /livez
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{Status: HealthStatusOK})
+}
+
+// Readyz は依存先を並行して確認し、全て正常な場合のみ200を返す
+// GET /readyz
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: HealthStatusDraining})
+		return
+	}
+
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	ctx := c.Request.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	results := make([]DependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			results[i] = ping(ctx, check.pinger)
+		}(i, check)
+	}
+	wg.Wait()
+
+	response := HealthResponse{
+		Status:       HealthStatusOK,
+		Dependencies: make(map[string]DependencyStatus, len(checks)),
+	}
+	for i, check := range checks {
+		if results[i].Status != HealthStatusOK {
+			response.Status = HealthStatusUnavailable
+		}
+		response.Dependencies[check.name] = results[i]
+	}
+
+	status := http.StatusOK
+	if response.Status != HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+func ping(ctx context.Context, pinger Pinger) DependencyStatus {
+	start := time.Now()
+
+	// コンテキストを無視する実装でもタイムアウトで打ち切る
+	done := make(chan error, 1)
+	go func() {
+		done <- pinger.Ping(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := DependencyStatus{
+		Status:    HealthStatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pingerFunc は関数をPingerとして使うためのテスト用の型
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+func performHealthRequest(t *testing.T, handler *HealthHandler, path string) (int, HealthResponse) {
+	t.Helper()
+	router := gin.New()
+	router.GET("/livez", handler.Livez)
+	router.GET("/readyz", handler.Readyz)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var response HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("依存先が全て正常ならreadyを返す", func(t *testing.T) {
+		handler := NewHealthHandler(time.Second)
+		handler.AddCheck("memory", pingerFunc(func(context.Context) error { return nil }))
+
+		code, response := performHealthRequest(t, handler, "/readyz")
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, HealthStatusOK, response.Status)
+		assert.Equal(t, HealthStatusOK, response.Dependencies["memory"].Status)
+		assert.Empty(t, response.Dependencies["memory"].Error)
+	})
+
+	t.Run("依存先のエラーは依存先ごとに報告し503を返す", func(t *testing.T) {
+		handler := NewHealthHandler(time.Second)
+		handler.AddCheck("cache", pingerFunc(func(context.Context) error { return nil }))
+		handler.AddCheck("firestore", pingerFunc(func(context.Context) error { return errors.New("connection refused") }))
+
+		code, response := performHealthRequest(t, handler, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, HealthStatusUnavailable, response.Status)
+		assert.Equal(t, HealthStatusOK, response.Dependencies["cache"].Status)
+		assert.Equal(t, HealthStatusUnavailable, response.Dependencies["firestore"].Status)
+		assert.Equal(t, "connection refused", response.Dependencies["firestore"].Error)
+	})
+
+	t.Run("応答しない依存先はタイムアウトで打ち切る", func(t *testing.T) {
+		block := make(chan struct{})
+		defer close(block)
+
+		handler := NewHealthHandler(50 * time.Millisecond)
+		handler.AddCheck("redis", pingerFunc(func(context.Context) error {
+			<-block
+			return nil
+		}))
+
+		start := time.Now()
+		code, response := performHealthRequest(t, handler, "/readyz")
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Contains(t, response.Dependencies["redis"].Error, context.DeadlineExceeded.Error())
+		assert.GreaterOrEqual(t, response.Dependencies["redis"].LatencyMs, float64(50))
+	})
+
+	t.Run("シャットダウン中はreadinessのみ503になる", func(t *testing.T) {
+		pinged := false
+		handler := NewHealthHandler(time.Second)
+		handler.AddCheck("memory", pingerFunc(func(context.Context) error {
+			pinged = true
+			return nil
+		}))
+		handler.SetDraining()
+
+		code, response := performHealthRequest(t, handler, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, HealthStatusDraining, response.Status)
+		assert.False(t, pinged)
+
+		code, response = performHealthRequest(t, handler, "/livez")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, HealthStatusOK, response.Status)
+	})
+
+	t.Run("livenessは依存先を確認しない", func(t *testing.T) {
+		handler := NewHealthHandler(time.Second)
+		handler.AddCheck("firestore", pingerFunc(func(context.Context) error { return errors.New("down") }))
+
+		code, response := performHealthRequest(t, handler, "/livez")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, response.Dependencies)
+	})
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/ical"
+)
+
+// .icsファイルの取り込みの制限と既定値
+const (
+	// MaxImportFileSize はアップロードできる.icsファイルの最大サイズ
+	MaxImportFileSize = 1 << 20
+	// MaxImportDays は空き時間を計算する期間の最大日数
+	MaxImportDays = 31
+	// defaultImportDays は期間が指定されなかった場合の日数（スケジュールの有効期間と同じ1週間）
+	defaultImportDays = 7
+	// defaultImportTimezone は営業時間やフローティングの日時を解釈するタイムゾーンの既定値
+	defaultImportTimezone = "Asia/Tokyo"
+	// defaultMinFreeMinutes はこれより短い空き時間を候補にしない既定の分数
+	defaultMinFreeMinutes = 30
+	// importSlotAlignment は当日の取り込みで現在時刻以降の空き時間をそろえる単位
+	importSlotAlignment = 30 * time.Minute
+)
+
+// importDateFormat は取り込み期間の日付の形式
+const importDateFormat = "2006-01-02"
+
+// ImportScheduleResponse は.icsファイルから作成したスケジュールのレスポンス
+type ImportScheduleResponse struct {
+	CreateScheduleResponse
+	// ImportedEvents はファイルに含まれていた予定の数
+	ImportedEvents int `json:"importedEvents"`
+	// SkippedEvents は繰り返しルールに対応していないため空き時間の計算に使わなかった予定のUID
+	SkippedEvents []string `json:"skippedEvents,omitempty"`
+}
+
+// importOptions は取り込みのフォーム項目
+type importOptions struct {
+	location    *time.Location
+	from        time.Time
+	to          time.Time
+	minDuration time.Duration
+	// includeWeekends が true の場合は土日の営業時間も空き時間にする
+	includeWeekends bool
+}
+
+// ImportSchedule はアップロードされた.icsファイルの予定から空き時間を計算してスケジュールを作成するハンドラー
+// multipart/form-dataで次の項目を受け付ける
+//   - file: .icsファイル（必須）
+//   - from, to: 期間の開始日と終了日（YYYY-MM-DD、終了日を含む。既定は今日から1週間）
+//   - timezone: IANAのタイムゾーン名（既定は Asia/Tokyo）
+//   - minDuration: 候補にする空き時間の最短の分数（既定は30）
+//   - includeWeekends: true の場合は土日も空き時間にする（既定は平日のみ）
+//   - comment, viewPassword, webhooks, email, locale: 通常の作成と同じ（webhooksは複数指定できる）
+//
+// 期間内の各日の営業時間から予定が入っている時間を除いた残りを空き時間とする
+func (h *ScheduleHandler) ImportSchedule(c *gin.Context) {
+	// フォームの他の項目の分だけ余裕を持たせる
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportFileSize+64*1024)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error:   "Request Entity Too Large",
+				Message: fmt.Sprintf("アップロードできるファイルは%dMBまでです", MaxImportFileSize>>20),
+				Code:    "FILE_TOO_LARGE",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "file is required",
+		})
+		return
+	}
+	if header.Size > MaxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error:   "Request Entity Too Large",
+			Message: fmt.Sprintf("アップロードできるファイルは%dMBまでです", MaxImportFileSize>>20),
+			Code:    "FILE_TOO_LARGE",
+		})
+		return
+	}
+
+	options, err := parseImportOptions(c, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	comment := c.PostForm("comment")
+	viewPassword := c.PostForm("viewPassword")
+	if err := h.validateLimits(0, comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := validateViewPassword(viewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	events, err := ical.Parse(file, options.location)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    "INVALID_CALENDAR",
+		})
+		return
+	}
+
+	busy, skipped, err := ical.BusyPeriods(events, options.from, options.to)
+	if errors.Is(err, ical.ErrTooManyOccurrences) {
+		// 予定や繰り返しが多すぎるカレンダーは、展開に時間がかかりすぎるため受け付けない
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    "CALENDAR_TOO_COMPLEX",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    "INVALID_CALENDAR",
+		})
+		return
+	}
+
+	timeSlots := freeTimeSlots(busy, options)
+	if len(timeSlots) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error:   "Unprocessable Entity",
+			Message: "指定した期間の営業時間内に空き時間が見つかりませんでした",
+			Code:    "NO_FREE_TIME",
+		})
+		return
+	}
+	if err := h.validateLimits(len(timeSlots), comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 新しいスケジュールを作成
+	schedule, err := model.NewSchedule()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create schedule",
+		})
+		return
+	}
+
+	schedule.TimeSlots = timeSlots
+	schedule.Comment = comment
+	schedule.ExpiresAt = schedule.CreatedAt.Add(h.config.Expiry)
+	if err := schedule.SetViewPassword(viewPassword); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create schedule",
+		})
+		return
+	}
+	if err := h.setWebhooks(schedule, c.PostFormArray("webhooks")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := h.setNotifyEmail(c, schedule, c.PostForm("email"), c.PostForm("locale")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// バリデーション
+	if err := schedule.ValidateTimeSlots(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// リポジトリに保存（短縮コードが衝突した場合は振り直す）
+	if err := h.createWithShortCode(c, schedule); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to save schedule",
+		})
+		return
+	}
+
+	response := ImportScheduleResponse{
+		CreateScheduleResponse: CreateScheduleResponse{
+			ID:                schedule.ID,
+			EditToken:         schedule.EditToken,
+			ShortCode:         schedule.ShortCode,
+			TimeSlots:         schedule.TimeSlots,
+			Comment:           schedule.Comment,
+			CreatedAt:         schedule.CreatedAt,
+			ExpiresAt:         schedule.ExpiresAt,
+			PasswordProtected: schedule.HasViewPassword(),
+			Webhooks:          schedule.WebhookURLs,
+			WebhookSecret:     schedule.WebhookSecret,
+			Email:             schedule.NotifyEmail,
+		},
+		ImportedEvents: len(events),
+	}
+	for _, event := range skipped {
+		response.SkippedEvents = append(response.SkippedEvents, event.UID)
+	}
+
+	h.notify(c, model.EventScheduleCreated, schedule)
+	c.JSON(http.StatusCreated, response)
+}
+
+// parseImportOptions はフォームから期間・タイムゾーン・最短の空き時間・土日を含めるかを読み取る
+// 期間は[from, to)で返し、過去の時間は含めない
+func parseImportOptions(c *gin.Context, now time.Time) (importOptions, error) {
+	var options importOptions
+
+	timezone := c.DefaultPostForm("timezone", defaultImportTimezone)
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return options, fmt.Errorf("unknown timezone %q", timezone)
+	}
+	options.location = loc
+
+	y, m, d := now.In(loc).Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if raw := c.PostForm("from"); raw != "" {
+		from, err = time.ParseInLocation(importDateFormat, raw, loc)
+		if err != nil {
+			return options, errors.New("from must be a date in YYYY-MM-DD format")
+		}
+	}
+	to := from.AddDate(0, 0, defaultImportDays)
+	if raw := c.PostForm("to"); raw != "" {
+		last, err := time.ParseInLocation(importDateFormat, raw, loc)
+		if err != nil {
+			return options, errors.New("to must be a date in YYYY-MM-DD format")
+		}
+		to = last.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return options, errors.New("to must not be before from")
+	}
+	if to.After(from.AddDate(0, 0, MaxImportDays)) {
+		return options, fmt.Errorf("the import window must be at most %d days", MaxImportDays)
+	}
+
+	// 過去の時間は候補にしないため、現在時刻を切り上げた時刻から始める
+	if earliest := alignUp(now, importSlotAlignment).In(loc); from.Before(earliest) {
+		from = earliest
+	}
+	if !from.Before(to) {
+		return options, errors.New("the import window must not be in the past")
+	}
+	options.from, options.to = from, to
+
+	options.minDuration = defaultMinFreeMinutes * time.Minute
+	if raw := c.PostForm("minDuration"); raw != "" {
+		minutes, err := strconv.Atoi(raw)
+		if err != nil || minutes < 1 || minutes > 24*60 {
+			return options, errors.New("minDuration must be a number of minutes between 1 and 1440")
+		}
+		options.minDuration = time.Duration(minutes) * time.Minute
+	}
+
+	if raw := c.PostForm("includeWeekends"); raw != "" {
+		options.includeWeekends, err = strconv.ParseBool(raw)
+		if err != nil {
+			return options, errors.New("includeWeekends must be true or false")
+		}
+	}
+	return options, nil
+}
+
+// freeTimeSlots は予定が入っている時間を営業時間から除き、短すぎる空き時間を取り除いたタイムスロットを返す
+func freeTimeSlots(busy []ical.Period, options importOptions) []model.TimeSlot {
+	managed := make([]model.ManagedTimeSlot, len(busy))
+	for i, period := range busy {
+		managed[i] = model.ManagedTimeSlot{StartTime: period.Start, EndTime: period.End}
+	}
+
+	manager := model.NewTimeSlotManager()
+	manager.SetIncludeWeekends(options.includeWeekends)
+
+	var slots []model.TimeSlot
+	for _, free := range manager.FreeSlots(managed, options.from, options.to) {
+		if free.EndTime.Sub(free.StartTime) < options.minDuration {
+			continue
+		}
+		slots = append(slots, model.TimeSlot{
+			StartTime: free.StartTime,
+			EndTime:   free.EndTime,
+		})
+	}
+	return slots
+}
+
+// alignUp はtをdの倍数の時刻に切り上げる
+func alignUp(t time.Time, d time.Duration) time.Time {
+	truncated := t.Truncate(d)
+	if truncated.Equal(t) {
+		return t
+	}
+	return truncated.Add(d)
+}
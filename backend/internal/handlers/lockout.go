@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+)
+
+// EditLockoutStatus はスケジュールごとの編集トークン検証の失敗状況
+type EditLockoutStatus struct {
+	// Failures は直近の連続した失敗回数
+	Failures int
+	// RetryAfter はロックが解除されるまでの時間（0の場合はロックされていない）
+	RetryAfter time.Duration
+}
+
+// Locked はロック中かを返す
+func (s EditLockoutStatus) Locked() bool {
+	return s.RetryAfter > 0
+}
+
+// EditLockout は編集トークンの総当たりを防ぐため、スケジュールごとに検証の失敗を記録する
+// 失敗が続いたスケジュールは一定時間ロックされ、正しいトークンでも編集できなくなる
+// 接続元を変えても同じスケジュールへの試行はまとめて数えるため、分散した総当たりも遅くできる
+// keyは編集トークンではスケジュールID、閲覧パスワードでは "view:" + スケジュールID
+//
+// パスに編集トークンを含む旧エンドポイント（/edit/:token）はトークンでスケジュールを引くため、
+// 失敗をスケジュールに結び付けられずロックの対象外。総当たりはルートのレート制限（Editの予算）で防ぐ
+type EditLockout interface {
+	// Status は現在の失敗状況を返す
+	Status(ctx context.Context, key string) (EditLockoutStatus, error)
+	// RecordFailure は検証の失敗を記録し、記録後の状況を返す
+	RecordFailure(ctx context.Context, key string) (EditLockoutStatus, error)
+	// Reset は検証に成功したときに失敗の記録を消す
+	Reset(ctx context.Context, key string) error
+}
+
+type noopEditLockout struct{}
+
+func (noopEditLockout) Status(context.Context, string) (EditLockoutStatus, error) {
+	return EditLockoutStatus{}, nil
+}
+func (noopEditLockout) RecordFailure(context.Context, string) (EditLockoutStatus, error) {
+	return EditLockoutStatus{}, nil
+}
+func (noopEditLockout) Reset(context.Context, string) error { return nil }
+
+// SetEditLockout は編集トークンの検証失敗を記録する先を設定する
+func (h *ScheduleHandler) SetEditLockout(lockout EditLockout) {
+	if lockout == nil {
+		lockout = noopEditLockout{}
+	}
+	h.lockout = lockout
+}
+
+// SetAuditLogger は監査ログの出力先を設定する（nilの場合はslogのデフォルト）
+func (h *ScheduleHandler) SetAuditLogger(logger *slog.Logger) {
+	h.auditLogger = logger
+}
+
+// audit はトークン検証に関するイベントを監査ログとして出力する
+// リクエストIDやトレースIDはコンテキストからロガーが付与する
+func (h *ScheduleHandler) audit(c *gin.Context, level slog.Level, event, scheduleID string, status EditLockoutStatus) {
+	logger := h.auditLogger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	attrs := []slog.Attr{
+		slog.String("event", event),
+		slog.String("schedule_id", scheduleID),
+		slog.String("client_ip", c.ClientIP()),
+		slog.Int("failures", status.Failures),
+	}
+	if status.Locked() {
+		attrs = append(attrs, slog.Int64("retry_after_s", int64(status.RetryAfter.Seconds())))
+	}
+	logger.LogAttrs(c.Request.Context(), level, "audit", attrs...)
+}
+
+// secretCheck はロック状態を確認しながら検証する秘密情報（編集トークンや閲覧パスワード）
+type secretCheck struct {
+	// key はロックの単位
+	key string
+	// event は監査ログのイベント名の接頭辞
+	event string
+	// verify は秘密情報を検証する
+	verify func() error
+	// failed は検証に失敗したときに呼ばれる
+	failed func()
+	// reject はロックされていない状態で検証に失敗したときのレスポンスを書き込む
+	reject func()
+	// locked はロック中のレスポンスを書き込む
+	locked func(retryAfter time.Duration)
+}
+
+// guardedVerify はロック状態を確認した上で秘密情報を検証する
+// 検証に失敗した場合はレスポンスを書き込んでfalseを返す
+// ロック中は秘密情報を検証せずに拒否し、正しいかどうかを試せないようにする
+// ロック状態のストアに障害がある場合は、利用できなくなるのを避けるため検証だけを行う
+func (h *ScheduleHandler) guardedVerify(c *gin.Context, scheduleID string, check secretCheck) bool {
+	ctx := c.Request.Context()
+
+	status, err := h.lockout.Status(ctx, check.key)
+	if err != nil {
+		c.Error(err)
+	}
+	if status.Locked() {
+		// ロック中の試行は数えず、ロックが延びないようにする
+		h.audit(c, slog.LevelWarn, check.event+".rejected_locked", scheduleID, status)
+		check.locked(status.RetryAfter)
+		return false
+	}
+
+	if err := check.verify(); err != nil {
+		check.failed()
+		status, err := h.lockout.RecordFailure(ctx, check.key)
+		if err != nil {
+			c.Error(err)
+		}
+		if status.Locked() {
+			h.audit(c, slog.LevelWarn, check.event+".locked", scheduleID, status)
+			check.locked(status.RetryAfter)
+			return false
+		}
+		h.audit(c, slog.LevelWarn, check.event+".failed", scheduleID, status)
+		check.reject()
+		return false
+	}
+
+	if status.Failures > 0 {
+		if err := h.lockout.Reset(ctx, check.key); err != nil {
+			c.Error(err)
+		}
+		h.audit(c, slog.LevelInfo, check.event+".verified_after_failures", scheduleID, status)
+	}
+	return true
+}
+
+// verifyEditToken はロック状態を確認した上で編集トークンを検証する
+// 検証に失敗した場合はレスポンスを書き込んでfalseを返す
+func (h *ScheduleHandler) verifyEditToken(c *gin.Context, schedule *model.Schedule, token string) bool {
+	return h.guardedVerify(c, schedule.ID, secretCheck{
+		key:    schedule.ID,
+		event:  "edit_token",
+		verify: func() error { return schedule.VerifyEditToken(token) },
+		failed: h.metrics.TokenVerificationFailed,
+		reject: func() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "invalid edit token",
+			})
+		},
+		locked: func(retryAfter time.Duration) { EditLocked(c, retryAfter) },
+	})
+}
+
+// verifyViewPassword はロック状態を確認した上で閲覧パスワードを検証する
+// 編集トークンとは別に失敗を数え、パスワードの総当たりで所有者の編集が止まらないようにする
+func (h *ScheduleHandler) verifyViewPassword(c *gin.Context, schedule *model.Schedule, password string) bool {
+	return h.guardedVerify(c, schedule.ID, secretCheck{
+		key:    "view:" + schedule.ID,
+		event:  "view_password",
+		verify: func() error { return schedule.VerifyViewPassword(password) },
+		failed: func() {},
+		reject: func() {
+			PasswordRequired(c, "INVALID_PASSWORD", "閲覧パスワードが正しくありません")
+		},
+		locked: func(retryAfter time.Duration) { ViewLocked(c, retryAfter) },
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+)
+
+// ScheduleNotifier はスケジュールのライフサイクルイベントの通知先
+// Notifyはレスポンスを遅らせないよう、配信を待たずに戻らなければならない
+type ScheduleNotifier interface {
+	Notify(ctx context.Context, event model.ScheduleEvent, schedule *model.Schedule)
+}
+
+// ResponseNotifier は閲覧者の回答の内容も受け取る通知先
+// 実装していない通知先には、回答を含めずに EventResponseSubmitted を通知する
+type ResponseNotifier interface {
+	NotifyResponse(ctx context.Context, schedule *model.Schedule, response *model.Response)
+}
+
+// ScheduleNotifiers は登録された順に全ての通知先に通知する
+type ScheduleNotifiers []ScheduleNotifier
+
+func (n ScheduleNotifiers) Notify(ctx context.Context, event model.ScheduleEvent, schedule *model.Schedule) {
+	for _, notifier := range n {
+		notifier.Notify(ctx, event, schedule)
+	}
+}
+
+func (n ScheduleNotifiers) NotifyResponse(ctx context.Context, schedule *model.Schedule, response *model.Response) {
+	for _, notifier := range n {
+		notifyResponse(ctx, notifier, schedule, response)
+	}
+}
+
+// notifyResponse は回答を受け取れる通知先には回答を、それ以外にはイベントだけを通知する
+func notifyResponse(ctx context.Context, notifier ScheduleNotifier, schedule *model.Schedule, response *model.Response) {
+	if rn, ok := notifier.(ResponseNotifier); ok {
+		rn.NotifyResponse(ctx, schedule, response)
+		return
+	}
+	notifier.Notify(ctx, model.EventResponseSubmitted, schedule)
+}
+
+type noopScheduleNotifier struct{}
+
+func (noopScheduleNotifier) Notify(context.Context, model.ScheduleEvent, *model.Schedule) {}
+
+// SetNotifier はスケジュールのイベントの通知先を設定する（nilの場合は通知しない）
+func (h *ScheduleHandler) SetNotifier(notifier ScheduleNotifier) {
+	if notifier == nil {
+		notifier = noopScheduleNotifier{}
+	}
+	h.notifier = notifier
+}
+
+// notify はスケジュールのイベントを通知する
+// 通知先が後から読んでも影響しないよう、スケジュールのコピーを渡す
+func (h *ScheduleHandler) notify(c *gin.Context, event model.ScheduleEvent, schedule *model.Schedule) {
+	h.notifier.Notify(c.Request.Context(), event, schedule.Clone())
+}
+
+// notifyResponse は閲覧者の回答を EventResponseSubmitted として通知する
+func (h *ScheduleHandler) notifyResponse(c *gin.Context, schedule *model.Schedule, response *model.Response) {
+	notifyResponse(c.Request.Context(), h.notifier, schedule.Clone(), response)
+}
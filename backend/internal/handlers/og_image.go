@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/ogp"
+)
+
+// ogImageCacheSize は描画済みOGP画像をメモリに保持する枚数（1枚あたり数十KB程度）
+const ogImageCacheSize = 256
+
+// ogImageCacheControl はOGP画像のキャッシュ指定
+// 内容が更新されるとETagが変わるため、短めに保持させて再検証は304で返す
+const ogImageCacheControl = "public, max-age=300"
+
+// ScheduleOGImage はリンクプレビュー用のOGP画像（1200x630のPNG）を返すハンドラー
+// 失効したスケジュールは410ではなく「期限切れ」の画像を返し、SNSのプレビューが壊れないようにする
+// パスワード保護されたスケジュールは内容を含まない画像を返す
+func (h *ScheduleHandler) ScheduleOGImage(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if uuid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "uuid is required",
+		})
+		return
+	}
+
+	// スケジュールを取得
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
+		})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
+		})
+		return
+	}
+
+	variant := ogp.VariantOf(schedule, time.Now())
+	if variant == ogp.VariantExpired {
+		h.metrics.ExpiredOnRead()
+	}
+
+	version := ogp.Version(schedule, variant)
+	etag := `"` + version + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", ogImageCacheControl)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	data, ok := h.ogCache.Get(version)
+	if !ok {
+		data, err = h.ogRenderer.Render(schedule, variant)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to render og image",
+			})
+			return
+		}
+		h.ogCache.Add(version, data)
+	}
+
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+// etagMatches はIf-None-Matchヘッダーに指定のETagが含まれるかを判定する
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
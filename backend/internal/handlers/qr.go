@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/qr"
+)
+
+// qrCacheControl はQRコード画像のキャッシュ指定
+// 内容は共有URLだけで決まるため、会議中に何度も表示されてもブラウザのキャッシュを使えるようにする
+const qrCacheControl = "public, max-age=3600"
+
+// ShareURL はスケジュールを共有する公開URLを返す（PublicBaseURLが未設定の場合は空）
+// 短縮コードが割り当てられていれば短縮URLを、そうでなければ閲覧ページのURLを返す
+func (h *ScheduleHandler) ShareURL(schedule *model.Schedule) string {
+	if h.config.PublicBaseURL == "" {
+		return ""
+	}
+	if schedule.ShortCode != "" {
+		base := h.config.ShortLinkBaseURL
+		if base == "" {
+			base = h.config.PublicBaseURL
+		}
+		return strings.TrimRight(base, "/") + ShortLinkPath(schedule.ShortCode)
+	}
+	return h.ScheduleURL(schedule.ID)
+}
+
+// ScheduleURL はスケジュールを閲覧するフロントエンドの公開URLを返す（PublicBaseURLが未設定の場合は空）
+func (h *ScheduleHandler) ScheduleURL(id string) string {
+	if h.config.PublicBaseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(h.config.PublicBaseURL, "/") + SchedulePath(id)
+}
+
+// EditURL はスケジュールを編集するフロントエンドの公開URLを返す（PublicBaseURLが未設定の場合は空）
+func (h *ScheduleHandler) EditURL(token string) string {
+	if h.config.PublicBaseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(h.config.PublicBaseURL, "/") + EditPath(token)
+}
+
+// ScheduleQRPNG は共有URLのQRコードをPNG画像で返すハンドラー
+func (h *ScheduleHandler) ScheduleQRPNG(c *gin.Context) {
+	h.scheduleQR(c, "image/png", qr.PNG)
+}
+
+// ScheduleQRSVG は共有URLのQRコードをSVG画像で返すハンドラー
+func (h *ScheduleHandler) ScheduleQRSVG(c *gin.Context) {
+	h.scheduleQR(c, "image/svg+xml", qr.SVG)
+}
+
+// scheduleQR はsize（px）とec（誤り訂正レベル L/M/Q/H）のクエリを受け付けてQRコードを描画する
+// QRコードに含めるのは閲覧ページのURLだけのため、パスワード保護されたスケジュールも閲覧トークンなしで生成できる
+func (h *ScheduleHandler) scheduleQR(c *gin.Context, contentType string, render func(string, qr.Level, int) ([]byte, error)) {
+	if h.config.PublicBaseURL == "" {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "Service Unavailable",
+			Message: "公開URLが設定されていないため、QRコードを生成できません",
+			Code:    "PUBLIC_BASE_URL_NOT_CONFIGURED",
+		})
+		return
+	}
+
+	uuid := c.Param("uuid")
+	if uuid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "uuid is required",
+		})
+		return
+	}
+
+	size, level, err := h.qrOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// スケジュールを取得
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
+		})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
+		})
+		return
+	}
+
+	// 失効チェック
+	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
+		c.JSON(http.StatusGone, gin.H{
+			"error": "schedule has expired",
+		})
+		return
+	}
+
+	data, err := render(h.ShareURL(schedule), level, size)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to render qr code",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", qrCacheControl)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// qrOptions はクエリからQRコードの画像サイズと誤り訂正レベルを読み取る
+func (h *ScheduleHandler) qrOptions(c *gin.Context) (int, qr.Level, error) {
+	size := h.config.QRDefaultSize
+	if raw := c.Query("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < qr.MinSize || parsed > h.config.QRMaxSize {
+			return 0, 0, fmt.Errorf("size must be an integer between %d and %d", qr.MinSize, h.config.QRMaxSize)
+		}
+		size = parsed
+	}
+
+	level := h.config.QRLevel
+	if raw := c.Query("ec"); raw != "" {
+		parsed, err := qr.ParseLevel(raw)
+		if err != nil {
+			return 0, 0, errors.New("ec must be one of L, M, Q or H")
+		}
+		level = parsed
+	}
+	return size, level, nil
+}
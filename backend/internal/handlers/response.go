@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+)
+
+// maxResponseRequestSize は回答の本文の最大サイズ
+const maxResponseRequestSize = 64 << 10
+
+// SubmitResponseRequest は閲覧者の回答リクエスト
+type SubmitResponseRequest struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	// TimeSlots は都合の良いタイムスロットの添字（GetScheduleResponseのtimeSlotsの順）
+	TimeSlots []int `json:"timeSlots"`
+}
+
+// SubmitResponseResponse は受け付けた回答
+type SubmitResponseResponse struct {
+	TimeSlots   []int     `json:"timeSlots"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+// SubmitResponse は閲覧者の回答を受け付け、作成者が登録したWebhookに通知するハンドラー
+// 回答は保存しないため、Webhookが登録されていないスケジュールでは受け付けない
+// パスワード保護されたスケジュールでは閲覧と同じく閲覧トークンが必要
+func (h *ScheduleHandler) SubmitResponse(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if uuid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "uuid is required",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxResponseRequestSize)
+	var req SubmitResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	// スケジュールを取得
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
+		})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
+		})
+		return
+	}
+
+	// 失効チェック
+	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
+		c.JSON(http.StatusGone, gin.H{
+			"error": "schedule has expired",
+		})
+		return
+	}
+
+	if schedule.HasViewPassword() && !h.verifyViewToken(c, schedule) {
+		return
+	}
+
+	if len(schedule.WebhookURLs) == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "schedule does not accept responses",
+		})
+		return
+	}
+
+	response, err := schedule.NewResponse(req.Name, req.Message, req.TimeSlots, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.notifyResponse(c, schedule, response)
+	c.JSON(http.StatusAccepted, SubmitResponseResponse{
+		TimeSlots:   response.TimeSlots,
+		SubmittedAt: response.SubmittedAt,
+	})
+}
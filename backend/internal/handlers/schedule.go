@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/ogp"
+	"kareru-backend/internal/qr"
+	"kareru-backend/internal/viewtoken"
 )
 
 // ScheduleRepository はスケジュール操作のインターフェース
@@ -13,20 +21,155 @@ type ScheduleRepository interface {
 	Create(schedule *model.Schedule) error
 	GetByID(id string) (*model.Schedule, error)
 	GetByEditToken(token string) (*model.Schedule, error)
+	GetByShortCode(code string) (*model.Schedule, error)
 	Update(schedule *model.Schedule) error
 	Delete(id string) error
 }
 
+// ContextBinder はリクエストのコンテキストを引き継いで操作できるリポジトリ
+// （トレースの親スパンやキャンセルをリポジトリの操作に伝えるために使う）
+type ContextBinder interface {
+	WithContext(ctx context.Context) ScheduleRepository
+}
+
+// ScheduleHandlerConfig はスケジュールハンドラーの設定
+type ScheduleHandlerConfig struct {
+	// Expiry は作成からスケジュールが失効するまでの期間
+	Expiry time.Duration
+	// MaxTimeSlots はタイムスロット数の上限（0は無制限）
+	MaxTimeSlots int
+	// MaxCommentLength はコメントの最大文字数（0は無制限）
+	MaxCommentLength int
+	// ViewTokenTTL はパスワード保護されたスケジュールの閲覧トークンの有効期間
+	ViewTokenTTL time.Duration
+	// PublicBaseURL は共有URLの基点となるフロントエンドの公開URL（空の場合QRコードを生成しない）
+	PublicBaseURL string
+	// ShortLinkBaseURL は短縮URL（/s/:code）の基点となる公開URL（空の場合はPublicBaseURL）
+	ShortLinkBaseURL string
+	// QRDefaultSize はサイズを指定しない場合のQRコードの画像サイズ（px）
+	QRDefaultSize int
+	// QRMaxSize は指定できるQRコードの画像サイズの上限（px）
+	QRMaxSize int
+	// QRLevel は指定しない場合のQRコードの誤り訂正レベル（ゼロ値はL）
+	QRLevel qr.Level
+	// WebhooksEnabled が true の場合、作成者がスケジュールにWebhookのURLを登録できる
+	WebhooksEnabled bool
+	// EmailEnabled が true の場合、作成者が共有URLと編集URLを受け取るメールアドレスを指定できる
+	EmailEnabled bool
+}
+
+// DefaultScheduleHandlerConfig はデフォルトの設定を返す
+func DefaultScheduleHandlerConfig() ScheduleHandlerConfig {
+	return ScheduleHandlerConfig{
+		Expiry:        7 * 24 * time.Hour,
+		ViewTokenTTL:  30 * time.Minute,
+		QRDefaultSize: 256,
+		QRMaxSize:     1024,
+		QRLevel:       qr.LevelM,
+	}
+}
+
+// 閲覧パスワードの長さの制限（ハッシュ計算の負荷を抑えるため上限も設ける）
+const (
+	MinViewPasswordLength = 4
+	MaxViewPasswordLength = 128
+)
+
+// ViewTokenHeader はパスワード保護されたスケジュールの閲覧トークンを渡すヘッダー
+const ViewTokenHeader = "X-View-Token"
+
+// ScheduleMetrics はハンドラー内で発生するイベントの計測先
+type ScheduleMetrics interface {
+	// ExpiredOnRead は失効済みのスケジュールにアクセスされたときに呼ばれる
+	ExpiredOnRead()
+	// TokenVerificationFailed は編集トークンの検証に失敗したときに呼ばれる
+	TokenVerificationFailed()
+}
+
+type noopScheduleMetrics struct{}
+
+func (noopScheduleMetrics) ExpiredOnRead()           {}
+func (noopScheduleMetrics) TokenVerificationFailed() {}
+
 // ScheduleHandler はスケジュール関連のHTTPハンドラー
 type ScheduleHandler struct {
-	repo ScheduleRepository
+	repo        ScheduleRepository
+	config      ScheduleHandlerConfig
+	metrics     ScheduleMetrics
+	lockout     EditLockout
+	auditLogger *slog.Logger
+	notifier    ScheduleNotifier
+	viewTokens  *viewtoken.Signer
+	ogRenderer  *ogp.Renderer
+	ogCache     *ogp.Cache
 }
 
-// NewScheduleHandler は新しいScheduleHandlerを作成
+// NewScheduleHandler はデフォルト設定で新しいScheduleHandlerを作成
 func NewScheduleHandler(repo ScheduleRepository) *ScheduleHandler {
+	return NewScheduleHandlerWithConfig(repo, DefaultScheduleHandlerConfig())
+}
+
+// NewScheduleHandlerWithConfig は指定した設定で新しいScheduleHandlerを作成
+func NewScheduleHandlerWithConfig(repo ScheduleRepository, config ScheduleHandlerConfig) *ScheduleHandler {
+	if config.Expiry <= 0 {
+		config.Expiry = DefaultScheduleHandlerConfig().Expiry
+	}
+	if config.ViewTokenTTL <= 0 {
+		config.ViewTokenTTL = DefaultScheduleHandlerConfig().ViewTokenTTL
+	}
+	if config.QRMaxSize <= 0 {
+		config.QRMaxSize = DefaultScheduleHandlerConfig().QRMaxSize
+	}
+	if config.QRDefaultSize <= 0 {
+		config.QRDefaultSize = min(DefaultScheduleHandlerConfig().QRDefaultSize, config.QRMaxSize)
+	}
+	// 署名鍵が設定されるまではプロセスごとのランダムな鍵を使う
+	viewTokens, err := viewtoken.NewRandomSigner(config.ViewTokenTTL)
+	if err != nil {
+		panic(fmt.Sprintf("handlers: failed to generate view token key: %v", err))
+	}
 	return &ScheduleHandler{
-		repo: repo,
+		repo:       repo,
+		config:     config,
+		metrics:    noopScheduleMetrics{},
+		lockout:    noopEditLockout{},
+		notifier:   noopScheduleNotifier{},
+		viewTokens: viewTokens,
+		ogRenderer: ogp.NewRenderer(),
+		ogCache:    ogp.NewCache(ogImageCacheSize),
+	}
+}
+
+// SetMetrics はイベントの計測先を設定する
+func (h *ScheduleHandler) SetMetrics(metrics ScheduleMetrics) {
+	if metrics == nil {
+		metrics = noopScheduleMetrics{}
+	}
+	h.metrics = metrics
+}
+
+// SetViewTokenKey は閲覧トークンの署名鍵を設定する（複数インスタンスで同じ鍵を使う）
+func (h *ScheduleHandler) SetViewTokenKey(key []byte) {
+	h.viewTokens = viewtoken.NewSigner(key, h.config.ViewTokenTTL)
+}
+
+// repository はリクエストのコンテキストを引き継いだリポジトリを返す
+func (h *ScheduleHandler) repository(c *gin.Context) ScheduleRepository {
+	if binder, ok := h.repo.(ContextBinder); ok {
+		return binder.WithContext(c.Request.Context())
+	}
+	return h.repo
+}
+
+// validateLimits はタイムスロット数とコメント長が設定の上限内かを確認する
+func (h *ScheduleHandler) validateLimits(timeSlotCount int, comment string) error {
+	if h.config.MaxTimeSlots > 0 && timeSlotCount > h.config.MaxTimeSlots {
+		return fmt.Errorf("too many time slots (max %d)", h.config.MaxTimeSlots)
+	}
+	if h.config.MaxCommentLength > 0 && utf8.RuneCountInString(comment) > h.config.MaxCommentLength {
+		return fmt.Errorf("comment is too long (max %d characters)", h.config.MaxCommentLength)
 	}
+	return nil
 }
 
 // CreateSchedule はスケジュール作成ハンドラー
@@ -39,6 +182,20 @@ func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 		return
 	}
 
+	if err := h.validateLimits(len(req.TimeSlots), req.Comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := validateViewPassword(req.ViewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// 新しいスケジュールを作成
 	schedule, err := model.NewSchedule()
 	if err != nil {
@@ -59,6 +216,26 @@ func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 
 	schedule.TimeSlots = timeSlots
 	schedule.Comment = req.Comment
+	schedule.ExpiresAt = schedule.CreatedAt.Add(h.config.Expiry)
+	if err := schedule.SetViewPassword(req.ViewPassword); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create schedule",
+		})
+		return
+	}
+	if err := h.setWebhooks(schedule, req.Webhooks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := h.setNotifyEmail(c, schedule, req.Email, req.Locale); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 
 	// バリデーション
 	if err := schedule.ValidateTimeSlots(); err != nil {
@@ -68,8 +245,9 @@ func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 		return
 	}
 
-	// リポジトリに保存
-	if err := h.repo.Create(schedule); err != nil {
+	// リポジトリに保存（短縮コードが衝突した場合は振り直す）
+	if err := h.createWithShortCode(c, schedule); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to save schedule",
 		})
@@ -78,14 +256,20 @@ func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 
 	// レスポンスを作成
 	response := CreateScheduleResponse{
-		ID:        schedule.ID,
-		EditToken: schedule.EditToken,
-		TimeSlots: schedule.TimeSlots,
-		Comment:   schedule.Comment,
-		CreatedAt: schedule.CreatedAt,
-		ExpiresAt: schedule.ExpiresAt,
-	}
-
+		ID:                schedule.ID,
+		EditToken:         schedule.EditToken,
+		ShortCode:         schedule.ShortCode,
+		TimeSlots:         schedule.TimeSlots,
+		Comment:           schedule.Comment,
+		CreatedAt:         schedule.CreatedAt,
+		ExpiresAt:         schedule.ExpiresAt,
+		PasswordProtected: schedule.HasViewPassword(),
+		Webhooks:          schedule.WebhookURLs,
+		WebhookSecret:     schedule.WebhookSecret,
+		Email:             schedule.NotifyEmail,
+	}
+
+	h.notify(c, model.EventScheduleCreated, schedule)
 	c.JSON(http.StatusCreated, response)
 }
 
@@ -100,36 +284,53 @@ func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
 	}
 
 	// スケジュールを取得
-	schedule, err := h.repo.GetByID(uuid)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to get schedule",
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
 		})
 		return
 	}
-
-	if schedule == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "schedule not found",
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
 		})
 		return
 	}
 
 	// 失効チェック
 	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
 		c.JSON(http.StatusGone, gin.H{
 			"error": "schedule has expired",
 		})
 		return
 	}
 
+	// ヘッダーで編集トークンが渡された場合は、編集画面を開く前の確認として検証する
+	// パスワード保護されている場合は、編集トークンを持つ作成者か閲覧トークンを持つ人だけが閲覧できる
+	token := editTokenFromHeader(c)
+	if token != "" {
+		if !h.verifyEditToken(c, schedule, token) {
+			return
+		}
+	} else if schedule.HasViewPassword() && !h.verifyViewToken(c, schedule) {
+		return
+	}
+
 	// レスポンスを作成（編集トークンは除外）
 	response := GetScheduleResponse{
-		ID:        schedule.ID,
-		TimeSlots: schedule.TimeSlots,
-		Comment:   schedule.Comment,
-		CreatedAt: schedule.CreatedAt,
-		ExpiresAt: schedule.ExpiresAt,
+		ID:                schedule.ID,
+		ShortCode:         schedule.ShortCode,
+		TimeSlots:         schedule.TimeSlots,
+		Comment:           schedule.Comment,
+		CreatedAt:         schedule.CreatedAt,
+		ExpiresAt:         schedule.ExpiresAt,
+		PasswordProtected: schedule.HasViewPassword(),
+	}
+	if token != "" {
+		response = editorView(response, schedule)
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -153,43 +354,52 @@ func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
 		return
 	}
 
-	// 編集トークンの確認
-	if req.EditToken == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "edit token is required",
+	if err := h.validateLimits(len(req.TimeSlots), req.Comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	// スケジュールを取得
-	schedule, err := h.repo.GetByID(uuid)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to get schedule",
+	// 編集トークンの確認（ヘッダーを優先し、後方互換のためボディのeditTokenも受け付ける）
+	editToken := editTokenFromHeader(c)
+	if editToken == "" {
+		editToken = req.EditToken
+	}
+	if editToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "edit token is required",
 		})
 		return
 	}
 
-	if schedule == nil {
+	// スケジュールを取得
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "schedule not found",
 		})
 		return
 	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
+		})
+		return
+	}
 
 	// 失効チェック
 	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
 		c.JSON(http.StatusGone, gin.H{
 			"error": "schedule has expired",
 		})
 		return
 	}
 
-	// 編集トークンの検証
-	if err := schedule.VerifyEditToken(req.EditToken); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "invalid edit token",
-		})
+	// 編集トークンの検証（失敗が続いたスケジュールは一時的にロックする）
+	if !h.verifyEditToken(c, schedule, editToken) {
 		return
 	}
 
@@ -205,6 +415,7 @@ func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
 	// スケジュールを更新
 	schedule.TimeSlots = timeSlots
 	schedule.Comment = req.Comment
+	schedule.UpdatedAt = time.Now()
 
 	// バリデーション
 	if err := schedule.ValidateTimeSlots(); err != nil {
@@ -213,47 +424,77 @@ func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
 		})
 		return
 	}
+	// webhooksが省略された場合は登録済みのURLを変更しない
+	if req.Webhooks != nil {
+		if err := h.setWebhooks(schedule, req.Webhooks); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
 
 	// リポジトリで更新
-	if err := h.repo.Update(schedule); err != nil {
+	if err := h.repository(c).Update(schedule); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to update schedule",
 		})
 		return
 	}
+	h.notify(c, model.EventScheduleUpdated, schedule)
 
 	// レスポンスを作成（編集トークンは除外）
 	response := GetScheduleResponse{
-		ID:        schedule.ID,
-		TimeSlots: schedule.TimeSlots,
-		Comment:   schedule.Comment,
-		CreatedAt: schedule.CreatedAt,
-		ExpiresAt: schedule.ExpiresAt,
+		ID:                schedule.ID,
+		ShortCode:         schedule.ShortCode,
+		TimeSlots:         schedule.TimeSlots,
+		Comment:           schedule.Comment,
+		CreatedAt:         schedule.CreatedAt,
+		ExpiresAt:         schedule.ExpiresAt,
+		PasswordProtected: schedule.HasViewPassword(),
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, editorView(response, schedule))
 }
 
 // UpdateScheduleRequest はスケジュール更新リクエスト
 type UpdateScheduleRequest struct {
+	// EditToken は後方互換のためのフィールド。X-Edit-TokenまたはAuthorizationヘッダーでの指定を推奨する
 	EditToken string            `json:"editToken"`
 	TimeSlots []TimeSlotRequest `json:"timeSlots"`
 	Comment   string            `json:"comment"`
+	// Webhooks は登録するWebhookのURL（省略した場合は変更せず、空の配列で全て解除する）
+	Webhooks []string `json:"webhooks"`
 }
 
 // GetScheduleResponse はスケジュール取得レスポンス
 type GetScheduleResponse struct {
 	ID        string           `json:"id"`
+	ShortCode string           `json:"shortCode,omitempty"`
 	TimeSlots []model.TimeSlot `json:"timeSlots"`
 	Comment   string           `json:"comment"`
 	CreatedAt time.Time        `json:"createdAt"`
 	ExpiresAt time.Time        `json:"expiresAt"`
+	// PasswordProtected は閲覧にパスワードが必要か
+	PasswordProtected bool `json:"passwordProtected,omitempty"`
+	// Webhooks と WebhookSecret は編集トークンで取得・更新した場合にだけ返す
+	Webhooks      []string `json:"webhooks,omitempty"`
+	WebhookSecret string   `json:"webhookSecret,omitempty"`
 }
 
 // CreateScheduleRequest はスケジュール作成リクエスト
 type CreateScheduleRequest struct {
 	TimeSlots []TimeSlotRequest `json:"timeSlots"`
 	Comment   string            `json:"comment"`
+	// ViewPassword を指定すると、閲覧にパスワードが必要になる
+	ViewPassword string `json:"viewPassword,omitempty"`
+	// Webhooks はライフサイクルイベントを通知するURL
+	Webhooks []string `json:"webhooks,omitempty"`
+	// Email を指定すると、共有URLと編集URL（リマインダーが有効な場合は失効前のリマインダーも）がメールで届く
+	Email string `json:"email,omitempty"`
+	// Locale はメールの言語（ja または en、省略した場合はAccept-Languageから決める）
+	Locale string `json:"locale,omitempty"`
 }
 
 type TimeSlotRequest struct {
@@ -265,10 +506,18 @@ type TimeSlotRequest struct {
 type CreateScheduleResponse struct {
 	ID        string           `json:"id"`
 	EditToken string           `json:"editToken"`
+	ShortCode string           `json:"shortCode,omitempty"`
 	TimeSlots []model.TimeSlot `json:"timeSlots"`
 	Comment   string           `json:"comment"`
 	CreatedAt time.Time        `json:"createdAt"`
 	ExpiresAt time.Time        `json:"expiresAt"`
+	// PasswordProtected は閲覧にパスワードが必要か
+	PasswordProtected bool `json:"passwordProtected,omitempty"`
+	// Webhooks は登録されたWebhookのURL、WebhookSecret はその署名の検証に使う鍵
+	Webhooks      []string `json:"webhooks,omitempty"`
+	WebhookSecret string   `json:"webhookSecret,omitempty"`
+	// Email は共有URLと編集URLを送るアドレス（指定されなかった場合は省略）
+	Email string `json:"email,omitempty"`
 }
 
 // DeleteSchedule はスケジュール削除ハンドラー
@@ -281,16 +530,23 @@ func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
 		return
 	}
 
+	// ヘッダーでトークンを渡す場合はボディを省略できる
 	var req DeleteScheduleRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid request body",
-		})
-		return
+	if hasBody(c.Request) {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid request body",
+			})
+			return
+		}
 	}
 
-	// 編集トークンの確認
-	if req.EditToken == "" {
+	// 編集トークンの確認（ヘッダーを優先し、後方互換のためボディのeditTokenも受け付ける）
+	editToken := editTokenFromHeader(c)
+	if editToken == "" {
+		editToken = req.EditToken
+	}
+	if editToken == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "edit token is required",
 		})
@@ -298,50 +554,51 @@ func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
 	}
 
 	// スケジュールを取得
-	schedule, err := h.repo.GetByID(uuid)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to get schedule",
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
 		})
 		return
 	}
-
-	if schedule == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "schedule not found",
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
 		})
 		return
 	}
 
 	// 失効チェック
 	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
 		c.JSON(http.StatusGone, gin.H{
 			"error": "schedule has expired",
 		})
 		return
 	}
 
-	// 編集トークンの検証
-	if err := schedule.VerifyEditToken(req.EditToken); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "invalid edit token",
-		})
+	// 編集トークンの検証（失敗が続いたスケジュールは一時的にロックする）
+	if !h.verifyEditToken(c, schedule, editToken) {
 		return
 	}
 
 	// スケジュールを削除
-	if err := h.repo.Delete(uuid); err != nil {
+	if err := h.repository(c).Delete(uuid); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to delete schedule",
 		})
 		return
 	}
+	h.notify(c, model.EventScheduleDeleted, schedule)
 
 	c.JSON(http.StatusNoContent, nil)
 }
 
 // DeleteScheduleRequest はスケジュール削除リクエスト
 type DeleteScheduleRequest struct {
+	// EditToken は後方互換のためのフィールド。X-Edit-TokenまたはAuthorizationヘッダーでの指定を推奨する
 	EditToken string `json:"editToken"`
 }
 
@@ -356,8 +613,10 @@ func (h *ScheduleHandler) GetScheduleByEditToken(c *gin.Context) {
 	}
 
 	// 編集トークンでスケジュールを取得
-	schedule, err := h.repo.GetByEditToken(token)
+	schedule, err := h.repository(c).GetByEditToken(token)
 	if err != nil {
+		c.Error(err)
+		h.metrics.TokenVerificationFailed()
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "編集権限がありません",
 		})
@@ -373,6 +632,7 @@ func (h *ScheduleHandler) GetScheduleByEditToken(c *gin.Context) {
 
 	// 失効チェック
 	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
 		c.JSON(http.StatusGone, gin.H{
 			"error": "schedule has expired",
 		})
@@ -381,14 +641,16 @@ func (h *ScheduleHandler) GetScheduleByEditToken(c *gin.Context) {
 
 	// レスポンスを作成
 	response := GetScheduleResponse{
-		ID:        schedule.ID,
-		TimeSlots: schedule.TimeSlots,
-		Comment:   schedule.Comment,
-		CreatedAt: schedule.CreatedAt,
-		ExpiresAt: schedule.ExpiresAt,
+		ID:                schedule.ID,
+		ShortCode:         schedule.ShortCode,
+		TimeSlots:         schedule.TimeSlots,
+		Comment:           schedule.Comment,
+		CreatedAt:         schedule.CreatedAt,
+		ExpiresAt:         schedule.ExpiresAt,
+		PasswordProtected: schedule.HasViewPassword(),
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, editorView(response, schedule))
 }
 
 // UpdateScheduleByEditToken は編集トークンでスケジュール更新ハンドラー
@@ -409,9 +671,18 @@ func (h *ScheduleHandler) UpdateScheduleByEditToken(c *gin.Context) {
 		return
 	}
 
+	if err := h.validateLimits(len(req.TimeSlots), req.Comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// 編集トークンでスケジュールを取得
-	schedule, err := h.repo.GetByEditToken(token)
+	schedule, err := h.repository(c).GetByEditToken(token)
 	if err != nil {
+		c.Error(err)
+		h.metrics.TokenVerificationFailed()
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "編集権限がありません",
 		})
@@ -427,6 +698,7 @@ func (h *ScheduleHandler) UpdateScheduleByEditToken(c *gin.Context) {
 
 	// 失効チェック
 	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
 		c.JSON(http.StatusGone, gin.H{
 			"error": "schedule has expired",
 		})
@@ -446,6 +718,7 @@ func (h *ScheduleHandler) UpdateScheduleByEditToken(c *gin.Context) {
 	// スケジュールを更新
 	schedule.TimeSlots = timeSlots
 	schedule.Comment = req.Comment
+	schedule.UpdatedAt = time.Now()
 
 	// バリデーション
 	if err := schedule.ValidateTimeSlots(); err != nil {
@@ -454,25 +727,38 @@ func (h *ScheduleHandler) UpdateScheduleByEditToken(c *gin.Context) {
 		})
 		return
 	}
+	// webhooksが省略された場合は登録済みのURLを変更しない
+	if req.Webhooks != nil {
+		if err := h.setWebhooks(schedule, req.Webhooks); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
 
 	// リポジトリで更新
-	if err := h.repo.Update(schedule); err != nil {
+	if err := h.repository(c).Update(schedule); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to update schedule",
 		})
 		return
 	}
+	h.notify(c, model.EventScheduleUpdated, schedule)
 
 	// レスポンスを作成
 	response := GetScheduleResponse{
-		ID:        schedule.ID,
-		TimeSlots: schedule.TimeSlots,
-		Comment:   schedule.Comment,
-		CreatedAt: schedule.CreatedAt,
-		ExpiresAt: schedule.ExpiresAt,
+		ID:                schedule.ID,
+		ShortCode:         schedule.ShortCode,
+		TimeSlots:         schedule.TimeSlots,
+		Comment:           schedule.Comment,
+		CreatedAt:         schedule.CreatedAt,
+		ExpiresAt:         schedule.ExpiresAt,
+		PasswordProtected: schedule.HasViewPassword(),
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, editorView(response, schedule))
 }
 
 // DeleteScheduleByEditToken は編集トークンでスケジュール削除ハンドラー
@@ -486,8 +772,10 @@ func (h *ScheduleHandler) DeleteScheduleByEditToken(c *gin.Context) {
 	}
 
 	// 編集トークンでスケジュールを取得
-	schedule, err := h.repo.GetByEditToken(token)
+	schedule, err := h.repository(c).GetByEditToken(token)
 	if err != nil {
+		c.Error(err)
+		h.metrics.TokenVerificationFailed()
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "編集権限がありません",
 		})
@@ -503,6 +791,7 @@ func (h *ScheduleHandler) DeleteScheduleByEditToken(c *gin.Context) {
 
 	// 失効チェック
 	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
 		c.JSON(http.StatusGone, gin.H{
 			"error": "schedule has expired",
 		})
@@ -510,12 +799,14 @@ func (h *ScheduleHandler) DeleteScheduleByEditToken(c *gin.Context) {
 	}
 
 	// スケジュールを削除
-	if err := h.repo.Delete(schedule.ID); err != nil {
+	if err := h.repository(c).Delete(schedule.ID); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to delete schedule",
 		})
 		return
 	}
+	h.notify(c, model.EventScheduleDeleted, schedule)
 
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -524,6 +815,8 @@ func (h *ScheduleHandler) DeleteScheduleByEditToken(c *gin.Context) {
 type UpdateScheduleByEditTokenRequest struct {
 	TimeSlots []EditTimeSlotRequest `json:"timeSlots"`
 	Comment   string                `json:"comment"`
+	// Webhooks は登録するWebhookのURL（省略した場合は変更せず、空の配列で全て解除する）
+	Webhooks []string `json:"webhooks"`
 }
 
 type EditTimeSlotRequest struct {
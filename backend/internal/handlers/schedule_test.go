@@ -2,15 +2,27 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/ical"
 )
 
 // MockScheduleRepository はテスト用のモックリポジトリ
@@ -67,6 +79,18 @@ func (m *MockScheduleRepository) GetByEditToken(token string) (*model.Schedule,
 	return nil, nil
 }
 
+func (m *MockScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	for _, schedule := range m.schedules {
+		if code != "" && schedule.ShortCode == code {
+			return schedule, nil
+		}
+	}
+	return nil, model.ErrScheduleNotFound
+}
+
 func (m *MockScheduleRepository) Delete(id string) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
@@ -368,3 +392,1662 @@ func TestDeleteSchedule(t *testing.T) {
 }
 
 
+
+// notFoundScheduleRepository は実際のリポジトリと同じく、存在しないIDにErrScheduleNotFoundを返す
+type notFoundScheduleRepository struct {
+	*MockScheduleRepository
+}
+
+func (r *notFoundScheduleRepository) GetByID(id string) (*model.Schedule, error) {
+	return nil, model.ErrScheduleNotFound
+}
+
+func TestScheduleNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewScheduleHandler(&notFoundScheduleRepository{NewMockScheduleRepository()})
+	router := gin.New()
+	var loggedErrors []string
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		loggedErrors = append(loggedErrors, c.Errors.Errors()...)
+	})
+	router.GET("/schedules/:uuid", handler.GetSchedule)
+	router.PUT("/schedules/:uuid", handler.UpdateSchedule)
+	router.DELETE("/schedules/:uuid", handler.DeleteSchedule)
+	router.POST("/schedules/:uuid/unlock", handler.UnlockSchedule)
+
+	for _, tt := range []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{http.MethodGet, "/schedules/missing", nil},
+		{http.MethodPut, "/schedules/missing", UpdateScheduleRequest{EditToken: "token"}},
+		{http.MethodDelete, "/schedules/missing", DeleteScheduleRequest{EditToken: "token"}},
+		{http.MethodPost, "/schedules/missing/unlock", UnlockScheduleRequest{Password: "password"}},
+	} {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			loggedErrors = nil
+			var body io.Reader
+			if tt.body != nil {
+				b, _ := json.Marshal(tt.body)
+				body = bytes.NewReader(b)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, body))
+
+			// 存在しないスケジュールはサーバーのエラーとして記録しない
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Contains(t, w.Body.String(), "schedule not found")
+			assert.Empty(t, loggedErrors)
+		})
+	}
+}
+
+func TestScheduleHandlerConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(config ScheduleHandlerConfig) (*gin.Engine, *MockScheduleRepository) {
+		router := gin.New()
+		mockRepo := NewMockScheduleRepository()
+		handler := NewScheduleHandlerWithConfig(mockRepo, config)
+		router.POST("/schedules", handler.CreateSchedule)
+		router.PUT("/schedules/edit/:token", handler.UpdateScheduleByEditToken)
+		return router, mockRepo
+	}
+
+	t.Run("設定した有効期限でスケジュールが作成される", func(t *testing.T) {
+		router, _ := newRouter(ScheduleHandlerConfig{Expiry: 48 * time.Hour})
+
+		body, _ := json.Marshal(CreateScheduleRequest{Comment: "期限テスト"})
+		req := httptest.NewRequest(http.MethodPost, "/schedules", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response CreateScheduleResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 48*time.Hour, response.ExpiresAt.Sub(response.CreatedAt))
+	})
+
+	t.Run("タイムスロット数の上限を超えると400を返す", func(t *testing.T) {
+		router, mockRepo := newRouter(ScheduleHandlerConfig{MaxTimeSlots: 1})
+
+		now := time.Now()
+		body, _ := json.Marshal(CreateScheduleRequest{
+			TimeSlots: []TimeSlotRequest{
+				{StartTime: now.Add(1 * time.Hour), EndTime: now.Add(2 * time.Hour)},
+				{StartTime: now.Add(3 * time.Hour), EndTime: now.Add(4 * time.Hour)},
+			},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/schedules", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "too many time slots")
+		assert.Empty(t, mockRepo.schedules)
+	})
+
+	t.Run("コメントの文字数上限を超えると400を返す", func(t *testing.T) {
+		router, mockRepo := newRouter(ScheduleHandlerConfig{MaxCommentLength: 3})
+		mockRepo.schedules["limit-uuid"] = &model.Schedule{
+			ID:        "limit-uuid",
+			EditToken: "limit-token",
+			Comment:   "元",
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+		}
+
+		body, _ := json.Marshal(UpdateScheduleByEditTokenRequest{Comment: "四文字だ"})
+		req := httptest.NewRequest(http.MethodPut, "/schedules/edit/limit-token", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "comment is too long")
+		assert.Equal(t, "元", mockRepo.schedules["limit-uuid"].Comment)
+
+		body, _ = json.Marshal(UpdateScheduleByEditTokenRequest{Comment: "三文字"})
+		req = httptest.NewRequest(http.MethodPut, "/schedules/edit/limit-token", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+// recordingMetrics はハンドラーから通知されたイベントを数えるテスト用の計測先
+type recordingMetrics struct {
+	expired      int
+	tokenFailure int
+}
+
+func (r *recordingMetrics) ExpiredOnRead()           { r.expired++ }
+func (r *recordingMetrics) TokenVerificationFailed() { r.tokenFailure++ }
+
+func TestScheduleHandlerMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	mockRepo := NewMockScheduleRepository()
+	handler := NewScheduleHandler(mockRepo)
+	metrics := &recordingMetrics{}
+	handler.SetMetrics(metrics)
+	router.GET("/schedules/:uuid", handler.GetSchedule)
+	router.PUT("/schedules/:uuid", handler.UpdateSchedule)
+
+	mockRepo.schedules["expired-uuid"] = &model.Schedule{
+		ID:        "expired-uuid",
+		EditToken: "expired-token",
+		CreatedAt: time.Now().Add(-8 * 24 * time.Hour),
+		ExpiresAt: time.Now().Add(-24 * time.Hour),
+	}
+	mockRepo.schedules["active-uuid"] = &model.Schedule{
+		ID:        "active-uuid",
+		EditToken: "active-token",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schedules/expired-uuid", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Equal(t, 1, metrics.expired)
+
+	body, _ := json.Marshal(UpdateScheduleRequest{EditToken: "wrong-token"})
+	req := httptest.NewRequest(http.MethodPut, "/schedules/active-uuid", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, 1, metrics.tokenFailure)
+}
+
+// fakeEditLockout は失敗回数が上限に達するとロックするテスト用のEditLockout
+type fakeEditLockout struct {
+	maxAttempts int
+	failures    map[string]int
+	statusErr   error
+}
+
+func newFakeEditLockout(maxAttempts int) *fakeEditLockout {
+	return &fakeEditLockout{maxAttempts: maxAttempts, failures: make(map[string]int)}
+}
+
+func (f *fakeEditLockout) status(id string) EditLockoutStatus {
+	status := EditLockoutStatus{Failures: f.failures[id]}
+	if status.Failures >= f.maxAttempts {
+		status.RetryAfter = 90 * time.Second
+	}
+	return status
+}
+
+func (f *fakeEditLockout) Status(_ context.Context, id string) (EditLockoutStatus, error) {
+	if f.statusErr != nil {
+		return EditLockoutStatus{}, f.statusErr
+	}
+	return f.status(id), nil
+}
+
+func (f *fakeEditLockout) RecordFailure(_ context.Context, id string) (EditLockoutStatus, error) {
+	f.failures[id]++
+	return f.status(id), nil
+}
+
+func (f *fakeEditLockout) Reset(_ context.Context, id string) error {
+	delete(f.failures, id)
+	return nil
+}
+
+func TestScheduleHandlerEditLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(lockout EditLockout, audit *bytes.Buffer) *gin.Engine {
+		mockRepo := NewMockScheduleRepository()
+		mockRepo.schedules["locked-uuid"] = &model.Schedule{
+			ID:        "locked-uuid",
+			EditToken: "right-token",
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+		}
+		handler := NewScheduleHandler(mockRepo)
+		handler.SetEditLockout(lockout)
+		handler.SetAuditLogger(slog.New(slog.NewJSONHandler(audit, nil)))
+
+		router := gin.New()
+		router.PUT("/schedules/:uuid", handler.UpdateSchedule)
+		router.DELETE("/schedules/:uuid", handler.DeleteSchedule)
+		return router
+	}
+	sendFrom := func(router *gin.Engine, remoteAddr, method, token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(UpdateScheduleRequest{EditToken: token})
+		req := httptest.NewRequest(method, "/schedules/locked-uuid", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	send := func(router *gin.Engine, method, token string) *httptest.ResponseRecorder {
+		return sendFrom(router, "192.0.2.1:1234", method, token)
+	}
+
+	t.Run("失敗が続くとEDIT_LOCKEDを返し、正しいトークンでも編集できない", func(t *testing.T) {
+		var audit bytes.Buffer
+		router := setup(newFakeEditLockout(3), &audit)
+
+		assert.Equal(t, http.StatusForbidden, send(router, http.MethodPut, "wrong-1").Code)
+		assert.Equal(t, http.StatusForbidden, send(router, http.MethodDelete, "wrong-2").Code)
+
+		w := send(router, http.MethodPut, "wrong-3")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "EDIT_LOCKED", response.Code)
+		assert.Equal(t, 90, response.RetryAfter)
+
+		w = send(router, http.MethodPut, "wrong-4")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		assert.Contains(t, audit.String(), `"event":"edit_token.failed"`)
+		assert.Contains(t, audit.String(), `"event":"edit_token.locked"`)
+		assert.Contains(t, audit.String(), `"event":"edit_token.rejected_locked"`)
+		assert.Contains(t, audit.String(), `"schedule_id":"locked-uuid"`)
+		assert.NotContains(t, audit.String(), "wrong-")
+		assert.NotContains(t, audit.String(), "right-token")
+	})
+
+	t.Run("ロック中は正しいトークンでも拒否し、失敗回数を変えない", func(t *testing.T) {
+		var audit bytes.Buffer
+		lockout := newFakeEditLockout(3)
+		router := setup(lockout, &audit)
+
+		for _, token := range []string{"wrong-1", "wrong-2", "wrong-3"} {
+			send(router, http.MethodPut, token)
+		}
+		require.Equal(t, http.StatusTooManyRequests, send(router, http.MethodPut, "wrong-4").Code)
+
+		assert.Equal(t, http.StatusTooManyRequests, send(router, http.MethodPut, "right-token").Code)
+		assert.Equal(t, 3, lockout.failures["locked-uuid"])
+		assert.NotContains(t, audit.String(), `"event":"edit_token.verified_after_failures"`)
+	})
+
+	t.Run("失敗はスケジュールごとに数え、接続元を変えてもロックされる", func(t *testing.T) {
+		lockout := newFakeEditLockout(3)
+		router := setup(lockout, &bytes.Buffer{})
+
+		send(router, http.MethodPut, "wrong-1")
+		sendFrom(router, "198.51.100.7:1234", http.MethodPut, "wrong-2")
+		sendFrom(router, "203.0.113.9:1234", http.MethodPut, "wrong-3")
+
+		assert.Equal(t, http.StatusTooManyRequests, sendFrom(router, "198.51.100.8:1234", http.MethodPut, "right-token").Code)
+		assert.Equal(t, 3, lockout.failures["locked-uuid"])
+	})
+
+	t.Run("ロック前に正しいトークンで検証すると失敗回数をリセットする", func(t *testing.T) {
+		var audit bytes.Buffer
+		lockout := newFakeEditLockout(3)
+		router := setup(lockout, &audit)
+
+		send(router, http.MethodPut, "wrong")
+		send(router, http.MethodPut, "wrong")
+		assert.Equal(t, http.StatusOK, send(router, http.MethodPut, "right-token").Code)
+		assert.Equal(t, 0, lockout.failures["locked-uuid"])
+		assert.Contains(t, audit.String(), `"event":"edit_token.verified_after_failures"`)
+	})
+
+	t.Run("ロック状態を取得できない場合もトークンを検証して編集できる", func(t *testing.T) {
+		lockout := newFakeEditLockout(3)
+		lockout.statusErr = errors.New("redis unavailable")
+		router := setup(lockout, &bytes.Buffer{})
+
+		assert.Equal(t, http.StatusNoContent, send(router, http.MethodDelete, "right-token").Code)
+	})
+}
+
+func TestEditTokenHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() (*gin.Engine, *MockScheduleRepository) {
+		mockRepo := NewMockScheduleRepository()
+		mockRepo.schedules["header-uuid"] = &model.Schedule{
+			ID:        "header-uuid",
+			EditToken: "header-token",
+			Comment:   "元のコメント",
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+		}
+		handler := NewScheduleHandler(mockRepo)
+		router := gin.New()
+		router.GET("/schedules/:uuid", handler.GetSchedule)
+		router.PUT("/schedules/:uuid", handler.UpdateSchedule)
+		router.DELETE("/schedules/:uuid", handler.DeleteSchedule)
+		return router, mockRepo
+	}
+
+	t.Run("X-Edit-Tokenヘッダーで更新できる", func(t *testing.T) {
+		router, mockRepo := setup()
+
+		body, _ := json.Marshal(UpdateScheduleRequest{Comment: "ヘッダーで更新"})
+		req := httptest.NewRequest(http.MethodPut, "/schedules/header-uuid", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(EditTokenHeader, "header-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ヘッダーで更新", mockRepo.schedules["header-uuid"].Comment)
+	})
+
+	t.Run("ヘッダーのトークンはボディのトークンより優先される", func(t *testing.T) {
+		router, _ := setup()
+
+		body, _ := json.Marshal(UpdateScheduleRequest{EditToken: "header-token"})
+		req := httptest.NewRequest(http.MethodPut, "/schedules/header-uuid", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer wrong-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Authorization: Bearerで、ボディなしで削除できる", func(t *testing.T) {
+		router, mockRepo := setup()
+
+		req := httptest.NewRequest(http.MethodDelete, "/schedules/header-uuid", nil)
+		req.Header.Set("Authorization", "Bearer header-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.NotContains(t, mockRepo.schedules, "header-uuid")
+	})
+
+	t.Run("トークンなしでボディもない削除は401を返す", func(t *testing.T) {
+		router, _ := setup()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/schedules/header-uuid", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("GETでトークンを渡すと編集権限を確認できる", func(t *testing.T) {
+		router, _ := setup()
+
+		tests := []struct {
+			name   string
+			header string
+			value  string
+			want   int
+		}{
+			{"正しいトークン", EditTokenHeader, "header-token", http.StatusOK},
+			{"誤ったトークン", "Authorization", "Bearer wrong-token", http.StatusForbidden},
+			{"Bearer以外の認証方式は無視する", "Authorization", "Basic dXNlcjpwYXNz", http.StatusOK},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/schedules/header-uuid", nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code, tt.name)
+			assert.NotContains(t, w.Body.String(), "header-token", tt.name)
+		}
+	})
+}
+
+func TestViewPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() (*gin.Engine, *MockScheduleRepository) {
+		mockRepo := NewMockScheduleRepository()
+		handler := NewScheduleHandler(mockRepo)
+		handler.SetEditLockout(newFakeEditLockout(3))
+		handler.SetAuditLogger(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+		router := gin.New()
+		router.POST("/schedules", handler.CreateSchedule)
+		router.GET("/schedules/:uuid", handler.GetSchedule)
+		router.PUT("/schedules/:uuid", handler.UpdateSchedule)
+		router.POST("/schedules/:uuid/unlock", handler.UnlockSchedule)
+		return router, mockRepo
+	}
+	create := func(t *testing.T, router *gin.Engine) CreateScheduleResponse {
+		body, _ := json.Marshal(CreateScheduleRequest{Comment: "社内ミーティング", ViewPassword: "team-pass"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/schedules", bytes.NewBuffer(body)))
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return created
+	}
+	get := func(router *gin.Engine, id string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/schedules/"+id, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	unlock := func(router *gin.Engine, id, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(UnlockScheduleRequest{Password: password})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/schedules/"+id+"/unlock", bytes.NewBuffer(body)))
+		return w
+	}
+	errorCode := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Code
+	}
+
+	t.Run("パスワードはハッシュで保存され、閲覧トークンなしでは401を返す", func(t *testing.T) {
+		router, mockRepo := setup()
+		created := create(t, router)
+		assert.True(t, created.PasswordProtected)
+
+		stored := mockRepo.schedules[created.ID]
+		assert.NotEqual(t, "team-pass", stored.ViewPasswordHash)
+		assert.NoError(t, stored.VerifyViewPassword("team-pass"))
+
+		w := get(router, created.ID, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "PASSWORD_REQUIRED", errorCode(t, w))
+		assert.NotContains(t, w.Body.String(), "社内ミーティング")
+
+		w = get(router, created.ID, map[string]string{ViewTokenHeader: "123.forged"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "PASSWORD_REQUIRED", errorCode(t, w))
+	})
+
+	t.Run("正しいパスワードで発行された閲覧トークンで閲覧できる", func(t *testing.T) {
+		router, _ := setup()
+		created := create(t, router)
+
+		w := unlock(router, created.ID, "team-pass")
+		require.Equal(t, http.StatusOK, w.Code)
+		var unlocked UnlockScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &unlocked))
+		assert.NotEmpty(t, unlocked.ViewToken)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), unlocked.ExpiresAt, 2*time.Second)
+
+		w = get(router, created.ID, map[string]string{ViewTokenHeader: unlocked.ViewToken})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response GetScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "社内ミーティング", response.Comment)
+		assert.True(t, response.PasswordProtected)
+	})
+
+	t.Run("作成者は編集トークンで閲覧できる", func(t *testing.T) {
+		router, _ := setup()
+		created := create(t, router)
+
+		w := get(router, created.ID, map[string]string{EditTokenHeader: created.EditToken})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("誤ったパスワードはINVALID_PASSWORD、続くとVIEW_LOCKEDになり編集はロックされない", func(t *testing.T) {
+		router, _ := setup()
+		created := create(t, router)
+
+		w := unlock(router, created.ID, "wrong-1")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "INVALID_PASSWORD", errorCode(t, w))
+		unlock(router, created.ID, "wrong-2")
+
+		w = unlock(router, created.ID, "wrong-3")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "VIEW_LOCKED", errorCode(t, w))
+		assert.Equal(t, http.StatusTooManyRequests, unlock(router, created.ID, "wrong-4").Code)
+
+		body, _ := json.Marshal(UpdateScheduleRequest{Comment: "更新"})
+		req := httptest.NewRequest(http.MethodPut, "/schedules/"+created.ID, bytes.NewBuffer(body))
+		req.Header.Set(EditTokenHeader, created.EditToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ロック中は正しいパスワードでも閲覧トークンを発行しない", func(t *testing.T) {
+		router, _ := setup()
+		created := create(t, router)
+
+		for _, password := range []string{"wrong-1", "wrong-2", "wrong-3"} {
+			unlock(router, created.ID, password)
+		}
+
+		w := unlock(router, created.ID, "team-pass")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "VIEW_LOCKED", errorCode(t, w))
+		assert.NotContains(t, w.Body.String(), "viewToken")
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("短すぎるパスワードでは作成できず、保護されていないスケジュールはアンロックできない", func(t *testing.T) {
+		router, _ := setup()
+
+		body, _ := json.Marshal(CreateScheduleRequest{ViewPassword: "abc"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/schedules", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		body, _ = json.Marshal(CreateScheduleRequest{Comment: "公開"})
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/schedules", bytes.NewBuffer(body)))
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.False(t, created.PasswordProtected)
+
+		assert.Equal(t, http.StatusOK, get(router, created.ID, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, unlock(router, created.ID, "anything").Code)
+	})
+}
+
+// collidingScheduleRepository は指定回数だけ短縮コードの衝突を返すリポジトリ
+type collidingScheduleRepository struct {
+	*MockScheduleRepository
+	collisions int
+	codes      []string
+}
+
+func (r *collidingScheduleRepository) Create(schedule *model.Schedule) error {
+	r.codes = append(r.codes, schedule.ShortCode)
+	if r.collisions > 0 {
+		r.collisions--
+		return model.ErrShortCodeTaken
+	}
+	return r.MockScheduleRepository.Create(schedule)
+}
+
+func TestShortCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	createRequest := func(t *testing.T, router *gin.Engine) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateScheduleRequest{Comment: "短縮コード"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/schedules", bytes.NewBuffer(body)))
+		return w
+	}
+	setupWithBaseURL := func(repo ScheduleRepository, baseURL string) *gin.Engine {
+		config := DefaultScheduleHandlerConfig()
+		config.PublicBaseURL = baseURL
+		handler := NewScheduleHandlerWithConfig(repo, config)
+		router := gin.New()
+		router.POST("/schedules", handler.CreateSchedule)
+		router.GET("/s/:code", handler.ResolveShortCode)
+		return router
+	}
+	setup := func(repo ScheduleRepository) *gin.Engine {
+		return setupWithBaseURL(repo, "https://kareru.example")
+	}
+	resolve := func(router *gin.Engine, code, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/s/"+code, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("作成時に短縮コードが割り当てられ、衝突した場合は振り直す", func(t *testing.T) {
+		repo := &collidingScheduleRepository{MockScheduleRepository: NewMockScheduleRepository(), collisions: 2}
+		router := setup(repo)
+
+		w := createRequest(t, router)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		require.Len(t, repo.codes, 3)
+		assert.NotEqual(t, repo.codes[0], repo.codes[2])
+		assert.Equal(t, repo.codes[2], created.ShortCode)
+		assert.Equal(t, created.ShortCode, repo.schedules[created.ID].ShortCode)
+	})
+
+	t.Run("衝突が続く場合は500を返す", func(t *testing.T) {
+		repo := &collidingScheduleRepository{MockScheduleRepository: NewMockScheduleRepository(), collisions: maxShortCodeAttempts}
+		router := setup(repo)
+
+		w := createRequest(t, router)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Len(t, repo.codes, maxShortCodeAttempts)
+		assert.Empty(t, repo.schedules)
+	})
+
+	t.Run("JSONを要求するとIDを返し、それ以外はフロントエンドの閲覧ページへリダイレクトする", func(t *testing.T) {
+		repo := NewMockScheduleRepository()
+		router := setup(repo)
+		w := createRequest(t, router)
+		require.Equal(t, http.StatusCreated, w.Code)
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		w = resolve(router, created.ShortCode, "application/json")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resolved ResolveShortCodeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resolved))
+		assert.Equal(t, created.ID, resolved.ID)
+		assert.Equal(t, created.ShortCode, resolved.ShortCode)
+
+		w = resolve(router, created.ShortCode, "text/html")
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://kareru.example/schedule/"+created.ID, w.Header().Get("Location"))
+	})
+
+	t.Run("公開URLが未設定の場合はリダイレクトせず503を返す", func(t *testing.T) {
+		repo := NewMockScheduleRepository()
+		repo.schedules["id-1"] = &model.Schedule{ID: "id-1", ShortCode: "7K3M9Q11", ExpiresAt: time.Now().Add(time.Hour)}
+		router := setupWithBaseURL(repo, "")
+
+		w := resolve(router, "7K3M9Q11", "text/html")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Empty(t, w.Header().Get("Location"))
+		assert.Contains(t, w.Body.String(), "PUBLIC_BASE_URL_NOT_CONFIGURED")
+
+		w = resolve(router, "7K3M9Q11", "application/json")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("小文字やハイフン区切りでも解決できる", func(t *testing.T) {
+		repo := NewMockScheduleRepository()
+		repo.schedules["id-1"] = &model.Schedule{ID: "id-1", ShortCode: "7K3M9Q11", ExpiresAt: time.Now().Add(time.Hour)}
+		router := setup(repo)
+
+		w := resolve(router, "7k3m-9qil", "application/json")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":"id-1"`)
+	})
+
+	t.Run("存在しない・不正な・失効した短縮コード", func(t *testing.T) {
+		repo := NewMockScheduleRepository()
+		repo.schedules["expired"] = &model.Schedule{ID: "expired", ShortCode: "AB12CD34", ExpiresAt: time.Now().Add(-time.Hour)}
+		router := setup(repo)
+
+		assert.Equal(t, http.StatusNotFound, resolve(router, "ZZZZZZZZ", "").Code)
+		assert.Equal(t, http.StatusNotFound, resolve(router, "not-a-code", "").Code)
+		assert.Equal(t, http.StatusGone, resolve(router, "AB12CD34", "").Code)
+	})
+
+	t.Run("リポジトリのエラーは500を返す", func(t *testing.T) {
+		repo := NewMockScheduleRepository()
+		repo.getErr = errors.New("boom")
+		router := setup(repo)
+
+		assert.Equal(t, http.StatusInternalServerError, resolve(router, "AB12CD34", "").Code)
+	})
+}
+
+func TestScheduleQR(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(baseURL string) (*gin.Engine, *ScheduleHandler) {
+		repo := NewMockScheduleRepository()
+		repo.schedules["qr-uuid"] = &model.Schedule{ID: "qr-uuid", ExpiresAt: time.Now().Add(time.Hour)}
+		repo.schedules["expired-uuid"] = &model.Schedule{ID: "expired-uuid", ExpiresAt: time.Now().Add(-time.Hour)}
+
+		config := DefaultScheduleHandlerConfig()
+		config.PublicBaseURL = baseURL
+		handler := NewScheduleHandlerWithConfig(repo, config)
+		router := gin.New()
+		router.GET("/schedules/:uuid/qr.png", handler.ScheduleQRPNG)
+		router.GET("/schedules/:uuid/qr.svg", handler.ScheduleQRSVG)
+		return router, handler
+	}
+	get := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("共有URLは短縮コードがあれば短縮URL、なければ閲覧ページのURLになる", func(t *testing.T) {
+		_, handler := setup("https://kareru.example/")
+		assert.Equal(t, "https://kareru.example/schedule/qr-uuid", handler.ShareURL(&model.Schedule{ID: "qr-uuid"}))
+		assert.Equal(t, "https://kareru.example/s/7K3M9QX2", handler.ShareURL(&model.Schedule{ID: "qr-uuid", ShortCode: "7K3M9QX2"}))
+
+		config := DefaultScheduleHandlerConfig()
+		config.PublicBaseURL = "https://kareru.example"
+		config.ShortLinkBaseURL = "https://k.example/"
+		handler = NewScheduleHandlerWithConfig(NewMockScheduleRepository(), config)
+		assert.Equal(t, "https://k.example/s/7K3M9QX2", handler.ShareURL(&model.Schedule{ID: "qr-uuid", ShortCode: "7K3M9QX2"}))
+
+		assert.Empty(t, NewScheduleHandler(NewMockScheduleRepository()).ShareURL(&model.Schedule{ID: "qr-uuid", ShortCode: "7K3M9QX2"}))
+	})
+
+	t.Run("PNGを指定したサイズで返す", func(t *testing.T) {
+		router, _ := setup("https://kareru.example")
+
+		w := get(router, "/schedules/qr-uuid/qr.png")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, qrCacheControl, w.Header().Get("Cache-Control"))
+		img, err := png.Decode(w.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 256, img.Bounds().Dx())
+
+		w = get(router, "/schedules/qr-uuid/qr.png?size=128&ec=H")
+		require.Equal(t, http.StatusOK, w.Code)
+		img, err = png.Decode(w.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 128, img.Bounds().Dx())
+	})
+
+	t.Run("SVGを返す", func(t *testing.T) {
+		router, _ := setup("https://kareru.example")
+
+		w := get(router, "/schedules/qr-uuid/qr.svg?size=300&ec=q")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `width="300"`)
+	})
+
+	t.Run("サイズや誤り訂正レベルが不正な場合は400を返す", func(t *testing.T) {
+		router, _ := setup("https://kareru.example")
+
+		for _, query := range []string{"size=abc", "size=16", "size=4096", "ec=Z"} {
+			w := get(router, "/schedules/qr-uuid/qr.png?"+query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("存在しない・失効したスケジュール", func(t *testing.T) {
+		router, _ := setup("https://kareru.example")
+
+		assert.Equal(t, http.StatusNotFound, get(router, "/schedules/missing/qr.png").Code)
+		assert.Equal(t, http.StatusGone, get(router, "/schedules/expired-uuid/qr.svg").Code)
+	})
+
+	t.Run("公開URLが未設定の場合は503を返す", func(t *testing.T) {
+		router, _ := setup("")
+
+		w := get(router, "/schedules/qr-uuid/qr.png")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "PUBLIC_BASE_URL_NOT_CONFIGURED")
+	})
+}
+
+func TestScheduleOGImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := NewMockScheduleRepository()
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	repo.schedules["og-uuid"] = &model.Schedule{
+		ID:        "og-uuid",
+		Comment:   "打ち合わせ候補",
+		TimeSlots: []model.TimeSlot{{StartTime: start, EndTime: start.Add(time.Hour)}},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	repo.schedules["expired-uuid"] = &model.Schedule{ID: "expired-uuid", ExpiresAt: time.Now().Add(-time.Hour)}
+
+	handler := NewScheduleHandler(repo)
+	router := gin.New()
+	router.GET("/schedules/:uuid/og.png", handler.ScheduleOGImage)
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("OGP画像をETag付きで返し、一致すれば304を返す", func(t *testing.T) {
+		w := get("/schedules/og-uuid/og.png", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, ogImageCacheControl, w.Header().Get("Cache-Control"))
+		img, err := png.Decode(w.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 1200, img.Bounds().Dx())
+		assert.Equal(t, 630, img.Bounds().Dy())
+		assert.Equal(t, 1, handler.ogCache.Len())
+
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		w = get("/schedules/og-uuid/og.png", `"other", W/`+etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("内容が更新されるとETagが変わる", func(t *testing.T) {
+		before := get("/schedules/og-uuid/og.png", "").Header().Get("ETag")
+		repo.schedules["og-uuid"].Comment = "変更後"
+		w := get("/schedules/og-uuid/og.png", before)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, before, w.Header().Get("ETag"))
+	})
+
+	t.Run("失効したスケジュールは期限切れの画像を返す", func(t *testing.T) {
+		w := get("/schedules/expired-uuid/og.png", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("存在しないスケジュールは404を返す", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/schedules/missing/og.png", "").Code)
+	})
+}
+
+func TestScheduleFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	repo := NewMockScheduleRepository()
+	repo.schedules["feed-uuid"] = &model.Schedule{
+		ID:        "feed-uuid",
+		EditToken: "feed-token",
+		Comment:   "定例の候補\n午後が望ましい",
+		TimeSlots: []model.TimeSlot{
+			{StartTime: start.Add(24 * time.Hour), EndTime: start.Add(25 * time.Hour)},
+			{StartTime: start, EndTime: start.Add(2 * time.Hour)},
+		},
+		CreatedAt: updatedAt.Add(-time.Hour),
+		UpdatedAt: updatedAt,
+		ExpiresAt: time.Now().Add(72 * time.Hour),
+	}
+	repo.schedules["expired-uuid"] = &model.Schedule{
+		ID:        "expired-uuid",
+		TimeSlots: []model.TimeSlot{{StartTime: start, EndTime: start.Add(time.Hour)}},
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	protected := &model.Schedule{
+		ID:        "protected-uuid",
+		Comment:   "社外秘の打ち合わせ",
+		TimeSlots: []model.TimeSlot{{StartTime: start, EndTime: start.Add(time.Hour)}},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, protected.SetViewPassword("secret"))
+	repo.schedules["protected-uuid"] = protected
+	expiredProtected := protected.Clone()
+	expiredProtected.ID = "expired-protected-uuid"
+	expiredProtected.ExpiresAt = time.Now().Add(-time.Hour)
+	repo.schedules["expired-protected-uuid"] = expiredProtected
+
+	config := DefaultScheduleHandlerConfig()
+	config.PublicBaseURL = "https://kareru.example"
+	handler := NewScheduleHandlerWithConfig(repo, config)
+	router := gin.New()
+	router.GET("/schedules/:uuid/feed.ics", handler.ScheduleFeed)
+	router.PUT("/schedules/edit/:token", handler.UpdateScheduleByEditToken)
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("空き時間をVFREEBUSYとVEVENTで返す", func(t *testing.T) {
+		w := get("/schedules/feed-uuid/feed.ics", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, updatedAt.UTC().Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+
+		body := w.Body.String()
+		assert.Contains(t, body, "X-WR-CALNAME:定例の候補\r\n")
+		assert.Contains(t, body, "BEGIN:VFREEBUSY\r\n")
+		assert.Contains(t, body, "FREEBUSY;FBTYPE=FREE:"+ical.FormatPeriod(start, start.Add(2*time.Hour))+"\r\n")
+		assert.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT\r\n"))
+		assert.Contains(t, body, "UID:feed-uuid-"+ical.FormatDateTime(start)+"@kareru\r\n")
+		assert.Contains(t, body, "URL:https://kareru.example/schedule/feed-uuid\r\n")
+		// 開始日時順に並べる
+		assert.Less(t, strings.Index(body, "DTSTART:"+ical.FormatDateTime(start)), strings.Index(body, "DTSTART:"+ical.FormatDateTime(start.Add(24*time.Hour))))
+	})
+
+	t.Run("ETagかLast-Modifiedが一致すれば304を返す", func(t *testing.T) {
+		w := get("/schedules/feed-uuid/feed.ics", nil)
+		etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+
+		w = get("/schedules/feed-uuid/feed.ics", http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+
+		w = get("/schedules/feed-uuid/feed.ics", http.Header{"If-Modified-Since": {lastModified}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		// If-None-Matchが一致しなければIf-Modified-Sinceは見ない
+		w = get("/schedules/feed-uuid/feed.ics", http.Header{"If-None-Match": {`"stale"`}, "If-Modified-Since": {lastModified}})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("編集トークンでの更新が反映される", func(t *testing.T) {
+		before := get("/schedules/feed-uuid/feed.ics", nil)
+
+		body, _ := json.Marshal(UpdateScheduleByEditTokenRequest{
+			Comment:   "時間を変更しました",
+			TimeSlots: []EditTimeSlotRequest{{StartTime: start.Add(3 * time.Hour), EndTime: start.Add(4 * time.Hour)}},
+		})
+		req := httptest.NewRequest(http.MethodPut, "/schedules/edit/feed-token", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = get("/schedules/feed-uuid/feed.ics", http.Header{"If-None-Match": {before.Header().Get("ETag")}})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, before.Header().Get("Last-Modified"), w.Header().Get("Last-Modified"))
+		assert.Contains(t, w.Body.String(), "X-WR-CALNAME:時間を変更しました\r\n")
+		assert.Equal(t, 1, strings.Count(w.Body.String(), "BEGIN:VEVENT\r\n"))
+	})
+
+	t.Run("失効したスケジュールは予定のない空のカレンダーを返す", func(t *testing.T) {
+		w := get("/schedules/expired-uuid/feed.ics", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "BEGIN:VCALENDAR\r\n")
+		assert.NotContains(t, w.Body.String(), "BEGIN:VEVENT")
+		assert.NotContains(t, w.Body.String(), "BEGIN:VFREEBUSY")
+	})
+
+	t.Run("パスワード保護されたスケジュールは購読できない", func(t *testing.T) {
+		w := get("/schedules/protected-uuid/feed.ics", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "FEED_NOT_AVAILABLE")
+
+		// 失効していても空のカレンダーを返さず、コメントを明かさない
+		w = get("/schedules/expired-protected-uuid/feed.ics", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "社外秘")
+
+		body := string(handler.scheduleCalendar(protected, false))
+		assert.Contains(t, body, "X-WR-CALNAME:"+defaultFeedName+"\r\n")
+		assert.NotContains(t, body, "社外秘")
+	})
+
+	t.Run("存在しないスケジュールは404を返す", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/schedules/missing/feed.ics", nil).Code)
+	})
+}
+
+func TestImportSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	// 2週間以上先の月曜日を期間の開始日にする
+	monday := time.Now().In(tokyo).AddDate(0, 0, 14)
+	for monday.Weekday() != time.Monday {
+		monday = monday.AddDate(0, 0, 1)
+	}
+	y, m, d := monday.Date()
+	monday = time.Date(y, m, d, 0, 0, 0, 0, tokyo)
+	local := func(days, hour int) string {
+		return monday.AddDate(0, 0, days).Add(time.Duration(hour) * time.Hour).Format("20060102T150405")
+	}
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:daily",
+		"DTSTART;TZID=Asia/Tokyo:" + local(0, 12),
+		"DTEND;TZID=Asia/Tokyo:" + local(0, 13),
+		"RRULE:FREQ=DAILY;COUNT=2",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:meeting",
+		"DTSTART;TZID=Asia/Tokyo:" + local(1, 9),
+		"DTEND;TZID=Asia/Tokyo:" + local(1, 10),
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:short-gap",
+		"DTSTART;TZID=Asia/Tokyo:" + local(1, 13),
+		"DTEND;TZID=Asia/Tokyo:" + monday.AddDate(0, 0, 1).Add(17*time.Hour+45*time.Minute).Format("20060102T150405"),
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:hourly",
+		"DTSTART;TZID=Asia/Tokyo:" + local(0, 9),
+		"RRULE:FREQ=HOURLY",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+
+	setup := func() (*gin.Engine, *MockScheduleRepository) {
+		repo := NewMockScheduleRepository()
+		handler := NewScheduleHandler(repo)
+		router := gin.New()
+		router.POST("/schedules/import", handler.ImportSchedule)
+		return router, repo
+	}
+	upload := func(router *gin.Engine, content string, fields map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		if content != "" {
+			part, err := writer.CreateFormFile("file", "calendar.ics")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		for key, value := range fields {
+			require.NoError(t, writer.WriteField(key, value))
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/schedules/import", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("予定を除いた営業時間の空き時間でスケジュールを作成する", func(t *testing.T) {
+		router, repo := setup()
+		w := upload(router, calendar, map[string]string{
+			"from":    monday.Format("2006-01-02"),
+			"to":      monday.AddDate(0, 0, 1).Format("2006-01-02"),
+			"comment": "取り込み",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response ImportScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 4, response.ImportedEvents)
+		assert.Equal(t, []string{"hourly"}, response.SkippedEvents)
+		assert.Equal(t, "取り込み", response.Comment)
+		assert.NotEmpty(t, response.EditToken)
+
+		var got []string
+		for _, slot := range response.TimeSlots {
+			got = append(got, slot.StartTime.In(tokyo).Format("Mon 15:04")+"-"+slot.EndTime.In(tokyo).Format("15:04"))
+		}
+		// 火曜日の17:45-18:00は最短の30分に満たないため除く
+		assert.Equal(t, []string{"Mon 09:00-12:00", "Mon 13:00-18:00", "Tue 10:00-12:00"}, got)
+		require.Contains(t, repo.schedules, response.ID)
+	})
+
+	t.Run("最短の空き時間とタイムゾーンを指定できる", func(t *testing.T) {
+		router, _ := setup()
+		w := upload(router, calendar, map[string]string{
+			"from":        monday.AddDate(0, 0, 1).Format("2006-01-02"),
+			"to":          monday.AddDate(0, 0, 1).Format("2006-01-02"),
+			"minDuration": "15",
+			"timezone":    "Asia/Tokyo",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response ImportScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.TimeSlots, 2)
+	})
+
+	t.Run("土日は指定した場合だけ空き時間にする", func(t *testing.T) {
+		router, _ := setup()
+		saturday := monday.AddDate(0, 0, 5).Format("2006-01-02")
+		sunday := monday.AddDate(0, 0, 6).Format("2006-01-02")
+		fields := map[string]string{"from": saturday, "to": sunday}
+
+		w := upload(router, calendar, fields)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+
+		fields["includeWeekends"] = "true"
+		w = upload(router, calendar, fields)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var response ImportScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.TimeSlots, 2)
+	})
+
+	t.Run("入力が不正な場合は400を返す", func(t *testing.T) {
+		router, _ := setup()
+		from := monday.Format("2006-01-02")
+
+		assert.Equal(t, http.StatusBadRequest, upload(router, "", map[string]string{"from": from}).Code)
+		for name, fields := range map[string]map[string]string{
+			"日付の形式":   {"from": "next monday"},
+			"期間が逆":    {"from": from, "to": monday.AddDate(0, 0, -1).Format("2006-01-02")},
+			"期間が長すぎる": {"from": from, "to": monday.AddDate(0, 0, MaxImportDays).Format("2006-01-02")},
+			"タイムゾーン":  {"from": from, "timezone": "Mars/Olympus"},
+			"最短の空き時間": {"from": from, "minDuration": "0"},
+			"土日を含めるか": {"from": from, "includeWeekends": "weekends"},
+			"過去の期間":   {"from": "2020-01-01", "to": "2020-01-07"},
+		} {
+			assert.Equal(t, http.StatusBadRequest, upload(router, calendar, fields).Code, name)
+		}
+
+		w := upload(router, "BEGIN:VEVENT\r\nEND:VEVENT\r\n", map[string]string{"from": from})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_CALENDAR")
+	})
+
+	t.Run("空き時間がない場合は422を返す", func(t *testing.T) {
+		router, _ := setup()
+		busy := strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"BEGIN:VEVENT",
+			"UID:all-day",
+			"DTSTART;VALUE=DATE:" + monday.Format("20060102"),
+			"END:VEVENT",
+			"END:VCALENDAR",
+		}, "\r\n")
+		w := upload(router, busy, map[string]string{"from": monday.Format("2006-01-02"), "to": monday.Format("2006-01-02")})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "NO_FREE_TIME")
+	})
+
+	t.Run("展開しきれない繰り返しを含むカレンダーは400を返す", func(t *testing.T) {
+		router, _ := setup()
+		lines := []string{"BEGIN:VCALENDAR"}
+		for i := 0; i < 50; i++ {
+			lines = append(lines,
+				"BEGIN:VEVENT",
+				"UID:hostile-"+strconv.Itoa(i),
+				"DTSTART:19000101T000000Z",
+				"DTEND:19000101T000100Z",
+				"RRULE:FREQ=WEEKLY;COUNT=1000000;BYDAY=MO,TU,WE,TH,FR,SA,SU",
+				"END:VEVENT",
+			)
+		}
+		lines = append(lines, "END:VCALENDAR")
+
+		started := time.Now()
+		w := upload(router, strings.Join(lines, "\r\n"), map[string]string{"from": monday.Format("2006-01-02")})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "CALENDAR_TOO_COMPLEX")
+		assert.Less(t, time.Since(started), 5*time.Second)
+	})
+
+	t.Run("大きすぎるファイルは413を返す", func(t *testing.T) {
+		router, _ := setup()
+		w := upload(router, strings.Repeat("X", MaxImportFileSize+128*1024), nil)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+// calDAVFixture はtestdata/caldavのXMLを読み込み、{{name}}の部分を置き換える
+func calDAVFixture(t *testing.T, name string, replacements ...string) *strings.Reader {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "caldav", name))
+	require.NoError(t, err)
+	for i := 0; i+1 < len(replacements); i += 2 {
+		replacements[i] = "{{" + replacements[i] + "}}"
+	}
+	return strings.NewReader(strings.NewReplacer(replacements...).Replace(string(data)))
+}
+
+func TestCalDAV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2026, 10, 20, 1, 0, 0, 0, time.UTC)
+	repo := NewMockScheduleRepository()
+	repo.schedules["caldav-uuid"] = &model.Schedule{
+		ID:      "caldav-uuid",
+		Comment: "定例の候補",
+		TimeSlots: []model.TimeSlot{
+			{StartTime: start.Add(24 * time.Hour), EndTime: start.Add(26 * time.Hour)},
+			{StartTime: start, EndTime: start.Add(2 * time.Hour)},
+		},
+		CreatedAt: time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	repo.schedules["expired-uuid"] = &model.Schedule{
+		ID:        "expired-uuid",
+		TimeSlots: []model.TimeSlot{{StartTime: start, EndTime: start.Add(time.Hour)}},
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	protected := &model.Schedule{ID: "protected-uuid", Comment: "社外秘の打ち合わせ", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, protected.SetViewPassword("secret"))
+	repo.schedules["protected-uuid"] = protected
+	expiredProtected := protected.Clone()
+	expiredProtected.ID = "expired-protected-uuid"
+	expiredProtected.ExpiresAt = time.Now().Add(-time.Hour)
+	repo.schedules["expired-protected-uuid"] = expiredProtected
+
+	handler := NewScheduleHandler(repo)
+	router := gin.New()
+	router.OPTIONS("/caldav/schedules/:uuid/", handler.CalDAVOptions)
+	router.Handle("PROPFIND", "/caldav/schedules/:uuid/", handler.CalDAVPropfind)
+	router.Handle("REPORT", "/caldav/schedules/:uuid/", handler.CalDAVReport)
+	router.Handle("PROPFIND", "/caldav/schedules/:uuid/:resource", handler.CalDAVPropfind)
+	router.GET("/caldav/schedules/:uuid/:resource", handler.CalDAVGetObject)
+	serve := func(method, path string, body *strings.Reader, header http.Header) *httptest.ResponseRecorder {
+		var req *http.Request
+		if body != nil {
+			req = httptest.NewRequest(method, path, body)
+		} else {
+			req = httptest.NewRequest(method, path, nil)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	collection := "/caldav/schedules/caldav-uuid/"
+	first := collection + ical.FormatDateTime(start) + ".ics"
+	second := collection + ical.FormatDateTime(start.Add(24*time.Hour)) + ".ics"
+
+	t.Run("OPTIONSでcalendar-accessに対応していることを伝える", func(t *testing.T) {
+		w := serve(http.MethodOptions, collection, nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1, calendar-access", w.Header().Get("DAV"))
+		assert.Contains(t, w.Header().Get("Allow"), "REPORT")
+	})
+
+	t.Run("PROPFINDでカレンダーコレクションのプロパティを返す", func(t *testing.T) {
+		w := serve("PROPFIND", collection, calDAVFixture(t, "propfind.xml"), http.Header{"Depth": {"0"}})
+		require.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		assert.Equal(t, 1, strings.Count(body, "<D:response>"))
+		assert.Contains(t, body, "<D:href>"+collection+"</D:href>")
+		assert.Contains(t, body, "<D:resourcetype><D:collection/><C:calendar/></D:resourcetype>")
+		assert.Contains(t, body, "<D:displayname>定例の候補</D:displayname>")
+		assert.Contains(t, body, "<CS:getctag>")
+		assert.Contains(t, body, `<C:comp name="VFREEBUSY"/>`)
+		// 対応していないプロパティは404のpropstatで返す
+		assert.Contains(t, body, "<D:quota-available-bytes/></D:prop><D:status>HTTP/1.1 404 Not Found</D:status>")
+	})
+
+	t.Run("Depth: 1ではタイムスロットごとのカレンダーオブジェクトも返す", func(t *testing.T) {
+		w := serve("PROPFIND", collection, calDAVFixture(t, "propfind.xml"), http.Header{"Depth": {"1"}})
+		require.Equal(t, http.StatusMultiStatus, w.Code)
+
+		body := w.Body.String()
+		assert.Equal(t, 3, strings.Count(body, "<D:response>"))
+		// 開始日時順に並べる
+		assert.Less(t, strings.Index(body, "<D:href>"+first+"</D:href>"), strings.Index(body, "<D:href>"+second+"</D:href>"))
+	})
+
+	t.Run("free-busy-queryは期間に切り詰めた空き時間をVFREEBUSYで返す", func(t *testing.T) {
+		from, to := start.Add(time.Hour), start.Add(12*time.Hour)
+		w := serve("REPORT", collection, calDAVFixture(t, "free-busy-query.xml",
+			"start", ical.FormatDateTime(from), "end", ical.FormatDateTime(to)), http.Header{"Depth": {"1"}})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		assert.Contains(t, body, "BEGIN:VFREEBUSY\r\n")
+		assert.Contains(t, body, "DTSTART:"+ical.FormatDateTime(from)+"\r\n")
+		assert.Contains(t, body, "DTEND:"+ical.FormatDateTime(to)+"\r\n")
+		assert.Contains(t, body, "FREEBUSY;FBTYPE=FREE:"+ical.FormatPeriod(from, start.Add(2*time.Hour))+"\r\n")
+		assert.Equal(t, 1, strings.Count(body, "FREEBUSY;FBTYPE=FREE:"))
+		assert.NotContains(t, body, "BEGIN:VEVENT")
+	})
+
+	t.Run("calendar-queryは期間に重なるカレンダーオブジェクトを返す", func(t *testing.T) {
+		w := serve("REPORT", collection, calDAVFixture(t, "calendar-query.xml",
+			"start", ical.FormatDateTime(start.Add(12*time.Hour)), "end", ical.FormatDateTime(start.Add(48*time.Hour))), nil)
+		require.Equal(t, http.StatusMultiStatus, w.Code)
+
+		body := w.Body.String()
+		assert.Equal(t, 1, strings.Count(body, "<D:response>"))
+		assert.Contains(t, body, "<D:href>"+second+"</D:href>")
+		assert.Contains(t, body, "<D:getetag>")
+		assert.Contains(t, body, "BEGIN:VEVENT")
+		assert.NotContains(t, body, "METHOD:")
+	})
+
+	t.Run("calendar-multigetは存在しないhrefを404で返す", func(t *testing.T) {
+		w := serve("REPORT", collection, calDAVFixture(t, "calendar-multiget.xml",
+			"found", first, "missing", collection+"20200101T000000Z.ics"), nil)
+		require.Equal(t, http.StatusMultiStatus, w.Code)
+
+		body := w.Body.String()
+		assert.Equal(t, 2, strings.Count(body, "<D:response>"))
+		assert.Contains(t, body, "UID:caldav-uuid-"+ical.FormatDateTime(start)+"@kareru")
+		assert.Contains(t, body, "<D:href>"+collection+"20200101T000000Z.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+	})
+
+	t.Run("対応していないREPORTは403を返す", func(t *testing.T) {
+		w := serve("REPORT", collection, calDAVFixture(t, "sync-collection.xml"), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "<D:supported-report/>")
+
+		w = serve("REPORT", collection, strings.NewReader("<not-xml"), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("カレンダーオブジェクトをGETとPROPFINDで取得できる", func(t *testing.T) {
+		w := serve(http.MethodGet, first, nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, strings.Count(w.Body.String(), "BEGIN:VEVENT\r\n"))
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		w = serve(http.MethodGet, first, nil, http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		w = serve("PROPFIND", first, calDAVFixture(t, "propfind.xml"), nil)
+		require.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Contains(t, w.Body.String(), "<D:getetag>"+strings.ReplaceAll(etag, `"`, "&#34;")+"</D:getetag>")
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, collection+"20200101T000000Z.ics", nil, nil).Code)
+	})
+
+	t.Run("失効したスケジュールは空のコレクションになる", func(t *testing.T) {
+		w := serve("PROPFIND", "/caldav/schedules/expired-uuid/", calDAVFixture(t, "propfind.xml"), http.Header{"Depth": {"1"}})
+		require.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Equal(t, 1, strings.Count(w.Body.String(), "<D:response>"))
+
+		w = serve(http.MethodGet, "/caldav/schedules/expired-uuid/"+ical.FormatDateTime(start)+".ics", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("パスワード保護されたスケジュールと存在しないスケジュール", func(t *testing.T) {
+		w := serve("PROPFIND", "/caldav/schedules/protected-uuid/", calDAVFixture(t, "propfind.xml"), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "FEED_NOT_AVAILABLE")
+
+		// 失効していても空のコレクションを返さず、コメントを明かさない
+		w = serve("PROPFIND", "/caldav/schedules/expired-protected-uuid/", calDAVFixture(t, "propfind.xml"), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "社外秘")
+		for _, prop := range calDAVCollectionProps(protected, nil) {
+			assert.NotContains(t, prop.Inner, "社外秘", prop.Name.Local)
+		}
+
+		w = serve("REPORT", "/caldav/schedules/missing/", calDAVFixture(t, "free-busy-query.xml",
+			"start", ical.FormatDateTime(start), "end", ical.FormatDateTime(start.Add(time.Hour))), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+type recordingNotifier struct {
+	events []model.ScheduleEvent
+	last   *model.Schedule
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, event model.ScheduleEvent, schedule *model.Schedule) {
+	r.events = append(r.events, event)
+	r.last = schedule
+}
+
+// respondingNotifier は閲覧者の回答の内容も受け取る通知先
+type respondingNotifier struct {
+	recordingNotifier
+	responses []*model.Response
+}
+
+func (r *respondingNotifier) NotifyResponse(_ context.Context, schedule *model.Schedule, response *model.Response) {
+	r.Notify(context.Background(), model.EventResponseSubmitted, schedule)
+	r.responses = append(r.responses, response)
+}
+
+func TestScheduleWebhooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(enabled bool) (*gin.Engine, *recordingNotifier) {
+		config := DefaultScheduleHandlerConfig()
+		config.WebhooksEnabled = enabled
+		handler := NewScheduleHandlerWithConfig(NewMockScheduleRepository(), config)
+		notifier := &recordingNotifier{}
+		handler.SetNotifier(notifier)
+		router := gin.New()
+		router.POST("/schedules", handler.CreateSchedule)
+		router.GET("/schedules/:uuid", handler.GetSchedule)
+		router.PUT("/schedules/:uuid", handler.UpdateSchedule)
+		router.DELETE("/schedules/:uuid", handler.DeleteSchedule)
+		router.POST("/schedules/:uuid/unlock", handler.UnlockSchedule)
+		router.POST("/schedules/:uuid/responses", handler.SubmitResponse)
+		return router, notifier
+	}
+	serve := func(router *gin.Engine, method, path string, body interface{}, editToken string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != nil {
+			b, _ := json.Marshal(body)
+			reader = bytes.NewReader(b)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		if editToken != "" {
+			req.Header.Set(EditTokenHeader, editToken)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	create := func(t *testing.T, router *gin.Engine, webhooks ...string) CreateScheduleResponse {
+		w := serve(router, http.MethodPost, "/schedules", CreateScheduleRequest{Comment: "定例", Webhooks: webhooks}, "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return created
+	}
+
+	t.Run("作成時に登録すると署名鍵を返し、作成を通知する", func(t *testing.T) {
+		router, notifier := setup(true)
+		created := create(t, router, "https://hooks.example.com/kareru")
+
+		assert.Equal(t, []string{"https://hooks.example.com/kareru"}, created.Webhooks)
+		assert.True(t, strings.HasPrefix(created.WebhookSecret, "whsec_"))
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated}, notifier.events)
+		assert.Equal(t, created.ID, notifier.last.ID)
+	})
+
+	t.Run("Webhookの設定は編集トークンを持つ作成者にだけ返す", func(t *testing.T) {
+		router, _ := setup(true)
+		created := create(t, router, "https://hooks.example.com/kareru")
+
+		w := serve(router, http.MethodGet, "/schedules/"+created.ID, nil, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "webhook")
+
+		w = serve(router, http.MethodGet, "/schedules/"+created.ID, nil, created.EditToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		var got GetScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, created.Webhooks, got.Webhooks)
+		assert.Equal(t, created.WebhookSecret, got.WebhookSecret)
+	})
+
+	t.Run("更新で省略した場合は変更せず、空の配列で解除する", func(t *testing.T) {
+		router, notifier := setup(true)
+		created := create(t, router, "https://hooks.example.com/kareru")
+
+		w := serve(router, http.MethodPut, "/schedules/"+created.ID, UpdateScheduleRequest{EditToken: created.EditToken, Comment: "変更"}, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var updated GetScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+		assert.Equal(t, created.Webhooks, updated.Webhooks)
+
+		w = serve(router, http.MethodPut, "/schedules/"+created.ID, UpdateScheduleRequest{EditToken: created.EditToken, Webhooks: []string{}}, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "webhook")
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated, model.EventScheduleUpdated, model.EventScheduleUpdated}, notifier.events)
+		assert.Empty(t, notifier.last.WebhookURLs)
+	})
+
+	t.Run("削除を削除前のスケジュールで通知する", func(t *testing.T) {
+		router, notifier := setup(true)
+		created := create(t, router, "https://hooks.example.com/kareru")
+
+		w := serve(router, http.MethodDelete, "/schedules/"+created.ID, nil, created.EditToken)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, model.EventScheduleDeleted, notifier.events[len(notifier.events)-1])
+		assert.Equal(t, created.Webhooks, notifier.last.WebhookURLs)
+	})
+
+	t.Run("閲覧者の回答を通知する", func(t *testing.T) {
+		config := DefaultScheduleHandlerConfig()
+		config.WebhooksEnabled = true
+		handler := NewScheduleHandlerWithConfig(NewMockScheduleRepository(), config)
+		plain, responding := &recordingNotifier{}, &respondingNotifier{}
+		handler.SetNotifier(ScheduleNotifiers{plain, responding})
+		router := gin.New()
+		router.POST("/schedules", handler.CreateSchedule)
+		router.POST("/schedules/:uuid/responses", handler.SubmitResponse)
+
+		start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+		w := serve(router, http.MethodPost, "/schedules", CreateScheduleRequest{
+			TimeSlots: []TimeSlotRequest{
+				{StartTime: start, EndTime: start.Add(time.Hour)},
+				{StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour)},
+			},
+			Webhooks: []string{"https://hooks.example.com/kareru"},
+		}, "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		w = serve(router, http.MethodPost, "/schedules/"+created.ID+"/responses", SubmitResponseRequest{Name: "山田", Message: "どちらでも", TimeSlots: []int{1, 0}}, "")
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		var accepted SubmitResponseResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &accepted))
+		assert.Equal(t, []int{0, 1}, accepted.TimeSlots)
+
+		// 回答を受け取れない通知先にもイベントは通知する
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated, model.EventResponseSubmitted}, plain.events)
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated, model.EventResponseSubmitted}, responding.events)
+		require.Len(t, responding.responses, 1)
+		assert.Equal(t, "山田", responding.responses[0].Name)
+		assert.Equal(t, "どちらでも", responding.responses[0].Message)
+		assert.Equal(t, []int{0, 1}, responding.responses[0].TimeSlots)
+		assert.Equal(t, created.ID, responding.last.ID)
+	})
+
+	t.Run("不正な回答やWebhookのないスケジュールへの回答は通知しない", func(t *testing.T) {
+		router, notifier := setup(true)
+		created := create(t, router, "https://hooks.example.com/kareru")
+
+		w := serve(router, http.MethodPost, "/schedules/"+created.ID+"/responses", SubmitResponseRequest{Name: " "}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = serve(router, http.MethodPost, "/schedules/"+created.ID+"/responses", SubmitResponseRequest{Name: "山田", TimeSlots: []int{0}}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = serve(router, http.MethodPost, "/schedules/missing/responses", SubmitResponseRequest{Name: "山田"}, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		withoutWebhooks := create(t, router)
+		w = serve(router, http.MethodPost, "/schedules/"+withoutWebhooks.ID+"/responses", SubmitResponseRequest{Name: "山田"}, "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated, model.EventScheduleCreated}, notifier.events)
+	})
+
+	t.Run("パスワード保護されたスケジュールへの回答には閲覧トークンが必要", func(t *testing.T) {
+		router, notifier := setup(true)
+		w := serve(router, http.MethodPost, "/schedules", CreateScheduleRequest{ViewPassword: "password", Webhooks: []string{"https://hooks.example.com/kareru"}}, "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		w = serve(router, http.MethodPost, "/schedules/"+created.ID+"/responses", SubmitResponseRequest{Name: "山田"}, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated}, notifier.events)
+
+		w = serve(router, http.MethodPost, "/schedules/"+created.ID+"/unlock", UnlockScheduleRequest{Password: "password"}, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var unlocked UnlockScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &unlocked))
+
+		body, _ := json.Marshal(SubmitResponseRequest{Name: "山田"})
+		req := httptest.NewRequest(http.MethodPost, "/schedules/"+created.ID+"/responses", bytes.NewReader(body))
+		req.Header.Set(ViewTokenHeader, unlocked.ViewToken)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated, model.EventResponseSubmitted}, notifier.events)
+	})
+
+	t.Run("不正なURLや無効なサーバーでの登録は400を返し、通知しない", func(t *testing.T) {
+		router, notifier := setup(true)
+		w := serve(router, http.MethodPost, "/schedules", CreateScheduleRequest{Webhooks: []string{"ftp://hooks.example.com"}}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		created := create(t, router)
+		assert.Empty(t, created.WebhookSecret)
+		w = serve(router, http.MethodPut, "/schedules/"+created.ID, UpdateScheduleRequest{EditToken: created.EditToken, Webhooks: []string{"not a url"}}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated}, notifier.events)
+
+		router, notifier = setup(false)
+		w = serve(router, http.MethodPost, "/schedules", CreateScheduleRequest{Webhooks: []string{"https://hooks.example.com/kareru"}}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not enabled")
+		assert.Empty(t, notifier.events)
+	})
+}
+
+func TestScheduleEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(enabled bool) (*gin.Engine, *recordingNotifier) {
+		config := DefaultScheduleHandlerConfig()
+		config.EmailEnabled = enabled
+		handler := NewScheduleHandlerWithConfig(NewMockScheduleRepository(), config)
+		notifier := &recordingNotifier{}
+		handler.SetNotifier(ScheduleNotifiers{notifier})
+		router := gin.New()
+		router.POST("/schedules", handler.CreateSchedule)
+		return router, notifier
+	}
+	create := func(router *gin.Engine, req CreateScheduleRequest, acceptLanguage string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/schedules", bytes.NewReader(body))
+		if acceptLanguage != "" {
+			r.Header.Set("Accept-Language", acceptLanguage)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("指定したアドレスと言語で作成を通知する", func(t *testing.T) {
+		router, notifier := setup(true)
+		w := create(router, CreateScheduleRequest{Email: "taro@example.com", Locale: model.LocaleEnglish}, "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var created CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "taro@example.com", created.Email)
+		assert.Equal(t, []model.ScheduleEvent{model.EventScheduleCreated}, notifier.events)
+		assert.Equal(t, "taro@example.com", notifier.last.NotifyEmail)
+		assert.Equal(t, model.LocaleEnglish, notifier.last.NotifyLocale)
+		assert.Equal(t, created.EditToken, notifier.last.EditToken)
+	})
+
+	t.Run("言語を省略した場合はAccept-Languageから決める", func(t *testing.T) {
+		for header, want := range map[string]string{
+			"en-US,en;q=0.9,ja;q=0.8": model.LocaleEnglish,
+			"ja,en-US;q=0.9":          model.LocaleJapanese,
+			"":                        model.LocaleJapanese,
+		} {
+			router, notifier := setup(true)
+			w := create(router, CreateScheduleRequest{Email: "taro@example.com"}, header)
+			require.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, want, notifier.last.NotifyLocale, header)
+		}
+	})
+
+	t.Run("不正なアドレスや無効なサーバーでの指定は400を返す", func(t *testing.T) {
+		router, notifier := setup(true)
+		w := create(router, CreateScheduleRequest{Email: "Taro <taro@example.com>"}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = create(router, CreateScheduleRequest{Email: "taro@example.com", Locale: "fr"}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, notifier.events)
+
+		router, _ = setup(false)
+		w = create(router, CreateScheduleRequest{Email: "taro@example.com"}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not enabled")
+
+		// アドレスを指定しなければ無効なサーバーでも作成できる
+		w = create(router, CreateScheduleRequest{Comment: "定例"}, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotContains(t, w.Body.String(), "email")
+	})
+
+	t.Run("編集URLは共有URLと同じ基点から作る", func(t *testing.T) {
+		config := DefaultScheduleHandlerConfig()
+		config.PublicBaseURL = "https://kareru.example.com/"
+		handler := NewScheduleHandlerWithConfig(NewMockScheduleRepository(), config)
+		assert.Equal(t, "https://kareru.example.com/edit/token", handler.EditURL("token"))
+
+		assert.Empty(t, NewScheduleHandler(NewMockScheduleRepository()).EditURL("token"))
+	})
+}
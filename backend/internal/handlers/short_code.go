@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+)
+
+// maxShortCodeAttempts は短縮コードが衝突したときに振り直す上限回数
+// 40bitの空間に対して衝突は稀なため、続けて失敗する場合はストアの異常とみなす
+const maxShortCodeAttempts = 5
+
+// ResolveShortCodeResponse は短縮コードの解決結果
+type ResolveShortCodeResponse struct {
+	ID        string `json:"id"`
+	ShortCode string `json:"shortCode"`
+}
+
+// ShortLinkPath は短縮コードを閲覧ページへリダイレクトするパス（ResolveShortCodeが応答する）
+func ShortLinkPath(code string) string {
+	return "/s/" + code
+}
+
+// SchedulePath はフロントエンドでスケジュールを閲覧するページのパス
+func SchedulePath(id string) string {
+	return "/schedule/" + id
+}
+
+// EditPath はフロントエンドで編集トークンを使ってスケジュールを編集するページのパス
+func EditPath(token string) string {
+	return "/edit/" + token
+}
+
+// createWithShortCode はスケジュールを保存する
+// 短縮コードが他のスケジュールと衝突した場合は新しいコードを割り当てて再試行する
+func (h *ScheduleHandler) createWithShortCode(c *gin.Context, schedule *model.Schedule) error {
+	repo := h.repository(c)
+	for attempt := 1; ; attempt++ {
+		err := repo.Create(schedule)
+		if !errors.Is(err, model.ErrShortCodeTaken) {
+			return err
+		}
+		if attempt >= maxShortCodeAttempts {
+			return fmt.Errorf("failed to allocate short code after %d attempts: %w", attempt, err)
+		}
+
+		code, err := model.GenerateShortCode()
+		if err != nil {
+			return err
+		}
+		schedule.ShortCode = code
+	}
+}
+
+// ResolveShortCode は短縮コードをスケジュールのIDに解決するハンドラー
+// JSONを要求された場合はIDを返し、それ以外（ブラウザでの直接アクセス）は閲覧ページへリダイレクトする
+// 返すのはIDだけのため、パスワード保護されたスケジュールも閲覧ページでパスワードを求められる
+func (h *ScheduleHandler) ResolveShortCode(c *gin.Context) {
+	// 電話口や手入力での表記揺れ（小文字・ハイフン・I/L/O）を吸収する
+	code, err := model.NormalizeShortCode(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
+		})
+		return
+	}
+
+	schedule, err := h.repository(c).GetByShortCode(code)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
+		})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
+		})
+		return
+	}
+
+	// 失効チェック
+	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
+		c.JSON(http.StatusGone, gin.H{
+			"error": "schedule has expired",
+		})
+		return
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), gin.MIMEJSON) {
+		c.JSON(http.StatusOK, ResolveShortCodeResponse{
+			ID:        schedule.ID,
+			ShortCode: schedule.ShortCode,
+		})
+		return
+	}
+	// 短縮URLはバックエンドが応答するため、フロントエンドの閲覧ページへは公開URLでリダイレクトする
+	location := h.ScheduleURL(schedule.ID)
+	if location == "" {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "Service Unavailable",
+			Message: "公開URLが設定されていないため、閲覧ページへリダイレクトできません",
+			Code:    "PUBLIC_BASE_URL_NOT_CONFIGURED",
+		})
+		return
+	}
+	c.Redirect(http.StatusFound, location)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/viewtoken"
+)
+
+// UnlockScheduleRequest は閲覧パスワードの検証リクエスト
+type UnlockScheduleRequest struct {
+	Password string `json:"password"`
+}
+
+// UnlockScheduleResponse は閲覧トークンのレスポンス
+// 閲覧時は X-View-Token ヘッダーに viewToken を指定する
+type UnlockScheduleResponse struct {
+	ViewToken string    `json:"viewToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// validateViewPassword は作成時に指定された閲覧パスワードの長さを確認する（空は保護なし）
+func validateViewPassword(password string) error {
+	if password == "" {
+		return nil
+	}
+	length := utf8.RuneCountInString(password)
+	if length < MinViewPasswordLength || length > MaxViewPasswordLength {
+		return fmt.Errorf("view password must be between %d and %d characters", MinViewPasswordLength, MaxViewPasswordLength)
+	}
+	return nil
+}
+
+// verifyViewToken はパスワード保護されたスケジュールの閲覧トークンを検証する
+// 検証に失敗した場合は401を書き込んでfalseを返す
+func (h *ScheduleHandler) verifyViewToken(c *gin.Context, schedule *model.Schedule) bool {
+	token := c.GetHeader(ViewTokenHeader)
+	if token == "" {
+		PasswordRequired(c, "PASSWORD_REQUIRED", "このスケジュールの閲覧にはパスワードが必要です")
+		return false
+	}
+	if err := h.viewTokens.Verify(token, schedule.ID, schedule.ViewPasswordHash); err != nil {
+		message := "閲覧トークンが無効です。パスワードを再入力してください"
+		if errors.Is(err, viewtoken.ErrExpired) {
+			message = "閲覧トークンの有効期限が切れました。パスワードを再入力してください"
+		}
+		PasswordRequired(c, "PASSWORD_REQUIRED", message)
+		return false
+	}
+	return true
+}
+
+// UnlockSchedule は閲覧パスワードを検証し、有効期間の短い閲覧トークンを発行するハンドラー
+func (h *ScheduleHandler) UnlockSchedule(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if uuid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "uuid is required",
+		})
+		return
+	}
+
+	var req UnlockScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "password is required",
+		})
+		return
+	}
+	if utf8.RuneCountInString(req.Password) > MaxViewPasswordLength {
+		PasswordRequired(c, "INVALID_PASSWORD", "閲覧パスワードが正しくありません")
+		return
+	}
+
+	// スケジュールを取得
+	schedule, err := h.repository(c).GetByID(uuid)
+	if errors.Is(err, model.ErrScheduleNotFound) || (err == nil && schedule == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "schedule not found",
+		})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get schedule",
+		})
+		return
+	}
+
+	// 失効チェック
+	if schedule.IsExpired() {
+		h.metrics.ExpiredOnRead()
+		c.JSON(http.StatusGone, gin.H{
+			"error": "schedule has expired",
+		})
+		return
+	}
+
+	if !schedule.HasViewPassword() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "schedule is not password protected",
+		})
+		return
+	}
+
+	// パスワードの検証（失敗が続いた場合は一時的にロックする）
+	if !h.verifyViewPassword(c, schedule, req.Password) {
+		return
+	}
+
+	token, expiresAt := h.viewTokens.Issue(schedule.ID, schedule.ViewPasswordHash)
+	c.JSON(http.StatusOK, UnlockScheduleResponse{
+		ViewToken: token,
+		ExpiresAt: expiresAt,
+	})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// errWebhooksDisabled はWebhookが無効なサーバーでWebhookを登録しようとした場合に返される
+var errWebhooksDisabled = errors.New("webhooks are not enabled on this server")
+
+// setWebhooks はリクエストで指定されたWebhookのURLをスケジュールに登録する
+func (h *ScheduleHandler) setWebhooks(schedule *model.Schedule, urls []string) error {
+	if len(urls) > 0 && !h.config.WebhooksEnabled {
+		return errWebhooksDisabled
+	}
+	return schedule.SetWebhookURLs(urls)
+}
+
+// editorView は編集トークンを持つ作成者にだけ返すWebhookの設定をレスポンスに加える
+func editorView(response GetScheduleResponse, schedule *model.Schedule) GetScheduleResponse {
+	response.Webhooks = schedule.WebhookURLs
+	if len(schedule.WebhookURLs) > 0 {
+		response.WebhookSecret = schedule.WebhookSecret
+	}
+	return response
+}
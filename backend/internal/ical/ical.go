@@ -0,0 +1,137 @@
+// Package ical はiCalendar（RFC 5545）形式のデータを組み立てる
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType はiCalendarのレスポンスに指定するContent-Type
+const ContentType = "text/calendar; charset=utf-8"
+
+// ProdID はこのサービスが生成するカレンダーの製品識別子
+const ProdID = "-//Kareru//Kareru Schedule//JA"
+
+// maxLineOctets は1行の最大オクテット数（改行を除く）
+// これを超える行は折り返す（RFC 5545 3.1）
+const maxLineOctets = 75
+
+// dateTimeFormat はUTCの日時の書式
+const dateTimeFormat = "20060102T150405Z"
+
+// Writer はiCalendarのコンテンツ行を書き出す
+type Writer struct {
+	b strings.Builder
+}
+
+// NewWriter は空のWriterを作成する
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// Begin はコンポーネントを開始する
+func (w *Writer) Begin(component string) {
+	w.Line("BEGIN", component)
+}
+
+// End はコンポーネントを終了する
+func (w *Writer) End(component string) {
+	w.Line("END", component)
+}
+
+// Line はエスケープ済みの値をそのまま書き出す
+// nameにはパラメーターを含められる（例: "FREEBUSY;FBTYPE=FREE"）
+func (w *Writer) Line(name, value string) {
+	w.fold(name + ":" + value)
+}
+
+// Text はテキスト値をエスケープして書き出す
+func (w *Writer) Text(name, value string) {
+	w.Line(name, EscapeText(value))
+}
+
+// DateTime は日時をUTCで書き出す
+func (w *Writer) DateTime(name string, t time.Time) {
+	w.Line(name, FormatDateTime(t))
+}
+
+// Bytes は書き出した内容を返す
+func (w *Writer) Bytes() []byte {
+	return []byte(w.b.String())
+}
+
+// fold は長い行を75オクテットごとに折り返して書き出す
+// 継続行は空白で始まるため、2行目以降は74オクテットまでにする
+// UTF-8の文字の途中では折り返さない
+func (w *Writer) fold(line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.b.WriteString(line[:cut])
+		w.b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+	w.b.WriteString(line)
+	w.b.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// EscapeText はTEXT型の値に含まれる特殊文字をエスケープする
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// FormatDateTime は日時をUTCのDATE-TIME形式（例: 20261020T010000Z）にする
+func FormatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+// FormatPeriod は開始と終了の日時をPERIOD形式（例: 20261020T010000Z/20261020T030000Z）にする
+func FormatPeriod(start, end time.Time) string {
+	return FormatDateTime(start) + "/" + FormatDateTime(end)
+}
+
+// FormatDuration は期間をDURATION形式（例: P1D, PT1H30M）にする
+// 1秒未満は切り捨てる
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
+	seconds := int64(d / time.Second)
+	days, seconds := seconds/86400, seconds%86400
+	hours, seconds := seconds/3600, seconds%3600
+	minutes, seconds := seconds/60, seconds%60
+
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	return b.String()
+}
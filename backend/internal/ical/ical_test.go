@@ -0,0 +1,61 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	w := NewWriter()
+	w.Begin("VCALENDAR")
+	w.Text("SUMMARY", "打ち合わせ; 候補, その1\n詳細は\\後で")
+	w.DateTime("DTSTART", time.Date(2026, 10, 20, 10, 0, 0, 0, time.FixedZone("JST", 9*60*60)))
+	w.End("VCALENDAR")
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"SUMMARY:打ち合わせ\\; 候補\\, その1\\n詳細は\\\\後で\r\n"+
+		"DTSTART:20261020T010000Z\r\n"+
+		"END:VCALENDAR\r\n", string(w.Bytes()))
+}
+
+func TestWriter_Fold(t *testing.T) {
+	w := NewWriter()
+	w.Text("DESCRIPTION", strings.Repeat("あ", 60))
+
+	lines := strings.Split(strings.TrimSuffix(string(w.Bytes()), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	var unfolded strings.Builder
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineOctets, "line %d", i)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+			line = line[1:]
+		}
+		unfolded.WriteString(line)
+	}
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("あ", 60), unfolded.String())
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                               "PT0S",
+		time.Hour:                       "PT1H",
+		90 * time.Minute:                "PT1H30M",
+		24 * time.Hour:                  "P1D",
+		26*time.Hour + 5*time.Second:    "P1DT2H5S",
+		-15 * time.Minute:               "-PT15M",
+		1500 * time.Millisecond:         "PT1S",
+		7*24*time.Hour + 30*time.Minute: "P7DT30M",
+	}
+	for d, want := range tests {
+		assert.Equal(t, want, FormatDuration(d), d.String())
+	}
+}
+
+func TestFormatPeriod(t *testing.T) {
+	start := time.Date(2026, 10, 20, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, "20261020T010000Z/20261020T030000Z", FormatPeriod(start, start.Add(2*time.Hour)))
+}
@@ -0,0 +1,418 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// TZIDのIANA名を実行環境のタイムゾーンデータに依存せず解決する
+	_ "time/tzdata"
+)
+
+// ErrInvalidCalendar はiCalendarとして解釈できないデータの場合に返される
+var ErrInvalidCalendar = errors.New("ical: invalid calendar")
+
+// maxLineBytes は折り返しを戻した後の1行の最大バイト数
+const maxLineBytes = 1 << 20
+
+// Event はVEVENTのうち空き時間の計算に必要な項目
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	// AllDay は日付だけで指定された終日の予定か
+	AllDay bool
+	// RRule は繰り返しルール（繰り返さない場合は空）
+	RRule   string
+	ExDates []time.Time
+	// RecurrenceID は繰り返しの特定の回を置き換える予定の場合に、置き換える回の開始日時
+	RecurrenceID time.Time
+	// Transparent は時間を占有しない予定か（TRANSP:TRANSPARENT）
+	Transparent bool
+	// Cancelled はキャンセルされた予定か（STATUS:CANCELLED）
+	Cancelled bool
+}
+
+// Period は開始と終了の日時で表す時間帯
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func (p property) param(name string) string {
+	return p.params[name]
+}
+
+// windowsZones はOutlookなどが出力するWindowsのタイムゾーン名のうち主なものとIANA名の対応
+var windowsZones = map[string]string{
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"China Standard Time":            "Asia/Shanghai",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"India Standard Time":            "Asia/Kolkata",
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Eastern Standard Time":          "America/New_York",
+	"Central Standard Time":          "America/Chicago",
+	"Mountain Standard Time":         "America/Denver",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Central European Standard Time": "Europe/Warsaw",
+}
+
+// Parse はiCalendarデータからVEVENTを読み取る
+// タイムゾーンの指定がない日時（フローティング）と終日の予定の日付はlocの時刻として扱う
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stack     []string
+		rawEvents [][]property
+		current   []property
+		// VTIMEZONEごとの標準時のオフセット（IANA名で解決できないTZIDの代わりに使う）
+		offsets = map[string]int{}
+		tzid    string
+		found   bool
+	)
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCalendar, i+1, err)
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			component := strings.ToUpper(prop.value)
+			if len(stack) == 0 && component != "VCALENDAR" {
+				return nil, fmt.Errorf("%w: expected BEGIN:VCALENDAR", ErrInvalidCalendar)
+			}
+			found = true
+			stack = append(stack, component)
+			switch component {
+			case "VEVENT":
+				current = nil
+			case "VTIMEZONE":
+				tzid = ""
+			}
+			continue
+		case "END":
+			component := strings.ToUpper(prop.value)
+			if len(stack) == 0 || stack[len(stack)-1] != component {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrInvalidCalendar, prop.value)
+			}
+			stack = stack[:len(stack)-1]
+			if component == "VEVENT" {
+				rawEvents = append(rawEvents, current)
+			}
+			continue
+		}
+		if len(stack) == 0 {
+			return nil, fmt.Errorf("%w: expected BEGIN:VCALENDAR", ErrInvalidCalendar)
+		}
+
+		switch top := stack[len(stack)-1]; {
+		case top == "VEVENT":
+			current = append(current, prop)
+		case top == "VTIMEZONE" && prop.name == "TZID":
+			tzid = prop.value
+		case (top == "STANDARD" || top == "DAYLIGHT") && prop.name == "TZOFFSETTO" && tzid != "":
+			offset, err := parseUTCOffset(prop.value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCalendar, i+1, err)
+			}
+			// 夏時間の規則までは解釈せず、標準時のオフセットを優先する
+			if _, ok := offsets[tzid]; !ok || top == "STANDARD" {
+				offsets[tzid] = offset
+			}
+		}
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%w: missing END:%s", ErrInvalidCalendar, stack[len(stack)-1])
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no VCALENDAR", ErrInvalidCalendar)
+	}
+
+	p := &parser{loc: loc, offsets: offsets, zones: map[string]*time.Location{}}
+	events := make([]Event, 0, len(rawEvents))
+	for _, props := range rawEvents {
+		event, err := p.event(props)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// unfold は折り返された行を元の1行に戻す
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+	return lines, nil
+}
+
+// parseProperty はコンテンツ行を名前・パラメーター・値に分ける
+// パラメーターの値は二重引用符で囲まれている場合があり、その中の「;」「:」は区切りとして扱わない
+func parseProperty(line string) (property, error) {
+	prop := property{params: map[string]string{}}
+
+	inQuote := false
+	start := 0
+	var name string
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case ch == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == ';' || ch == ':':
+			segment := line[start:i]
+			if name == "" {
+				name = segment
+			} else if key, value, ok := strings.Cut(segment, "="); ok {
+				prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+			}
+			start = i + 1
+			if ch == ':' {
+				if name == "" {
+					return prop, errors.New("missing property name")
+				}
+				prop.name = strings.ToUpper(name)
+				prop.value = line[i+1:]
+				return prop, nil
+			}
+		}
+	}
+	return prop, fmt.Errorf("malformed content line %q", line)
+}
+
+type parser struct {
+	loc     *time.Location
+	offsets map[string]int
+	zones   map[string]*time.Location
+}
+
+func (p *parser) event(props []property) (Event, error) {
+	var (
+		event    Event
+		hasStart bool
+		hasEnd   bool
+		duration string
+	)
+	for _, prop := range props {
+		var err error
+		switch prop.name {
+		case "UID":
+			event.UID = prop.value
+		case "SUMMARY":
+			event.Summary = UnescapeText(prop.value)
+		case "DTSTART":
+			var times []time.Time
+			times, event.AllDay, err = p.dateTimes(prop)
+			if err == nil {
+				event.Start, hasStart = times[0], true
+			}
+		case "DTEND":
+			var times []time.Time
+			times, _, err = p.dateTimes(prop)
+			if err == nil {
+				event.End, hasEnd = times[0], true
+			}
+		case "DURATION":
+			duration = prop.value
+		case "RRULE":
+			event.RRule = prop.value
+		case "EXDATE":
+			var times []time.Time
+			times, _, err = p.dateTimes(prop)
+			event.ExDates = append(event.ExDates, times...)
+		case "RECURRENCE-ID":
+			var times []time.Time
+			times, _, err = p.dateTimes(prop)
+			if err == nil {
+				event.RecurrenceID = times[0]
+			}
+		case "TRANSP":
+			event.Transparent = strings.EqualFold(prop.value, "TRANSPARENT")
+		case "STATUS":
+			event.Cancelled = strings.EqualFold(prop.value, "CANCELLED")
+		}
+		if err != nil {
+			return Event{}, fmt.Errorf("%w: event %q: %s: %v", ErrInvalidCalendar, event.UID, prop.name, err)
+		}
+	}
+
+	if !hasStart {
+		return Event{}, fmt.Errorf("%w: event %q has no DTSTART", ErrInvalidCalendar, event.UID)
+	}
+	switch {
+	case hasEnd:
+	case duration != "":
+		end, err := addDuration(event.Start, duration)
+		if err != nil {
+			return Event{}, fmt.Errorf("%w: event %q: DURATION: %v", ErrInvalidCalendar, event.UID, err)
+		}
+		event.End = end
+	case event.AllDay:
+		event.End = event.Start.AddDate(0, 0, 1)
+	default:
+		event.End = event.Start
+	}
+	if event.End.Before(event.Start) {
+		return Event{}, fmt.Errorf("%w: event %q ends before it starts", ErrInvalidCalendar, event.UID)
+	}
+	return event, nil
+}
+
+// dateTimes はDATE-TIMEまたはDATEの値（カンマ区切りで複数の場合もある）を解釈する
+func (p *parser) dateTimes(prop property) ([]time.Time, bool, error) {
+	loc := p.loc
+	if tzid := prop.param("TZID"); tzid != "" {
+		resolved, err := p.location(tzid)
+		if err != nil {
+			return nil, false, err
+		}
+		loc = resolved
+	}
+
+	allDay := strings.EqualFold(prop.param("VALUE"), "DATE")
+	var times []time.Time
+	for _, value := range strings.Split(prop.value, ",") {
+		value = strings.TrimSpace(value)
+		var (
+			t   time.Time
+			err error
+		)
+		switch {
+		case allDay || len(value) == len("20060102"):
+			allDay = true
+			t, err = time.ParseInLocation("20060102", value, p.loc)
+		case strings.HasSuffix(value, "Z"):
+			t, err = time.Parse(dateTimeFormat, value)
+		default:
+			t, err = time.ParseInLocation("20060102T150405", value, loc)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		times = append(times, t)
+	}
+	return times, allDay, nil
+}
+
+// location はTZIDをタイムゾーンに解決する
+// IANA名、主なWindowsのタイムゾーン名、VTIMEZONEの標準時のオフセットの順に試す
+func (p *parser) location(tzid string) (*time.Location, error) {
+	if loc, ok := p.zones[tzid]; ok {
+		return loc, nil
+	}
+
+	name := strings.TrimPrefix(tzid, "/")
+	if mapped, ok := windowsZones[name]; ok {
+		name = mapped
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		offset, ok := p.offsets[tzid]
+		if !ok {
+			return nil, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = time.FixedZone(tzid, offset)
+	}
+	p.zones[tzid] = loc
+	return loc, nil
+}
+
+// parseUTCOffset はUTC-OFFSET（例: +0900, -0430）を秒に変換する
+func parseUTCOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid utc offset %q", value)
+	}
+	sign := 1
+	switch value[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return 0, fmt.Errorf("invalid utc offset %q", value)
+	}
+	digits, err := strconv.Atoi(value[1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid utc offset %q", value)
+	}
+	if len(value) == 5 {
+		digits *= 100
+	}
+	hours, minutes, seconds := digits/10000, digits/100%100, digits%100
+	return sign * (hours*3600 + minutes*60 + seconds), nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// addDuration はDURATION（例: PT1H30M, P1D）をtに加える
+// 日と週は暦の上で加え、夏時間の切り替えをまたいでも同じ時刻にする
+func addDuration(t time.Time, value string) (time.Time, error) {
+	m := durationPattern.FindStringSubmatch(strings.ToUpper(value))
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return time.Time{}, fmt.Errorf("invalid duration %q", value)
+	}
+	n := make([]int, 5)
+	for i, s := range m[2:] {
+		if s != "" {
+			n[i], _ = strconv.Atoi(s)
+		}
+	}
+	sign := 1
+	if m[1] == "-" {
+		sign = -1
+	}
+	d := time.Duration(n[2])*time.Hour + time.Duration(n[3])*time.Minute + time.Duration(n[4])*time.Second
+	return t.AddDate(0, 0, sign*(n[0]*7+n[1])).Add(time.Duration(sign) * d), nil
+}
+
+var textUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+// UnescapeText はTEXT型の値のエスケープを戻す
+func UnescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
@@ -0,0 +1,300 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+// crlf はテスト用のカレンダーの改行をCRLFにそろえる
+func crlf(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestParse(t *testing.T) {
+	t.Run("タイムゾーン・終日・DURATION・折り返しを解釈する", func(t *testing.T) {
+		data := crlf(
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"BEGIN:VEVENT",
+			"UID:tzid@example.com",
+			"SUMMARY:定例\\, 週次",
+			"DTSTART;TZID=America/New_York:20261020T090000",
+			"DTEND;TZID=America/New_York:20261020T100000",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:utc@example.com",
+			"DTSTART:20261020T010000Z",
+			"DURATION:PT1H30M",
+			"DESCRIPTION:長い説明は",
+			"  折り返される",
+			"BEGIN:VALARM",
+			"TRIGGER:-PT15M",
+			"END:VALARM",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:allday@example.com",
+			"DTSTART;VALUE=DATE:20261021",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:floating@example.com",
+			"DTSTART:20261022T130000",
+			"DTEND:20261022T140000",
+			"TRANSP:TRANSPARENT",
+			"STATUS:CANCELLED",
+			"END:VEVENT",
+			"END:VCALENDAR",
+		)
+
+		events, err := Parse(strings.NewReader(data), jst)
+		require.NoError(t, err)
+		require.Len(t, events, 4)
+
+		newYork, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+		assert.Equal(t, "定例, 週次", events[0].Summary)
+		assert.True(t, events[0].Start.Equal(time.Date(2026, 10, 20, 9, 0, 0, 0, newYork)))
+		assert.Equal(t, time.Hour, events[0].End.Sub(events[0].Start))
+
+		assert.True(t, events[1].Start.Equal(time.Date(2026, 10, 20, 10, 0, 0, 0, jst)))
+		assert.Equal(t, 90*time.Minute, events[1].End.Sub(events[1].Start))
+
+		assert.True(t, events[2].AllDay)
+		assert.True(t, events[2].Start.Equal(time.Date(2026, 10, 21, 0, 0, 0, 0, jst)))
+		assert.True(t, events[2].End.Equal(time.Date(2026, 10, 22, 0, 0, 0, 0, jst)))
+
+		// フローティングの日時は指定したタイムゾーンの時刻になる
+		assert.True(t, events[3].Start.Equal(time.Date(2026, 10, 22, 13, 0, 0, 0, jst)))
+		assert.True(t, events[3].Transparent)
+		assert.True(t, events[3].Cancelled)
+	})
+
+	t.Run("IANA名でないTZIDはWindowsの名前かVTIMEZONEのオフセットで解決する", func(t *testing.T) {
+		data := crlf(
+			"BEGIN:VCALENDAR",
+			"BEGIN:VTIMEZONE",
+			"TZID:Custom Zone",
+			"BEGIN:DAYLIGHT",
+			"TZOFFSETTO:+1000",
+			"END:DAYLIGHT",
+			"BEGIN:STANDARD",
+			"TZOFFSETTO:+0930",
+			"END:STANDARD",
+			"END:VTIMEZONE",
+			"BEGIN:VEVENT",
+			"UID:custom",
+			`DTSTART;TZID="Custom Zone":20261020T090000`,
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:windows",
+			"DTSTART;TZID=Tokyo Standard Time:20261020T090000",
+			"END:VEVENT",
+			"END:VCALENDAR",
+		)
+
+		events, err := Parse(strings.NewReader(data), time.UTC)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.True(t, events[0].Start.Equal(time.Date(2026, 10, 19, 23, 30, 0, 0, time.UTC)))
+		assert.True(t, events[1].Start.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("不正なデータはErrInvalidCalendarを返す", func(t *testing.T) {
+		tests := map[string]string{
+			"空":            "",
+			"VCALENDARでない": crlf("BEGIN:VEVENT", "END:VEVENT"),
+			"閉じていない":       crlf("BEGIN:VCALENDAR", "BEGIN:VEVENT", "DTSTART:20261020T090000Z"),
+			"DTSTARTがない":   crlf("BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:x", "END:VEVENT", "END:VCALENDAR"),
+			"日時が不正":        crlf("BEGIN:VCALENDAR", "BEGIN:VEVENT", "DTSTART:tomorrow", "END:VEVENT", "END:VCALENDAR"),
+			"未知のTZID":      crlf("BEGIN:VCALENDAR", "BEGIN:VEVENT", "DTSTART;TZID=Nowhere:20261020T090000", "END:VEVENT", "END:VCALENDAR"),
+			"終了が開始より前":     crlf("BEGIN:VCALENDAR", "BEGIN:VEVENT", "DTSTART:20261020T090000Z", "DTEND:20261020T080000Z", "END:VEVENT", "END:VCALENDAR"),
+		}
+		for name, data := range tests {
+			_, err := Parse(strings.NewReader(data), jst)
+			assert.ErrorIs(t, err, ErrInvalidCalendar, name)
+		}
+	})
+}
+
+func TestRule_Starts(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, jst) // 月曜日
+	format := func(starts []time.Time) []string {
+		var result []string
+		for _, s := range starts {
+			result = append(result, s.Format("01/02 Mon 15:04"))
+		}
+		return result
+	}
+	expand := func(t *testing.T, rrule string, from time.Time, before time.Time) []string {
+		t.Helper()
+		rule, err := ParseRule(rrule, from)
+		require.NoError(t, err)
+		return format(rule.Starts(from, before))
+	}
+
+	t.Run("毎日・回数指定", func(t *testing.T) {
+		assert.Equal(t, []string{"10/05 Mon 10:00", "10/07 Wed 10:00", "10/09 Fri 10:00"},
+			expand(t, "FREQ=DAILY;INTERVAL=2;COUNT=3", start, start.AddDate(1, 0, 0)))
+	})
+
+	t.Run("毎週複数の曜日・終了日指定", func(t *testing.T) {
+		assert.Equal(t, []string{"10/05 Mon 10:00", "10/07 Wed 10:00", "10/12 Mon 10:00", "10/14 Wed 10:00"},
+			expand(t, "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20261014", start, start.AddDate(1, 0, 0)))
+	})
+
+	t.Run("隔週は週の始まりから数える", func(t *testing.T) {
+		assert.Equal(t, []string{"10/05 Mon 10:00", "10/09 Fri 10:00", "10/19 Mon 10:00", "10/23 Fri 10:00"},
+			expand(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", start, start.AddDate(0, 0, 21)))
+	})
+
+	t.Run("毎月第2火曜日と最終金曜日", func(t *testing.T) {
+		first := time.Date(2026, 10, 13, 10, 0, 0, 0, jst)
+		assert.Equal(t, []string{"10/13 Tue 10:00", "10/30 Fri 10:00", "11/10 Tue 10:00", "11/27 Fri 10:00"},
+			expand(t, "FREQ=MONTHLY;BYDAY=2TU,-1FR", first, time.Date(2026, 12, 1, 0, 0, 0, 0, jst)))
+	})
+
+	t.Run("31日の毎月の予定は31日がない月を飛ばす", func(t *testing.T) {
+		first := time.Date(2026, 10, 31, 10, 0, 0, 0, jst)
+		assert.Equal(t, []string{"10/31 Sat 10:00", "12/31 Thu 10:00"},
+			expand(t, "FREQ=MONTHLY;COUNT=2", first, time.Date(2027, 6, 1, 0, 0, 0, 0, jst)))
+	})
+
+	t.Run("毎年", func(t *testing.T) {
+		rule, err := ParseRule("FREQ=YEARLY;BYMONTH=10;BYDAY=1MO", start)
+		require.NoError(t, err)
+		starts := rule.Starts(start, time.Date(2028, 1, 1, 0, 0, 0, 0, jst))
+		require.Len(t, starts, 2)
+		assert.Equal(t, "2027-10-04", starts[1].Format("2006-01-02"))
+	})
+
+	t.Run("夏時間をまたいでも同じ時刻に繰り返す", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+		first := time.Date(2026, 10, 30, 9, 0, 0, 0, newYork)
+		rule, err := ParseRule("FREQ=DAILY;COUNT=4", first)
+		require.NoError(t, err)
+		for _, s := range rule.Starts(first, first.AddDate(0, 1, 0)) {
+			assert.Equal(t, 9, s.Hour())
+		}
+	})
+
+	t.Run("対応していないルールはErrUnsupportedRuleを返す", func(t *testing.T) {
+		for _, rrule := range []string{"FREQ=HOURLY", "FREQ=MONTHLY;BYSETPOS=-1;BYDAY=MO,TU", "FREQ=YEARLY;BYDAY=MO", "FREQ=WEEKLY;BYDAY=2MO"} {
+			_, err := ParseRule(rrule, start)
+			assert.ErrorIs(t, err, ErrUnsupportedRule, rrule)
+		}
+		_, err := ParseRule("FREQ=DAILY;COUNT=0", start)
+		assert.ErrorIs(t, err, ErrInvalidCalendar)
+	})
+}
+
+func TestBusyPeriods(t *testing.T) {
+	data := crlf(
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"DTSTART;TZID=Asia/Tokyo:20261019T100000",
+		"DTEND;TZID=Asia/Tokyo:20261019T103000",
+		"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+		"EXDATE;TZID=Asia/Tokyo:20261021T100000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"RECURRENCE-ID;TZID=Asia/Tokyo:20261022T100000",
+		"DTSTART;TZID=Asia/Tokyo:20261022T150000",
+		"DTEND;TZID=Asia/Tokyo:20261022T153000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:free",
+		"DTSTART;TZID=Asia/Tokyo:20261020T130000",
+		"DTEND;TZID=Asia/Tokyo:20261020T140000",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:hourly",
+		"DTSTART;TZID=Asia/Tokyo:20261020T090000",
+		"RRULE:FREQ=HOURLY",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	events, err := Parse(strings.NewReader(data), jst)
+	require.NoError(t, err)
+
+	from := time.Date(2026, 10, 20, 0, 0, 0, 0, jst)
+	busy, skipped, err := BusyPeriods(events, from, from.AddDate(0, 0, 5))
+	require.NoError(t, err)
+
+	var got []string
+	for _, period := range busy {
+		got = append(got, period.Start.In(jst).Format("01/02 15:04")+"-"+period.End.In(jst).Format("15:04"))
+	}
+	// 10/21は除外、10/22は15時に移動、10/24・25は週末
+	assert.Equal(t, []string{"10/20 10:00-10:30", "10/22 15:00-15:30", "10/23 10:00-10:30"}, got)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "hourly", skipped[0].UID)
+}
+
+func TestBusyPeriods_Limits(t *testing.T) {
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, jst)
+	to := from.AddDate(0, 0, 31)
+	hostile := func(n int, rrule string) []Event {
+		events := make([]Event, n)
+		for i := range events {
+			start := time.Date(1900, 1, 1, 9, 0, 0, 0, jst)
+			events[i] = Event{UID: fmt.Sprintf("hostile-%d", i), Start: start, End: start.Add(time.Hour), RRule: rrule}
+		}
+		return events
+	}
+
+	t.Run("COUNTのない繰り返しは期間の直前から展開する", func(t *testing.T) {
+		started := time.Now()
+		busy, _, err := BusyPeriods(hostile(1, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR,SA,SU"), from, to)
+		require.NoError(t, err)
+		require.Len(t, busy, 31)
+		assert.Equal(t, "2026-10-19 09:00", busy[0].Start.Format("2006-01-02 15:04"))
+		assert.Equal(t, "2026-11-18 09:00", busy[30].Start.Format("2006-01-02 15:04"))
+		assert.Less(t, time.Since(started), time.Second)
+
+		for _, rrule := range []string{"FREQ=DAILY;INTERVAL=3", "FREQ=MONTHLY;BYMONTHDAY=20", "FREQ=YEARLY;BYMONTH=10,11;BYDAY=-1MO"} {
+			event := hostile(1, rrule)[0]
+			rule, err := ParseRule(rrule, event.Start)
+			require.NoError(t, err)
+			// 最初から展開した結果のうち期間と重なる回と一致すること
+			var want []time.Time
+			for _, s := range rule.Starts(event.Start, to) {
+				if !s.Before(from.Add(-time.Hour)) {
+					want = append(want, s)
+				}
+			}
+			busy, _, err := BusyPeriods([]Event{event}, from, to)
+			require.NoError(t, err, rrule)
+			var got []time.Time
+			for _, period := range busy {
+				got = append(got, period.Start)
+			}
+			assert.Equal(t, want, got, rrule)
+		}
+	})
+
+	t.Run("展開する候補が多すぎる場合はErrTooManyOccurrencesを返す", func(t *testing.T) {
+		started := time.Now()
+		_, _, err := BusyPeriods(hostile(8000, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR,SA,SU"), from, to)
+		assert.ErrorIs(t, err, ErrTooManyOccurrences)
+
+		_, _, err = BusyPeriods(hostile(100, "FREQ=WEEKLY;COUNT=1000000;BYDAY=MO,TU,WE,TH,FR,SA,SU"), from, to)
+		assert.ErrorIs(t, err, ErrTooManyOccurrences)
+		assert.Less(t, time.Since(started), 2*time.Second)
+	})
+
+	t.Run("予定が多すぎる場合はErrTooManyOccurrencesを返す", func(t *testing.T) {
+		_, _, err := BusyPeriods(hostile(MaxEvents+1, ""), from, to)
+		assert.ErrorIs(t, err, ErrTooManyOccurrences)
+	})
+}
@@ -0,0 +1,525 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedRule は対応していない繰り返しルールの場合に返される
+var ErrUnsupportedRule = errors.New("ical: unsupported recurrence rule")
+
+// ErrTooManyOccurrences は予定の数や、繰り返しを展開するときに調べる候補の数が上限を超えた場合に返される
+var ErrTooManyOccurrences = errors.New("ical: too many events or occurrences")
+
+// maxRulePeriods は繰り返しを展開するときに調べる期間（日・週・月・年）の上限
+// 遠い過去から始まる毎日の予定でも展開できる程度にしつつ、不正なルールで止まらないようにする
+const maxRulePeriods = 50000
+
+// MaxEvents はBusyPeriodsで扱う予定の数の上限
+const MaxEvents = 10000
+
+// MaxExpandedCandidates はBusyPeriodsで全ての予定の繰り返しを展開するときに調べる候補の日の合計の上限
+// COUNTのない繰り返しは期間の直前から展開するため、通常のカレンダーではこの上限に達しない
+const MaxExpandedCandidates = 200000
+
+// Frequency は繰り返しの単位
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum はBYDAYの要素（例: MO, 2TU, -1FR）
+// Nが0の場合は期間内のすべての該当する曜日を表す
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule はRRULEのうち対応している要素
+// BYSETPOS・BYHOUR・BYWEEKNOなどを含むルールはErrUnsupportedRuleになる
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ParseRule はRRULEの値を解釈する
+// 日付だけのUNTILや、タイムゾーンの指定がないUNTILはstartと同じタイムゾーンとして扱う
+func ParseRule(value string, start time.Time) (*Rule, error) {
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed rule part %q", ErrInvalidCalendar, part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				return nil, fmt.Errorf("%w: FREQ=%s", ErrUnsupportedRule, val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("must be positive")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("must be positive")
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(val, start.Location())
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(val, 1, 12)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			weekday, ok := weekdayCodes[strings.ToUpper(val)]
+			if !ok {
+				err = errors.New("unknown weekday")
+			}
+			rule.WeekStart = weekday
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRule, strings.ToUpper(key))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s=%s: %v", ErrInvalidCalendar, strings.ToUpper(key), val, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: rule has no FREQ", ErrInvalidCalendar)
+	}
+	for _, day := range rule.ByDay {
+		// 序数付きの曜日は月単位の繰り返しでのみ意味を持つ
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("%w: BYDAY with ordinal for FREQ=%s", ErrUnsupportedRule, rule.Freq)
+		}
+	}
+	// 年単位のBYDAYは年全体の曜日を表すため、月を指定した場合だけ対応する
+	if rule.Freq == Yearly && len(rule.ByDay) > 0 && len(rule.ByMonth) == 0 {
+		return nil, fmt.Errorf("%w: BYDAY without BYMONTH for FREQ=YEARLY", ErrUnsupportedRule)
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return nil, fmt.Errorf("%w: BYMONTHDAY for FREQ=WEEKLY", ErrUnsupportedRule)
+	}
+	return rule, nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	switch {
+	case len(value) == len("20060102"):
+		// 日付だけの場合はその日の終わりまでを含める
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), err
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(dateTimeFormat, value)
+	default:
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		weekday, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		n := 0
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			var err error
+			n, err = strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid weekday %q", item)
+			}
+		}
+		days = append(days, WeekdayNum{Weekday: weekday, N: n})
+	}
+	return days, nil
+}
+
+func parseInts(value string, min, max int) ([]int, error) {
+	var result []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// Starts は繰り返しの各回の開始日時を、before より前のものだけ時刻順に返す
+// 最初の回は常にstart（DTSTART）で、COUNTにも数える
+func (r *Rule) Starts(start, before time.Time) []time.Time {
+	starts, _ := r.expand(start, start, before, nil)
+	return starts
+}
+
+// expand はStartsと同じく開始日時を返すが、COUNTのないルールではafterを含む期間の直前から展開する
+// そのためafterより前の回は（最初の回を除いて）含まれないことがある
+// budgetがnilでなければ調べた候補の日の数だけ減らし、足りなくなった場合はErrTooManyOccurrencesを返す
+func (r *Rule) expand(start, after, before time.Time, budget *int) ([]time.Time, error) {
+	var starts []time.Time
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		if !t.Before(before) {
+			return false
+		}
+		starts = append(starts, t)
+		emitted++
+		return r.Count == 0 || emitted < r.Count
+	}
+
+	if !emit(start) {
+		return starts, nil
+	}
+	// COUNTは最初の回から数える必要があるため、COUNTのないルールだけ先に進める
+	first := 0
+	if r.Count == 0 {
+		first = r.periodsBefore(start, after)
+	}
+	for k := first; k < first+maxRulePeriods; k++ {
+		if !r.periodStart(start, k).Before(before) {
+			break
+		}
+		candidates, examined := r.candidates(start, k)
+		if budget != nil {
+			if *budget -= examined + 1; *budget < 0 {
+				return nil, fmt.Errorf("%w: expanding recurrences needs more than %d candidates", ErrTooManyOccurrences, MaxExpandedCandidates)
+			}
+		}
+		for _, t := range candidates {
+			if !t.After(start) {
+				continue
+			}
+			if !emit(t) {
+				return starts, nil
+			}
+		}
+	}
+	return starts, nil
+}
+
+// periodsBefore はafterより前に終わることが確実な期間の数を返す
+// 夏時間や月の長さによるずれを見込み、計算した期間の1つ前から展開させる
+func (r *Rule) periodsBefore(start, after time.Time) int {
+	if !after.After(start) {
+		return 0
+	}
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = int(after.Sub(start)/(24*time.Hour)) / r.Interval
+	case Weekly:
+		periods = int(after.Sub(start)/(7*24*time.Hour)) / r.Interval
+	case Monthly:
+		sy, sm, _ := start.Date()
+		ay, am, _ := after.In(start.Location()).Date()
+		periods = ((ay-sy)*12 + int(am-sm)) / r.Interval
+	case Yearly:
+		periods = (after.In(start.Location()).Year() - start.Year()) / r.Interval
+	}
+	if periods <= 1 {
+		return 0
+	}
+	return periods - 1
+}
+
+// periodStart はk番目の期間（日・週・月・年）の最初の日のDTSTARTと同じ時刻を返す
+// その期間の候補はすべてこの日時以降になる
+func (r *Rule) periodStart(start time.Time, k int) time.Time {
+	loc := start.Location()
+	hour, minute, second := start.Clock()
+	y, m, d := start.Date()
+	switch r.Freq {
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		d = d - offset + 7*k*r.Interval
+	case Monthly:
+		m, d = m+time.Month(k*r.Interval), 1
+	case Yearly:
+		y, m, d = y+k*r.Interval, time.January, 1
+	default:
+		d += k * r.Interval
+	}
+	return time.Date(y, m, d, hour, minute, second, start.Nanosecond(), loc)
+}
+
+// candidates はk番目の期間（日・週・月・年）に含まれる候補の開始日時を時刻順に返す
+// examinedは絞り込む前に調べた日の数
+func (r *Rule) candidates(start time.Time, k int) (result []time.Time, examined int) {
+	loc := start.Location()
+	hour, minute, second := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, second, start.Nanosecond(), loc)
+	}
+	y, m, d := start.Date()
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{at(y, m, d+k*r.Interval)}
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := at(y, m, d-offset+7*k*r.Interval)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != start.Weekday() {
+				continue
+			}
+			days = append(days, day)
+		}
+	case Monthly:
+		first := at(y, m+time.Month(k*r.Interval), 1)
+		days = r.daysInMonth(start, first, at)
+	case Yearly:
+		year := y + k*r.Interval
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			days = append(days, r.daysInMonth(start, at(year, month, 1), at)...)
+		}
+	}
+
+	for _, day := range days {
+		if r.matches(day) {
+			result = append(result, day)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result, len(days)
+}
+
+// daysInMonth はfirstの月のうち、BYMONTHDAY・BYDAY（指定がなければDTSTARTの日）に当たる日を返す
+func (r *Rule) daysInMonth(start, first time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	y, m, _ := first.Date()
+	last := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var days []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, n := range r.ByMonthDay {
+			if n < 0 {
+				n = last + n + 1
+			}
+			if n >= 1 && n <= last {
+				days = append(days, at(y, m, n))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			var matched []time.Time
+			for day := 1; day <= last; day++ {
+				if t := at(y, m, day); t.Weekday() == wd.Weekday {
+					matched = append(matched, t)
+				}
+			}
+			switch {
+			case wd.N == 0:
+				days = append(days, matched...)
+			case wd.N > 0 && wd.N <= len(matched):
+				days = append(days, matched[wd.N-1])
+			case wd.N < 0 && -wd.N <= len(matched):
+				days = append(days, matched[len(matched)+wd.N])
+			}
+		}
+	default:
+		// 31日始まりの毎月の予定は31日がない月には発生しない
+		if day := start.Day(); day <= last {
+			days = append(days, at(y, m, day))
+		}
+	}
+	return days
+}
+
+// matches はBYMONTH・BYDAY・BYMONTHDAYによる絞り込みに当てはまるかを判定する
+func (r *Rule) matches(t time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, t.Month()) {
+		return false
+	}
+	if len(r.ByDay) > 0 && (r.Freq == Daily || r.Freq == Weekly || len(r.ByMonthDay) > 0) {
+		matched := false
+		for _, wd := range r.ByDay {
+			if wd.Weekday == t.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == Daily {
+		last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		matched := false
+		for _, n := range r.ByMonthDay {
+			if n == t.Day() || last+n+1 == t.Day() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// Occurrences は予定の各回のうち[from, to)と重なるものを返す
+// EXDATEで除外された回は含めない
+func (e Event) Occurrences(from, to time.Time) ([]Period, error) {
+	return e.occurrences(from, to, nil)
+}
+
+// occurrences はOccurrencesと同じ回を返し、budgetがnilでなければ繰り返しの展開で調べた候補の数だけ減らす
+func (e Event) occurrences(from, to time.Time, budget *int) ([]Period, error) {
+	duration := e.End.Sub(e.Start)
+	starts := []time.Time{e.Start}
+	if e.RRule != "" {
+		rule, err := ParseRule(e.RRule, e.Start)
+		if err != nil {
+			return nil, err
+		}
+		// fromより前に始まってfromの後まで続く回も含めるため、予定の長さの分だけ前から展開する
+		starts, err = rule.expand(e.Start, from.Add(-duration), to, budget)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var periods []Period
+	for _, start := range starts {
+		if e.excluded(start) {
+			continue
+		}
+		end := start.Add(duration)
+		if e.AllDay {
+			// 終日の予定は夏時間の切り替えをまたいでも日付単位で終わる
+			end = start.AddDate(0, 0, int(duration.Round(24*time.Hour)/(24*time.Hour)))
+		}
+		if start.Before(to) && end.After(from) {
+			periods = append(periods, Period{Start: start, End: end})
+		}
+	}
+	return periods, nil
+}
+
+// excluded はEXDATEで除外された回かを判定する
+// 終日の予定は日付だけで比較する
+func (e Event) excluded(start time.Time) bool {
+	for _, exdate := range e.ExDates {
+		if exdate.Equal(start) {
+			return true
+		}
+		if e.AllDay {
+			ey, em, ed := exdate.In(start.Location()).Date()
+			sy, sm, sd := start.Date()
+			if ey == sy && em == sm && ed == sd {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BusyPeriods は[from, to)の間で予定が入っている時間帯を返す
+// 時間を占有しない予定・キャンセルされた予定は含めず、RECURRENCE-IDで置き換えられた回は置き換え後の予定を使う
+// 対応していない繰り返しルールの予定はskippedに含めて、それ以外の予定から計算する
+// 予定がMaxEventsを超える場合や、繰り返しの展開がMaxExpandedCandidatesを超える場合はErrTooManyOccurrencesを返す
+func BusyPeriods(events []Event, from, to time.Time) (busy []Period, skipped []Event, err error) {
+	if len(events) > MaxEvents {
+		return nil, nil, fmt.Errorf("%w: more than %d events", ErrTooManyOccurrences, MaxEvents)
+	}
+	budget := MaxExpandedCandidates
+
+	type instance struct {
+		uid   string
+		start int64
+	}
+	overridden := map[instance]bool{}
+	for _, event := range events {
+		if !event.RecurrenceID.IsZero() {
+			overridden[instance{event.UID, event.RecurrenceID.UnixNano()}] = true
+		}
+	}
+
+	for _, event := range events {
+		if event.Transparent || event.Cancelled {
+			continue
+		}
+		occurrences, err := event.occurrences(from, to, &budget)
+		if errors.Is(err, ErrUnsupportedRule) {
+			skipped = append(skipped, event)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("event %q: %w", event.UID, err)
+		}
+		for _, occurrence := range occurrences {
+			if event.RecurrenceID.IsZero() && overridden[instance{event.UID, occurrence.Start.UnixNano()}] {
+				continue
+			}
+			busy = append(busy, occurrence)
+		}
+	}
+
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+	return busy, skipped, nil
+}
@@ -0,0 +1,214 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// Source はエクスポート元のスケジュール一覧を提供する
+type Source interface {
+	List(ctx context.Context) ([]*model.Schedule, error)
+}
+
+// Destination はインポート先のスケジュール保存先
+type Destination interface {
+	Create(ctx context.Context, schedule *model.Schedule) error
+}
+
+// Record はNDJSONの1行に対応するスケジュールのバックアップ形式
+// ID・編集トークン・短縮コード・作成日時・有効期限・閲覧パスワードのハッシュはインポート時にそのまま復元される
+type Record struct {
+	ID               string           `json:"id"`
+	EditToken        string           `json:"editToken"`
+	ShortCode        string           `json:"shortCode,omitempty"`
+	TimeSlots        []TimeSlotRecord `json:"timeSlots"`
+	Comment          string           `json:"comment"`
+	CreatedAt        time.Time        `json:"createdAt"`
+	UpdatedAt        time.Time        `json:"updatedAt"`
+	ExpiresAt        time.Time        `json:"expiresAt"`
+	ViewPasswordHash string           `json:"viewPasswordHash,omitempty"`
+	WebhookURLs      []string         `json:"webhookURLs,omitempty"`
+	WebhookSecret    string           `json:"webhookSecret,omitempty"`
+	NotifyEmail      string           `json:"notifyEmail,omitempty"`
+	NotifyLocale     string           `json:"notifyLocale,omitempty"`
+}
+
+type TimeSlotRecord struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Available bool      `json:"available"`
+}
+
+// Filter は作成日時による絞り込み条件（ゼロ値の境界は無制限）
+type Filter struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// Match はスケジュールが条件に一致するかを返す
+func (f Filter) Match(schedule *model.Schedule) bool {
+	if !f.CreatedAfter.IsZero() && schedule.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !schedule.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// ImportOptions はインポート時の設定
+type ImportOptions struct {
+	Filter Filter
+	// DryRun がtrueの場合は読み込みと検証のみ行い保存しない
+	DryRun bool
+}
+
+// Result はエクスポート・インポートの件数
+type Result struct {
+	Processed int
+	Skipped   int
+}
+
+// NewRecord はスケジュールからバックアップ形式を作成する
+func NewRecord(schedule *model.Schedule) Record {
+	slots := make([]TimeSlotRecord, len(schedule.TimeSlots))
+	for i, slot := range schedule.TimeSlots {
+		slots[i] = TimeSlotRecord{
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Available: slot.Available,
+		}
+	}
+
+	return Record{
+		ID:               schedule.ID,
+		EditToken:        schedule.EditToken,
+		ShortCode:        schedule.ShortCode,
+		TimeSlots:        slots,
+		Comment:          schedule.Comment,
+		CreatedAt:        schedule.CreatedAt,
+		UpdatedAt:        schedule.UpdatedAt,
+		ExpiresAt:        schedule.ExpiresAt,
+		ViewPasswordHash: schedule.ViewPasswordHash,
+		WebhookURLs:      schedule.WebhookURLs,
+		WebhookSecret:    schedule.WebhookSecret,
+		NotifyEmail:      schedule.NotifyEmail,
+		NotifyLocale:     schedule.NotifyLocale,
+	}
+}
+
+// Schedule はバックアップ形式からスケジュールを復元する
+func (r Record) Schedule() *model.Schedule {
+	slots := make([]model.TimeSlot, len(r.TimeSlots))
+	for i, slot := range r.TimeSlots {
+		slots[i] = model.TimeSlot{
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Available: slot.Available,
+		}
+	}
+
+	return &model.Schedule{
+		ID:               r.ID,
+		EditToken:        r.EditToken,
+		ShortCode:        r.ShortCode,
+		TimeSlots:        slots,
+		Comment:          r.Comment,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+		ExpiresAt:        r.ExpiresAt,
+		ViewPasswordHash: r.ViewPasswordHash,
+		WebhookURLs:      r.WebhookURLs,
+		WebhookSecret:    r.WebhookSecret,
+		NotifyEmail:      r.NotifyEmail,
+		NotifyLocale:     r.NotifyLocale,
+	}
+}
+
+// Validate は復元に必要な項目が揃っているかを確認する
+func (r Record) Validate() error {
+	if r.ID == "" {
+		return errors.New("id is required")
+	}
+	if r.EditToken == "" {
+		return errors.New("editToken is required")
+	}
+	if r.CreatedAt.IsZero() || r.ExpiresAt.IsZero() {
+		return errors.New("createdAt and expiresAt are required")
+	}
+	return nil
+}
+
+// Export はフィルタに一致するスケジュールを1行1件のNDJSONとして書き出す
+func Export(ctx context.Context, src Source, w io.Writer, filter Filter) (Result, error) {
+	var result Result
+
+	schedules, err := src.List(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, schedule := range schedules {
+		if !filter.Match(schedule) {
+			result.Skipped++
+			continue
+		}
+		if err := encoder.Encode(NewRecord(schedule)); err != nil {
+			return result, fmt.Errorf("failed to write schedule %s: %w", schedule.ID, err)
+		}
+		result.Processed++
+	}
+
+	return result, nil
+}
+
+// Import はNDJSONを読み込み、フィルタに一致するスケジュールを保存する
+// 同じIDのスケジュールが既に存在する場合は保存先の実装に従って上書きされる
+func Import(ctx context.Context, r io.Reader, dst Destination, opts ImportOptions) (Result, error) {
+	var result Result
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return result, fmt.Errorf("line %d: invalid record: %w", line, err)
+		}
+		if err := record.Validate(); err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		schedule := record.Schedule()
+		if !opts.Filter.Match(schedule) {
+			result.Skipped++
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := dst.Create(ctx, schedule); err != nil {
+				return result, fmt.Errorf("line %d: failed to import schedule %s: %w", line, schedule.ID, err)
+			}
+		}
+		result.Processed++
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+// fakeStore はテスト用のインメモリな保存先
+type fakeStore struct {
+	schedules []*model.Schedule
+}
+
+func (s *fakeStore) List(ctx context.Context) ([]*model.Schedule, error) {
+	return s.schedules, nil
+}
+
+func (s *fakeStore) Create(ctx context.Context, schedule *model.Schedule) error {
+	s.schedules = append(s.schedules, schedule)
+	return nil
+}
+
+func newBackupTestSchedule(id string, createdAt time.Time) *model.Schedule {
+	return &model.Schedule{
+		ID:        id,
+		EditToken: "token-" + id,
+		TimeSlots: []model.TimeSlot{
+			{
+				StartTime: createdAt.Add(24 * time.Hour),
+				EndTime:   createdAt.Add(25 * time.Hour),
+				Available: true,
+			},
+		},
+		Comment:   "バックアップテスト",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt.Add(time.Hour),
+		ExpiresAt: createdAt.Add(7 * 24 * time.Hour),
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+
+	t.Run("エクスポートした内容をインポートすると同じスケジュールが復元される", func(t *testing.T) {
+		src := &fakeStore{schedules: []*model.Schedule{
+			newBackupTestSchedule("backup-1", base),
+			newBackupTestSchedule("backup-2", base.Add(time.Hour)),
+		}}
+
+		var buf bytes.Buffer
+		exported, err := Export(ctx, src, &buf, Filter{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, exported.Processed)
+		assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+		dst := &fakeStore{}
+		imported, err := Import(ctx, &buf, dst, ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, imported.Processed)
+
+		require.Len(t, dst.schedules, 2)
+		for i, restored := range dst.schedules {
+			original := src.schedules[i]
+			assert.Equal(t, original.ID, restored.ID)
+			assert.Equal(t, original.EditToken, restored.EditToken)
+			assert.Equal(t, original.Comment, restored.Comment)
+			assert.True(t, original.CreatedAt.Equal(restored.CreatedAt))
+			assert.True(t, original.UpdatedAt.Equal(restored.UpdatedAt))
+			assert.True(t, original.ExpiresAt.Equal(restored.ExpiresAt))
+			require.Len(t, restored.TimeSlots, 1)
+			assert.True(t, original.TimeSlots[0].StartTime.Equal(restored.TimeSlots[0].StartTime))
+			assert.True(t, restored.TimeSlots[0].Available)
+		}
+	})
+
+	t.Run("作成日時で絞り込める", func(t *testing.T) {
+		src := &fakeStore{schedules: []*model.Schedule{
+			newBackupTestSchedule("old", base.Add(-48*time.Hour)),
+			newBackupTestSchedule("target", base),
+			newBackupTestSchedule("new", base.Add(48*time.Hour)),
+		}}
+		filter := Filter{CreatedAfter: base.Add(-time.Hour), CreatedBefore: base.Add(time.Hour)}
+
+		var buf bytes.Buffer
+		exported, err := Export(ctx, src, &buf, filter)
+		require.NoError(t, err)
+		assert.Equal(t, 1, exported.Processed)
+		assert.Equal(t, 2, exported.Skipped)
+		assert.Contains(t, buf.String(), `"id":"target"`)
+
+		var all bytes.Buffer
+		_, err = Export(ctx, src, &all, Filter{})
+		require.NoError(t, err)
+
+		dst := &fakeStore{}
+		imported, err := Import(ctx, &all, dst, ImportOptions{Filter: filter})
+		require.NoError(t, err)
+		assert.Equal(t, 1, imported.Processed)
+		assert.Equal(t, 2, imported.Skipped)
+		require.Len(t, dst.schedules, 1)
+		assert.Equal(t, "target", dst.schedules[0].ID)
+	})
+
+	t.Run("dry-runでは保存しない", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := Export(ctx, &fakeStore{schedules: []*model.Schedule{newBackupTestSchedule("dry", base)}}, &buf, Filter{})
+		require.NoError(t, err)
+
+		result, err := Import(ctx, &buf, nil, ImportOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Processed)
+	})
+
+	t.Run("不正な行は行番号付きのエラーになる", func(t *testing.T) {
+		input := `{"id":"ok","editToken":"t","createdAt":"2025-01-10T09:00:00Z","expiresAt":"2025-01-17T09:00:00Z"}
+{"id":"","editToken":"t","createdAt":"2025-01-10T09:00:00Z","expiresAt":"2025-01-17T09:00:00Z"}
+`
+		_, err := Import(ctx, strings.NewReader(input), &fakeStore{}, ImportOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 2")
+
+		_, err = Import(ctx, strings.NewReader("not json\n"), &fakeStore{}, ImportOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 1")
+	})
+}
@@ -0,0 +1,193 @@
+package diagnostics
+
+import (
+	"fmt"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// ドキュメントの状態
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+	StatusInvalid = "invalid"
+)
+
+// Issue はドキュメントで見つかった問題
+type Issue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// DocumentReport は1件のスケジュールドキュメントの診断結果
+type DocumentReport struct {
+	ID            string     `json:"id"`
+	Status        string     `json:"status"`
+	Comment       string     `json:"comment,omitempty"`
+	TimeSlotCount int        `json:"timeSlotCount"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	Issues        []Issue    `json:"issues,omitempty"`
+}
+
+// Summary は診断結果の件数
+type Summary struct {
+	Total   int `json:"total"`
+	Active  int `json:"active"`
+	Expired int `json:"expired"`
+	Invalid int `json:"invalid"`
+}
+
+// Report は診断結果全体
+type Report struct {
+	Summary   Summary          `json:"summary"`
+	Documents []DocumentReport `json:"documents"`
+}
+
+// Add は診断結果を追加して件数を更新する
+func (r *Report) Add(doc DocumentReport) {
+	r.Documents = append(r.Documents, doc)
+	r.Summary.Total++
+	switch doc.Status {
+	case StatusActive:
+		r.Summary.Active++
+	case StatusExpired:
+		r.Summary.Expired++
+	case StatusInvalid:
+		r.Summary.Invalid++
+	}
+}
+
+// InspectDocument はFirestoreに保存された生データを検証する
+// 型の誤りや必須項目の欠落に加え、モデルのタイムスロット検証も行う
+func InspectDocument(id string, data map[string]interface{}, now time.Time) DocumentReport {
+	report := DocumentReport{ID: id}
+	schedule := &model.Schedule{}
+
+	addIssue := func(field, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch v := data["id"].(type) {
+	case string:
+		schedule.ID = v
+		if v != id {
+			addIssue("id", "does not match document ID %q", id)
+		}
+	case nil:
+		addIssue("id", "is missing")
+	default:
+		addIssue("id", "must be a string, got %T", v)
+	}
+
+	switch v := data["editToken"].(type) {
+	case string:
+		schedule.EditToken = v
+		if v == "" {
+			addIssue("editToken", "is empty")
+		}
+	case nil:
+		addIssue("editToken", "is missing")
+	default:
+		addIssue("editToken", "must be a string, got %T", v)
+	}
+
+	switch v := data["comment"].(type) {
+	case string:
+		schedule.Comment = v
+		report.Comment = v
+	case nil:
+	default:
+		addIssue("comment", "must be a string, got %T", v)
+	}
+
+	if t, ok := inspectTime(data, "createdAt", addIssue); ok {
+		schedule.CreatedAt = t
+		report.CreatedAt = &t
+	}
+	expiresAt, hasExpiresAt := inspectTime(data, "expiresAt", addIssue)
+	if hasExpiresAt {
+		schedule.ExpiresAt = expiresAt
+		report.ExpiresAt = &expiresAt
+	}
+
+	switch v := data["timeSlots"].(type) {
+	case []interface{}:
+		for i, raw := range v {
+			field := fmt.Sprintf("timeSlots[%d]", i)
+			slotData, ok := raw.(map[string]interface{})
+			if !ok {
+				addIssue(field, "must be a map, got %T", raw)
+				continue
+			}
+
+			var slot model.TimeSlot
+			valid := true
+			if t, ok := inspectTime(slotData, "startTime", prefixed(field, addIssue)); ok {
+				slot.StartTime = t
+			} else {
+				valid = false
+			}
+			if t, ok := inspectTime(slotData, "endTime", prefixed(field, addIssue)); ok {
+				slot.EndTime = t
+			} else {
+				valid = false
+			}
+			switch a := slotData["available"].(type) {
+			case bool:
+				slot.Available = a
+			case nil:
+			default:
+				addIssue(field+".available", "must be a bool, got %T", a)
+			}
+
+			if valid {
+				schedule.TimeSlots = append(schedule.TimeSlots, slot)
+			}
+		}
+		report.TimeSlotCount = len(v)
+	case nil:
+		addIssue("timeSlots", "is missing")
+	default:
+		addIssue("timeSlots", "must be an array, got %T", v)
+	}
+
+	if err := schedule.ValidateTimeSlots(); err != nil {
+		addIssue("timeSlots", "%v", err)
+	}
+
+	switch {
+	case len(report.Issues) > 0:
+		report.Status = StatusInvalid
+	case !now.Before(expiresAt):
+		report.Status = StatusExpired
+	default:
+		report.Status = StatusActive
+	}
+	return report
+}
+
+type issueFunc func(field, format string, args ...interface{})
+
+func prefixed(prefix string, addIssue issueFunc) issueFunc {
+	return func(field, format string, args ...interface{}) {
+		addIssue(prefix+"."+field, format, args...)
+	}
+}
+
+func inspectTime(data map[string]interface{}, field string, addIssue issueFunc) (time.Time, bool) {
+	switch v := data[field].(type) {
+	case time.Time:
+		if v.IsZero() {
+			addIssue(field, "is zero")
+			return time.Time{}, false
+		}
+		return v, true
+	case nil:
+		addIssue(field, "is missing")
+	default:
+		addIssue(field, "must be a timestamp, got %T", v)
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,117 @@
+package diagnostics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validDocument(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        "doc-1",
+		"editToken": "token",
+		"comment":   "診断テスト",
+		"createdAt": now.Add(-time.Hour),
+		"expiresAt": now.Add(6 * 24 * time.Hour),
+		"timeSlots": []interface{}{
+			map[string]interface{}{
+				"startTime": now.Add(time.Hour),
+				"endTime":   now.Add(2 * time.Hour),
+				"available": true,
+			},
+		},
+	}
+}
+
+func issueFields(report DocumentReport) []string {
+	fields := make([]string, len(report.Issues))
+	for i, issue := range report.Issues {
+		fields[i] = issue.Field
+	}
+	return fields
+}
+
+func TestInspectDocument(t *testing.T) {
+	now := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+
+	t.Run("正常なドキュメントは有効と判定される", func(t *testing.T) {
+		report := InspectDocument("doc-1", validDocument(now), now)
+		assert.Equal(t, StatusActive, report.Status)
+		assert.Empty(t, report.Issues)
+		assert.Equal(t, 1, report.TimeSlotCount)
+		assert.Equal(t, "診断テスト", report.Comment)
+	})
+
+	t.Run("有効期限を過ぎたドキュメントは期限切れと判定される", func(t *testing.T) {
+		data := validDocument(now)
+		data["expiresAt"] = now.Add(-time.Minute)
+
+		report := InspectDocument("doc-1", data, now)
+		assert.Equal(t, StatusExpired, report.Status)
+	})
+
+	t.Run("timeSlotsが欠落したドキュメントを検出する", func(t *testing.T) {
+		data := validDocument(now)
+		delete(data, "timeSlots")
+
+		report := InspectDocument("doc-1", data, now)
+		assert.Equal(t, StatusInvalid, report.Status)
+		assert.Contains(t, issueFields(report), "timeSlots")
+	})
+
+	t.Run("型が不正なフィールドを検出する", func(t *testing.T) {
+		data := validDocument(now)
+		data["editToken"] = 123
+		data["expiresAt"] = "2025-01-17"
+		data["timeSlots"] = []interface{}{
+			map[string]interface{}{
+				"startTime": now,
+				"endTime":   "later",
+				"available": "yes",
+			},
+			"not a slot",
+		}
+
+		report := InspectDocument("doc-1", data, now)
+		assert.Equal(t, StatusInvalid, report.Status)
+		assert.ElementsMatch(t, []string{
+			"editToken",
+			"expiresAt",
+			"timeSlots[0].endTime",
+			"timeSlots[0].available",
+			"timeSlots[1]",
+		}, issueFields(report))
+	})
+
+	t.Run("モデルのバリデーションに失敗するタイムスロットを検出する", func(t *testing.T) {
+		data := validDocument(now)
+		data["timeSlots"] = []interface{}{
+			map[string]interface{}{"startTime": now.Add(time.Hour), "endTime": now.Add(3 * time.Hour)},
+			map[string]interface{}{"startTime": now.Add(2 * time.Hour), "endTime": now.Add(4 * time.Hour)},
+		}
+
+		report := InspectDocument("doc-1", data, now)
+		assert.Equal(t, StatusInvalid, report.Status)
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "time slots overlap", report.Issues[0].Message)
+	})
+
+	t.Run("IDがドキュメントIDと一致しない場合を検出する", func(t *testing.T) {
+		report := InspectDocument("other-id", validDocument(now), now)
+		assert.Equal(t, StatusInvalid, report.Status)
+		assert.Equal(t, []string{"id"}, issueFields(report))
+	})
+}
+
+func TestReport_Add(t *testing.T) {
+	var report Report
+	report.Add(DocumentReport{ID: "a", Status: StatusActive})
+	report.Add(DocumentReport{ID: "b", Status: StatusExpired})
+	report.Add(DocumentReport{ID: "c", Status: StatusInvalid})
+	report.Add(DocumentReport{ID: "d", Status: StatusActive})
+
+	assert.Equal(t, Summary{Total: 4, Active: 2, Expired: 1, Invalid: 1}, report.Summary)
+	assert.Len(t, report.Documents, 4)
+}
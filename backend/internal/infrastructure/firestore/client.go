@@ -15,20 +15,43 @@ type Client struct {
 	projectID string
 }
 
-func NewClient(ctx context.Context) (*Client, error) {
+// Config はFirestoreクライアントの接続設定
+type Config struct {
+	ProjectID string
+	// EmulatorHost が設定されている場合は認証なしでエミュレータに接続する
+	EmulatorHost string
+}
+
+// ConfigFromEnv は環境変数（FIRESTORE_PROJECT_ID, FIRESTORE_EMULATOR_HOST）から接続設定を作成する
+func ConfigFromEnv() Config {
 	projectID := os.Getenv("FIRESTORE_PROJECT_ID")
 	if projectID == "" {
 		projectID = "kareru-local"
 	}
 
+	return Config{
+		ProjectID:    projectID,
+		EmulatorHost: os.Getenv("FIRESTORE_EMULATOR_HOST"),
+	}
+}
+
+func NewClient(ctx context.Context) (*Client, error) {
+	return NewClientFromConfig(ctx, ConfigFromEnv())
+}
+
+func NewClientFromConfig(ctx context.Context, cfg Config) (*Client, error) {
 	var opts []option.ClientOption
 
-	if os.Getenv("FIRESTORE_EMULATOR_HOST") != "" {
+	if cfg.EmulatorHost != "" {
+		// Firestore SDKはエミュレータの接続先を環境変数から読み込む
+		if err := os.Setenv("FIRESTORE_EMULATOR_HOST", cfg.EmulatorHost); err != nil {
+			return nil, fmt.Errorf("failed to configure firestore emulator: %w", err)
+		}
 		opts = append(opts, option.WithoutAuthentication())
 	}
 
 	conf := &firebase.Config{
-		ProjectID: projectID,
+		ProjectID: cfg.ProjectID,
 	}
 
 	app, err := firebase.NewApp(ctx, conf, opts...)
@@ -43,7 +66,7 @@ func NewClient(ctx context.Context) (*Client, error) {
 
 	return &Client{
 		Client:    client,
-		projectID: projectID,
+		projectID: cfg.ProjectID,
 	}, nil
 }
 
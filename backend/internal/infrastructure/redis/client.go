@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+type Client struct {
+	goredis.UniversalClient
+	addr string
+}
+
+// Config はRedisクライアントの接続設定
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// ConfigFromEnv は環境変数（REDIS_ADDR, REDIS_PASSWORD, REDIS_DB）から接続設定を作成する
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = "localhost:6379"
+	}
+
+	if v := os.Getenv("REDIS_DB"); v != "" {
+		db, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_DB: %w", err)
+		}
+		cfg.DB = db
+	}
+
+	return cfg, nil
+}
+
+func NewClient(ctx context.Context) (*Client, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientFromConfig(ctx, cfg)
+}
+
+func NewClientFromConfig(ctx context.Context, cfg Config) (*Client, error) {
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+
+	return &Client{
+		UniversalClient: client,
+		addr:            cfg.Addr,
+	}, nil
+}
+
+func (c *Client) Addr() string {
+	return c.addr
+}
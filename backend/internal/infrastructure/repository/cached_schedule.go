@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+)
+
+// CacheOptions はキャッシュの設定
+type CacheOptions struct {
+	// Capacity はキャッシュに保持する最大エントリ数
+	Capacity int
+	// TTL は取得できたスケジュールを保持する期間
+	TTL time.Duration
+	// NegativeTTL は存在しなかったことを保持する期間
+	NegativeTTL time.Duration
+}
+
+// DefaultCacheOptions はデフォルトのキャッシュ設定を返す
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		Capacity:    1000,
+		TTL:         30 * time.Second,
+		NegativeTTL: 5 * time.Second,
+	}
+}
+
+// CacheStats はキャッシュのヒット・ミス数
+type CacheStats struct {
+	Hits         uint64
+	NegativeHits uint64
+	Misses       uint64
+	Evictions    uint64
+}
+
+type cacheEntry struct {
+	key       string
+	schedule  *model.Schedule // nilの場合は存在しないことをキャッシュしている
+	expiresAt time.Time
+}
+
+// CachedScheduleRepository は任意のScheduleRepositoryをラップする読み取りキャッシュ
+// TTL付きのLRUでスケジュールを保持し、同一キーへの同時ミスはsingleflightで1回の取得にまとめる
+type CachedScheduleRepository struct {
+	repo handlers.ScheduleRepository
+	opts CacheOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// generation は無効化のたびに増加する
+	generation uint64
+	// invalidated はキーとスケジュールIDごとに最後に無効化したときのgenerationを保持し、
+	// 無効化前に開始した取得結果の書き戻しを防ぐ。進行中の取得がなくなったら空にする
+	invalidated map[string]uint64
+	// loading は進行中の取得の数
+	loading int
+
+	group singleflight.Group
+
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+	evictions    atomic.Uint64
+}
+
+var (
+	_ handlers.ScheduleRepository = (*CachedScheduleRepository)(nil)
+	_ handlers.ContextBinder      = (*CachedScheduleRepository)(nil)
+)
+
+// NewCachedScheduleRepository はキャッシュ付きのリポジトリを作成する
+func NewCachedScheduleRepository(repo handlers.ScheduleRepository, opts CacheOptions) *CachedScheduleRepository {
+	defaults := DefaultCacheOptions()
+	if opts.Capacity <= 0 {
+		opts.Capacity = defaults.Capacity
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaults.TTL
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = defaults.NegativeTTL
+	}
+
+	return &CachedScheduleRepository{
+		repo:        repo,
+		opts:        opts,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+		invalidated: make(map[string]uint64),
+	}
+}
+
+func idKey(id string) string {
+	return "id:" + id
+}
+
+func tokenKey(token string) string {
+	return "token:" + token
+}
+
+func shortCodeKey(code string) string {
+	return "code:" + code
+}
+
+// scheduleKey はスケジュールIDごとの無効化を記録するキー（キャッシュのエントリには使わない）
+func scheduleKey(id string) string {
+	return "schedule:" + id
+}
+
+func (r *CachedScheduleRepository) Create(schedule *model.Schedule) error {
+	return r.create(context.Background(), schedule)
+}
+
+func (r *CachedScheduleRepository) GetByID(id string) (*model.Schedule, error) {
+	return r.getByID(context.Background(), id)
+}
+
+func (r *CachedScheduleRepository) GetByEditToken(token string) (*model.Schedule, error) {
+	return r.getByEditToken(context.Background(), token)
+}
+
+// GetByShortCode は短縮コードで取得する
+func (r *CachedScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	return r.getByShortCode(context.Background(), code)
+}
+
+func (r *CachedScheduleRepository) Update(schedule *model.Schedule) error {
+	return r.update(context.Background(), schedule)
+}
+
+func (r *CachedScheduleRepository) Delete(id string) error {
+	return r.delete(context.Background(), id)
+}
+
+// WithContext は下位のリポジトリにctxを引き継ぐリポジトリを返す（キャッシュは共有する）
+func (r *CachedScheduleRepository) WithContext(ctx context.Context) handlers.ScheduleRepository {
+	return &boundCachedScheduleRepository{cache: r, ctx: ctx}
+}
+
+// inner はctxを引き継いだ下位のリポジトリを返す
+func (r *CachedScheduleRepository) inner(ctx context.Context) handlers.ScheduleRepository {
+	if binder, ok := r.repo.(handlers.ContextBinder); ok {
+		return binder.WithContext(ctx)
+	}
+	return r.repo
+}
+
+func (r *CachedScheduleRepository) create(ctx context.Context, schedule *model.Schedule) error {
+	if err := r.inner(ctx).Create(schedule); err != nil {
+		return err
+	}
+	// 作成前に存在しないことがキャッシュされている可能性があるため無効化する
+	r.invalidate(schedule.ID, schedule.EditToken, schedule.ShortCode)
+	return nil
+}
+
+func (r *CachedScheduleRepository) getByID(ctx context.Context, id string) (*model.Schedule, error) {
+	return r.get(idKey(id), func() (*model.Schedule, error) {
+		return r.inner(ctx).GetByID(id)
+	})
+}
+
+func (r *CachedScheduleRepository) getByEditToken(ctx context.Context, token string) (*model.Schedule, error) {
+	return r.get(tokenKey(token), func() (*model.Schedule, error) {
+		return r.inner(ctx).GetByEditToken(token)
+	})
+}
+
+func (r *CachedScheduleRepository) getByShortCode(ctx context.Context, code string) (*model.Schedule, error) {
+	return r.get(shortCodeKey(code), func() (*model.Schedule, error) {
+		return r.inner(ctx).GetByShortCode(code)
+	})
+}
+
+func (r *CachedScheduleRepository) update(ctx context.Context, schedule *model.Schedule) error {
+	err := r.inner(ctx).Update(schedule)
+	// 失敗した場合も下位リポジトリの状態が不明なため無効化する
+	r.invalidate(schedule.ID, schedule.EditToken, schedule.ShortCode)
+	return err
+}
+
+func (r *CachedScheduleRepository) delete(ctx context.Context, id string) error {
+	err := r.inner(ctx).Delete(id)
+	r.invalidate(id, "", "")
+	return err
+}
+
+// boundCachedScheduleRepository はリクエストのコンテキストを引き継いでキャッシュを操作する
+// 読み取りはsingleflightで他のリクエストと共有するため、キャンセルは引き継がずトレースなどの値だけを引き継ぐ
+type boundCachedScheduleRepository struct {
+	cache *CachedScheduleRepository
+	ctx   context.Context
+}
+
+func (b *boundCachedScheduleRepository) Create(schedule *model.Schedule) error {
+	return b.cache.create(b.ctx, schedule)
+}
+
+func (b *boundCachedScheduleRepository) GetByID(id string) (*model.Schedule, error) {
+	return b.cache.getByID(context.WithoutCancel(b.ctx), id)
+}
+
+func (b *boundCachedScheduleRepository) GetByEditToken(token string) (*model.Schedule, error) {
+	return b.cache.getByEditToken(context.WithoutCancel(b.ctx), token)
+}
+
+func (b *boundCachedScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	return b.cache.getByShortCode(context.WithoutCancel(b.ctx), code)
+}
+
+func (b *boundCachedScheduleRepository) Update(schedule *model.Schedule) error {
+	return b.cache.update(b.ctx, schedule)
+}
+
+func (b *boundCachedScheduleRepository) Delete(id string) error {
+	return b.cache.delete(b.ctx, id)
+}
+
+// Stats は現在のヒット・ミス数を返す
+func (r *CachedScheduleRepository) Stats() CacheStats {
+	return CacheStats{
+		Hits:         r.hits.Load(),
+		NegativeHits: r.negativeHits.Load(),
+		Misses:       r.misses.Load(),
+		Evictions:    r.evictions.Load(),
+	}
+}
+
+func (r *CachedScheduleRepository) get(key string, load func() (*model.Schedule, error)) (*model.Schedule, error) {
+	if schedule, found := r.lookup(key); found {
+		if schedule == nil {
+			r.negativeHits.Add(1)
+			return nil, model.ErrScheduleNotFound
+		}
+		r.hits.Add(1)
+		return schedule, nil
+	}
+
+	r.misses.Add(1)
+	v, err, _ := r.group.Do(key, func() (interface{}, error) {
+		generation := r.beginLoad()
+		defer r.endLoad()
+		schedule, err := load()
+		if err != nil {
+			if errors.Is(err, model.ErrScheduleNotFound) {
+				r.store(key, nil, generation)
+			}
+			return nil, err
+		}
+		r.store(key, schedule, generation)
+		return schedule, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, _ := v.(*model.Schedule)
+	if schedule == nil {
+		// 下位リポジトリがnil, nilを返した場合も存在しないものとして扱う
+		return nil, nil
+	}
+	// singleflightで結果を共有した呼び出し元同士が同じ値を変更しないようにコピーを返す
+	return schedule.Clone(), nil
+}
+
+// lookup は有効なエントリを探す。見つかった場合はスケジュールのコピーを返す
+func (r *CachedScheduleRepository) lookup(key string) (*model.Schedule, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, exists := r.entries[key]
+	if !exists {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !r.now().Before(entry.expiresAt) {
+		r.removeElement(elem)
+		return nil, false
+	}
+
+	r.lru.MoveToFront(elem)
+	return entry.schedule.Clone(), true
+}
+
+// beginLoad は取得の開始を記録し、開始時点のgenerationを返す
+func (r *CachedScheduleRepository) beginLoad() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loading++
+	return r.generation
+}
+
+// endLoad は取得の終了を記録する
+func (r *CachedScheduleRepository) endLoad() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loading--
+	if r.loading == 0 {
+		clear(r.invalidated)
+	}
+}
+
+// staleLocked はgeneration以降にキーか取得したスケジュールが無効化されたかを返す
+func (r *CachedScheduleRepository) staleLocked(key string, schedule *model.Schedule, generation uint64) bool {
+	if r.invalidated[key] > generation {
+		return true
+	}
+	return schedule != nil && r.invalidated[scheduleKey(schedule.ID)] > generation
+}
+
+func (r *CachedScheduleRepository) store(key string, schedule *model.Schedule, generation uint64) {
+	ttl := r.opts.TTL
+	if schedule == nil {
+		ttl = r.opts.NegativeTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.staleLocked(key, schedule, generation) {
+		return
+	}
+
+	entry := &cacheEntry{
+		key:       key,
+		schedule:  schedule.Clone(),
+		expiresAt: r.now().Add(ttl),
+	}
+
+	if elem, exists := r.entries[key]; exists {
+		elem.Value = entry
+		r.lru.MoveToFront(elem)
+	} else {
+		r.entries[key] = r.lru.PushFront(entry)
+	}
+
+	for r.lru.Len() > r.opts.Capacity {
+		r.removeElement(r.lru.Back())
+		r.evictions.Add(1)
+	}
+}
+
+// invalidate はスケジュールに対応するIDキー・編集トークンキー・短縮コードキーのエントリを削除する
+// tokenやcodeが空の場合でも、キャッシュ済みのスケジュールIDが一致するエントリは削除される
+func (r *CachedScheduleRepository) invalidate(id, token, code string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	if r.loading > 0 {
+		// 編集トークンや短縮コードで取得中のスケジュールも、取得後にIDで無効化を判定できるようにする
+		r.invalidated[idKey(id)] = r.generation
+		r.invalidated[scheduleKey(id)] = r.generation
+		if token != "" {
+			r.invalidated[tokenKey(token)] = r.generation
+		}
+		if code != "" {
+			r.invalidated[shortCodeKey(code)] = r.generation
+		}
+	}
+
+	for elem := r.lru.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*cacheEntry)
+		if entry.key == idKey(id) ||
+			(token != "" && entry.key == tokenKey(token)) ||
+			(code != "" && entry.key == shortCodeKey(code)) ||
+			(entry.schedule != nil && entry.schedule.ID == id) {
+			r.removeElement(elem)
+		}
+		elem = next
+	}
+
+	// 無効化後の取得が進行中の古い取得に合流しないようにする
+	r.group.Forget(idKey(id))
+	if token != "" {
+		r.group.Forget(tokenKey(token))
+	}
+	if code != "" {
+		r.group.Forget(shortCodeKey(code))
+	}
+}
+
+func (r *CachedScheduleRepository) removeElement(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	r.lru.Remove(elem)
+	delete(r.entries, entry.key)
+}
+
+// Ping はキャッシュを経由せず、下位のリポジトリの疎通を確認する
+func (r *CachedScheduleRepository) Ping(ctx context.Context) error {
+	if pinger, ok := r.repo.(handlers.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
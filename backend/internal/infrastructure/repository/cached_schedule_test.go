@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+)
+
+// countingScheduleRepository は下位リポジトリへの呼び出し回数を数えるテスト用リポジトリ
+type countingScheduleRepository struct {
+	*MemoryScheduleRepository
+	getByIDCalls    atomic.Int32
+	getByTokenCalls atomic.Int32
+	// release が設定されている場合、GetByIDはチャネルが閉じられるまで待機する
+	release chan struct{}
+}
+
+func newCountingScheduleRepository() *countingScheduleRepository {
+	return &countingScheduleRepository{MemoryScheduleRepository: NewMemoryScheduleRepository()}
+}
+
+func (r *countingScheduleRepository) GetByID(id string) (*model.Schedule, error) {
+	r.getByIDCalls.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return r.MemoryScheduleRepository.GetByID(id)
+}
+
+func (r *countingScheduleRepository) GetByEditToken(token string) (*model.Schedule, error) {
+	r.getByTokenCalls.Add(1)
+	return r.MemoryScheduleRepository.GetByEditToken(token)
+}
+
+// contextKey はテストでコンテキストの引き継ぎを確認するためのキー
+type contextKey struct{}
+
+// contextRecordingRepository はWithContextで受け取ったコンテキストを記録するテスト用リポジトリ
+type contextRecordingRepository struct {
+	*MemoryScheduleRepository
+	mu   sync.Mutex
+	seen []context.Context
+}
+
+func (r *contextRecordingRepository) WithContext(ctx context.Context) handlers.ScheduleRepository {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = append(r.seen, ctx)
+	return r.MemoryScheduleRepository
+}
+
+func (r *contextRecordingRepository) last() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seen[len(r.seen)-1]
+}
+
+// fakeClock はテスト用に時刻を進められる時計
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestCache(t *testing.T, opts CacheOptions) (*CachedScheduleRepository, *countingScheduleRepository, *fakeClock) {
+	t.Helper()
+	inner := newCountingScheduleRepository()
+	clock := &fakeClock{now: time.Now()}
+	cache := NewCachedScheduleRepository(inner, opts)
+	cache.now = clock.Now
+	return cache, inner, clock
+}
+
+func TestCachedScheduleRepository_ReadThrough(t *testing.T) {
+	t.Run("2回目以降の取得はキャッシュから返される", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("cache-1", "token-1")))
+
+		for i := 0; i < 3; i++ {
+			schedule, err := cache.GetByID("cache-1")
+			require.NoError(t, err)
+			assert.Equal(t, "メモリテスト", schedule.Comment)
+		}
+
+		assert.Equal(t, int32(1), inner.getByIDCalls.Load())
+		stats := cache.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(1), stats.Misses)
+	})
+
+	t.Run("キャッシュから返された値を変更しても他の呼び出し元に影響しない", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("cache-2", "token-2")))
+
+		first, err := cache.GetByID("cache-2")
+		require.NoError(t, err)
+		first.Comment = "変更"
+		first.TimeSlots = nil
+
+		second, err := cache.GetByID("cache-2")
+		require.NoError(t, err)
+		assert.Equal(t, "メモリテスト", second.Comment)
+		assert.Len(t, second.TimeSlots, 1)
+	})
+
+	t.Run("TTLを過ぎると下位リポジトリから再取得する", func(t *testing.T) {
+		cache, inner, clock := newTestCache(t, CacheOptions{TTL: time.Minute})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("cache-3", "token-3")))
+
+		_, err := cache.GetByID("cache-3")
+		require.NoError(t, err)
+		clock.Advance(2 * time.Minute)
+		_, err = cache.GetByID("cache-3")
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(2), inner.getByIDCalls.Load())
+	})
+
+	t.Run("容量を超えると最も古く使われたエントリが追い出される", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheOptions{Capacity: 2})
+		for _, id := range []string{"lru-1", "lru-2", "lru-3"} {
+			require.NoError(t, cache.Create(newMemoryTestSchedule(id, "token-"+id)))
+		}
+
+		_, _ = cache.GetByID("lru-1")
+		_, _ = cache.GetByID("lru-2")
+		_, _ = cache.GetByID("lru-1") // lru-1を最近使用したことにする
+		_, _ = cache.GetByID("lru-3") // lru-2が追い出される
+
+		assert.Equal(t, uint64(1), cache.Stats().Evictions)
+
+		calls := inner.getByIDCalls.Load()
+		_, _ = cache.GetByID("lru-1")
+		assert.Equal(t, calls, inner.getByIDCalls.Load())
+		_, _ = cache.GetByID("lru-2")
+		assert.Equal(t, calls+1, inner.getByIDCalls.Load())
+	})
+}
+
+func TestCachedScheduleRepository_NegativeCache(t *testing.T) {
+	t.Run("存在しないスケジュールは短いTTLでキャッシュされる", func(t *testing.T) {
+		cache, inner, clock := newTestCache(t, CacheOptions{TTL: time.Minute, NegativeTTL: time.Second})
+
+		_, err := cache.GetByID("missing")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		_, err = cache.GetByID("missing")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+
+		assert.Equal(t, int32(1), inner.getByIDCalls.Load())
+		assert.Equal(t, uint64(1), cache.Stats().NegativeHits)
+
+		clock.Advance(2 * time.Second)
+		_, err = cache.GetByID("missing")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		assert.Equal(t, int32(2), inner.getByIDCalls.Load())
+	})
+
+	t.Run("作成すると存在しないことのキャッシュが無効化される", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, CacheOptions{})
+
+		_, err := cache.GetByID("created-later")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+
+		require.NoError(t, cache.Create(newMemoryTestSchedule("created-later", "token-later")))
+		schedule, err := cache.GetByID("created-later")
+		require.NoError(t, err)
+		assert.Equal(t, "created-later", schedule.ID)
+	})
+}
+
+func TestCachedScheduleRepository_ShortCode(t *testing.T) {
+	t.Run("短縮コードで取得でき、作成すると存在しないことのキャッシュが無効化される", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, CacheOptions{})
+
+		_, err := cache.GetByShortCode("7K3M9QX2")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+
+		schedule := newMemoryTestSchedule("coded", "token-coded")
+		schedule.ShortCode = "7K3M9QX2"
+		require.NoError(t, cache.Create(schedule))
+
+		found, err := cache.GetByShortCode("7K3M9QX2")
+		require.NoError(t, err)
+		assert.Equal(t, "coded", found.ID)
+	})
+
+	t.Run("削除すると短縮コードのキャッシュも無効化される", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, CacheOptions{})
+		schedule := newMemoryTestSchedule("coded-delete", "token-coded-delete")
+		schedule.ShortCode = "AB12CD34"
+		require.NoError(t, cache.Create(schedule))
+
+		_, err := cache.GetByShortCode("AB12CD34")
+		require.NoError(t, err)
+
+		require.NoError(t, cache.Delete("coded-delete"))
+		_, err = cache.GetByShortCode("AB12CD34")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+}
+
+func TestCachedScheduleRepository_Invalidation(t *testing.T) {
+	t.Run("更新するとIDと編集トークンのキャッシュが無効化される", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("inv-1", "inv-token-1")))
+
+		schedule, err := cache.GetByID("inv-1")
+		require.NoError(t, err)
+		_, err = cache.GetByEditToken("inv-token-1")
+		require.NoError(t, err)
+
+		schedule.Comment = "更新後"
+		require.NoError(t, cache.Update(schedule))
+
+		byID, err := cache.GetByID("inv-1")
+		require.NoError(t, err)
+		assert.Equal(t, "更新後", byID.Comment)
+
+		byToken, err := cache.GetByEditToken("inv-token-1")
+		require.NoError(t, err)
+		assert.Equal(t, "更新後", byToken.Comment)
+	})
+
+	t.Run("削除すると編集トークンのキャッシュも無効化される", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("inv-2", "inv-token-2")))
+
+		_, err := cache.GetByEditToken("inv-token-2")
+		require.NoError(t, err)
+
+		require.NoError(t, cache.Delete("inv-2"))
+
+		_, err = cache.GetByID("inv-2")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		_, err = cache.GetByEditToken("inv-token-2")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+
+	t.Run("別のスケジュールを無効化しても取得中の結果はキャッシュされる", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("inv-3", "inv-token-3")))
+		require.NoError(t, cache.Create(newMemoryTestSchedule("inv-4", "inv-token-4")))
+		inner.release = make(chan struct{})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := cache.GetByID("inv-3")
+			assert.NoError(t, err)
+		}()
+		require.Eventually(t, func() bool {
+			return inner.getByIDCalls.Load() == 1
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, cache.Delete("inv-4"))
+		close(inner.release)
+		<-done
+
+		_, err := cache.GetByID("inv-3")
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), inner.getByIDCalls.Load())
+	})
+
+	t.Run("取得中に同じスケジュールを無効化した場合は結果をキャッシュしない", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("inv-5", "inv-token-5")))
+		inner.release = make(chan struct{})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := cache.GetByID("inv-5")
+			assert.NoError(t, err)
+		}()
+		require.Eventually(t, func() bool {
+			return inner.getByIDCalls.Load() == 1
+		}, time.Second, time.Millisecond)
+
+		schedule := newMemoryTestSchedule("inv-5", "inv-token-5")
+		schedule.Comment = "更新後"
+		require.NoError(t, inner.MemoryScheduleRepository.Update(schedule))
+		cache.invalidate("inv-5", "", "")
+		close(inner.release)
+		<-done
+
+		inner.release = nil
+		byID, err := cache.GetByID("inv-5")
+		require.NoError(t, err)
+		assert.Equal(t, "更新後", byID.Comment)
+		assert.Equal(t, int32(2), inner.getByIDCalls.Load())
+	})
+}
+
+func TestCachedScheduleRepository_WithContext(t *testing.T) {
+	t.Run("下位のリポジトリにコンテキストの値を引き継ぐ", func(t *testing.T) {
+		inner := &contextRecordingRepository{MemoryScheduleRepository: NewMemoryScheduleRepository()}
+		cache := NewCachedScheduleRepository(inner, CacheOptions{})
+		ctx := context.WithValue(context.Background(), contextKey{}, "request-1")
+		bound := cache.WithContext(ctx)
+
+		require.NoError(t, bound.Create(newMemoryTestSchedule("ctx-1", "ctx-token-1")))
+		assert.Equal(t, "request-1", inner.last().Value(contextKey{}))
+
+		_, err := bound.GetByID("ctx-1")
+		require.NoError(t, err)
+		assert.Equal(t, "request-1", inner.last().Value(contextKey{}))
+	})
+
+	t.Run("読み取りは呼び出し元のキャンセルを引き継がない", func(t *testing.T) {
+		inner := &contextRecordingRepository{MemoryScheduleRepository: NewMemoryScheduleRepository()}
+		cache := NewCachedScheduleRepository(inner, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("ctx-2", "ctx-token-2")))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := cache.WithContext(ctx).GetByID("ctx-2")
+		require.NoError(t, err)
+		assert.NoError(t, inner.last().Err())
+	})
+}
+
+func TestCachedScheduleRepository_Singleflight(t *testing.T) {
+	t.Run("同じキーへの同時ミスは1回の取得にまとめられる", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheOptions{})
+		require.NoError(t, cache.Create(newMemoryTestSchedule("sf-1", "sf-token-1")))
+		inner.release = make(chan struct{})
+
+		const callers = 10
+		var started, done sync.WaitGroup
+		started.Add(callers)
+		done.Add(callers)
+		for i := 0; i < callers; i++ {
+			go func() {
+				defer done.Done()
+				started.Done()
+				schedule, err := cache.GetByID("sf-1")
+				if assert.NoError(t, err) {
+					assert.Equal(t, "sf-1", schedule.ID)
+				}
+			}()
+		}
+
+		started.Wait()
+		// 全ての呼び出し元がsingleflightに合流するまで少し待つ
+		assert.Eventually(t, func() bool {
+			return inner.getByIDCalls.Load() == 1
+		}, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(inner.release)
+		done.Wait()
+
+		assert.Equal(t, int32(1), inner.getByIDCalls.Load())
+	})
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+)
+
+// FirestoreScheduleAdapter はコンテキストを受け取るFirestoreのScheduleRepositoryを
+// handlers.ScheduleRepositoryとして使うためのアダプタ
+// 各操作はWithContextで渡されたリクエストのコンテキストを引き継ぎ、timeoutで打ち切られる
+type FirestoreScheduleAdapter struct {
+	repo    *ScheduleRepository
+	timeout time.Duration
+	ctx     context.Context
+}
+
+var (
+	_ handlers.ScheduleRepository = (*FirestoreScheduleAdapter)(nil)
+	_ handlers.ContextBinder      = (*FirestoreScheduleAdapter)(nil)
+)
+
+func NewFirestoreScheduleAdapter(repo *ScheduleRepository, timeout time.Duration) *FirestoreScheduleAdapter {
+	return &FirestoreScheduleAdapter{
+		repo:    repo,
+		timeout: timeout,
+		ctx:     context.Background(),
+	}
+}
+
+// WithContext はctxを親として操作するアダプタを返す
+// リクエストが中断された場合はFirestoreへの呼び出しも中断される
+func (a *FirestoreScheduleAdapter) WithContext(ctx context.Context) handlers.ScheduleRepository {
+	bound := *a
+	bound.ctx = ctx
+	return &bound
+}
+
+func (a *FirestoreScheduleAdapter) context() (context.Context, context.CancelFunc) {
+	return a.contextFrom(a.ctx)
+}
+
+// contextFrom はparentを親としてtimeoutで打ち切られるコンテキストを返す
+func (a *FirestoreScheduleAdapter) contextFrom(parent context.Context) (context.Context, context.CancelFunc) {
+	if a.timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, a.timeout)
+}
+
+func (a *FirestoreScheduleAdapter) Create(schedule *model.Schedule) error {
+	ctx, cancel := a.context()
+	defer cancel()
+	return a.repo.Create(ctx, schedule)
+}
+
+func (a *FirestoreScheduleAdapter) GetByID(id string) (*model.Schedule, error) {
+	ctx, cancel := a.context()
+	defer cancel()
+	return a.repo.GetByID(ctx, id)
+}
+
+func (a *FirestoreScheduleAdapter) GetByEditToken(token string) (*model.Schedule, error) {
+	ctx, cancel := a.context()
+	defer cancel()
+	return a.repo.GetByEditToken(ctx, token)
+}
+
+func (a *FirestoreScheduleAdapter) GetByShortCode(code string) (*model.Schedule, error) {
+	ctx, cancel := a.context()
+	defer cancel()
+	return a.repo.GetByShortCode(ctx, code)
+}
+
+func (a *FirestoreScheduleAdapter) Update(schedule *model.Schedule) error {
+	ctx, cancel := a.context()
+	defer cancel()
+	return a.repo.Update(ctx, schedule)
+}
+
+func (a *FirestoreScheduleAdapter) Delete(id string) error {
+	ctx, cancel := a.context()
+	defer cancel()
+	return a.repo.Delete(ctx, id)
+}
+
+func (a *FirestoreScheduleAdapter) List() ([]*model.Schedule, error) {
+	ctx, cancel := a.context()
+	defer cancel()
+	return a.repo.List(ctx)
+}
+
+func (a *FirestoreScheduleAdapter) ListExpiringBetween(ctx context.Context, from, to time.Time) ([]*model.Schedule, error) {
+	ctx, cancel := a.contextFrom(ctx)
+	defer cancel()
+	return a.repo.ListExpiringBetween(ctx, from, to)
+}
+
+func (a *FirestoreScheduleAdapter) ClaimReminder(ctx context.Context, schedule *model.Schedule) (bool, error) {
+	ctx, cancel := a.contextFrom(ctx)
+	defer cancel()
+	return a.repo.ClaimReminder(ctx, schedule)
+}
+
+func (a *FirestoreScheduleAdapter) Ping(ctx context.Context) error {
+	return a.repo.Ping(ctx)
+}
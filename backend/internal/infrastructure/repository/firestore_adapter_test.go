@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirestoreScheduleAdapter_Context(t *testing.T) {
+	t.Run("WithContextで渡したコンテキストを親にしてタイムアウトを設定する", func(t *testing.T) {
+		adapter := NewFirestoreScheduleAdapter(nil, time.Minute)
+		parent := context.WithValue(context.Background(), contextKey{}, "request-1")
+
+		bound := adapter.WithContext(parent).(*FirestoreScheduleAdapter)
+		ctx, cancel := bound.context()
+		defer cancel()
+
+		assert.Equal(t, "request-1", ctx.Value(contextKey{}))
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("リクエストが中断されると操作のコンテキストも中断される", func(t *testing.T) {
+		adapter := NewFirestoreScheduleAdapter(nil, 0)
+		parent, cancelParent := context.WithCancel(context.Background())
+
+		ctx, cancel := adapter.WithContext(parent).(*FirestoreScheduleAdapter).context()
+		defer cancel()
+		cancelParent()
+
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+
+	t.Run("WithContextを呼ばない場合は元のアダプタに影響しない", func(t *testing.T) {
+		adapter := NewFirestoreScheduleAdapter(nil, 0)
+		parent, cancelParent := context.WithCancel(context.Background())
+		cancelParent()
+		adapter.WithContext(parent)
+
+		ctx, cancel := adapter.context()
+		defer cancel()
+		assert.NoError(t, ctx.Err())
+	})
+}
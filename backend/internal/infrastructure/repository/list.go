@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"sort"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// sortSchedules は一覧の順序をバックエンドによらず揃えるため、作成日時・ID順に並べ替える
+func sortSchedules(schedules []*model.Schedule) {
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+}
@@ -1,28 +1,43 @@
 package repository
 
 import (
-	"fmt"
+	"context"
 	"sync"
+	"time"
 
 	"kareru-backend/internal/domain/model"
 )
 
+// MemoryScheduleRepository はメモリ上にスケジュールを保持するリポジトリ
+// 保存・取得時に必ずディープコピーを行い、呼び出し側がロック外で
+// 返却値を変更しても保存済みの状態に影響しないようにする
 type MemoryScheduleRepository struct {
 	mu        sync.RWMutex
 	schedules map[string]*model.Schedule
+	// reminded はリマインダーを送信したスケジュールのIDと、送信したときのExpiresAt
+	reminded map[string]time.Time
 }
 
 func NewMemoryScheduleRepository() *MemoryScheduleRepository {
 	return &MemoryScheduleRepository{
 		schedules: make(map[string]*model.Schedule),
+		reminded:  make(map[string]time.Time),
 	}
 }
 
 func (r *MemoryScheduleRepository) Create(schedule *model.Schedule) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	
-	r.schedules[schedule.ID] = schedule
+
+	if schedule.ShortCode != "" {
+		for _, existing := range r.schedules {
+			if existing.ShortCode == schedule.ShortCode && existing.ID != schedule.ID {
+				return model.ErrShortCodeTaken
+			}
+		}
+	}
+
+	r.schedules[schedule.ID] = schedule.Clone()
 	return nil
 }
 
@@ -32,9 +47,9 @@ func (r *MemoryScheduleRepository) GetByID(id string) (*model.Schedule, error) {
 	
 	schedule, exists := r.schedules[id]
 	if !exists {
-		return nil, fmt.Errorf("schedule not found")
+		return nil, model.ErrScheduleNotFound
 	}
-	return schedule, nil
+	return schedule.Clone(), nil
 }
 
 func (r *MemoryScheduleRepository) Update(schedule *model.Schedule) error {
@@ -42,10 +57,10 @@ func (r *MemoryScheduleRepository) Update(schedule *model.Schedule) error {
 	defer r.mu.Unlock()
 	
 	if _, exists := r.schedules[schedule.ID]; !exists {
-		return fmt.Errorf("schedule not found")
+		return model.ErrScheduleNotFound
 	}
 	
-	r.schedules[schedule.ID] = schedule
+	r.schedules[schedule.ID] = schedule.Clone()
 	return nil
 }
 
@@ -54,10 +69,11 @@ func (r *MemoryScheduleRepository) Delete(id string) error {
 	defer r.mu.Unlock()
 	
 	if _, exists := r.schedules[id]; !exists {
-		return fmt.Errorf("schedule not found")
+		return model.ErrScheduleNotFound
 	}
 	
 	delete(r.schedules, id)
+	delete(r.reminded, id)
 	return nil
 }
 
@@ -67,8 +83,73 @@ func (r *MemoryScheduleRepository) GetByEditToken(token string) (*model.Schedule
 	
 	for _, schedule := range r.schedules {
 		if schedule.EditToken == token {
-			return schedule, nil
+			return schedule.Clone(), nil
 		}
 	}
-	return nil, fmt.Errorf("schedule not found")
-}
\ No newline at end of file
+	return nil, model.ErrScheduleNotFound
+}
+
+// GetByShortCode は短縮コードでスケジュールを取得する
+func (r *MemoryScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if code == "" {
+		return nil, model.ErrScheduleNotFound
+	}
+	for _, schedule := range r.schedules {
+		if schedule.ShortCode == code {
+			return schedule.Clone(), nil
+		}
+	}
+	return nil, model.ErrScheduleNotFound
+}
+
+// List は保存されている全てのスケジュールのコピーを作成日時順で返す
+func (r *MemoryScheduleRepository) List() ([]*model.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schedules := make([]*model.Schedule, 0, len(r.schedules))
+	for _, schedule := range r.schedules {
+		schedules = append(schedules, schedule.Clone())
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+// ListExpiringBetween はExpiresAtがfromより後でto以前のスケジュールのコピーを作成日時順で返す
+func (r *MemoryScheduleRepository) ListExpiringBetween(ctx context.Context, from, to time.Time) ([]*model.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var schedules []*model.Schedule
+	for _, schedule := range r.schedules {
+		if schedule.ExpiresAt.After(from) && !schedule.ExpiresAt.After(to) {
+			schedules = append(schedules, schedule.Clone())
+		}
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+// ClaimReminder はスケジュールの現在のExpiresAtに対するリマインダーの送信を記録する
+// 既に記録されている場合や、スケジュールが削除されている場合はfalseを返す
+func (r *MemoryScheduleRepository) ClaimReminder(ctx context.Context, schedule *model.Schedule) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.schedules[schedule.ID]; !exists {
+		return false, nil
+	}
+	if expiresAt, ok := r.reminded[schedule.ID]; ok && expiresAt.Equal(schedule.ExpiresAt) {
+		return false, nil
+	}
+	r.reminded[schedule.ID] = schedule.ExpiresAt
+	return true, nil
+}
+
+// Ping はメモリ上のリポジトリのため常に成功する
+func (r *MemoryScheduleRepository) Ping(ctx context.Context) error {
+	return nil
+}
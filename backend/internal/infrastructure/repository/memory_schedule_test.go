@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+func newMemoryTestSchedule(id, token string) *model.Schedule {
+	now := time.Now()
+	return &model.Schedule{
+		ID:        id,
+		EditToken: token,
+		TimeSlots: []model.TimeSlot{
+			{
+				StartTime: now.Add(1 * time.Hour),
+				EndTime:   now.Add(2 * time.Hour),
+				Available: true,
+			},
+		},
+		Comment:   "メモリテスト",
+		CreatedAt: now,
+		UpdatedAt: now.Add(time.Minute),
+		ExpiresAt: now.Add(7 * 24 * time.Hour),
+	}
+}
+
+func TestMemoryScheduleRepository_DefensiveCopy(t *testing.T) {
+	t.Run("作成後に元のスケジュールを変更しても保存内容は変わらない", func(t *testing.T) {
+		repo := NewMemoryScheduleRepository()
+		schedule := newMemoryTestSchedule("memory-1", "token-1")
+		require.NoError(t, repo.Create(schedule))
+
+		schedule.Comment = "変更"
+		schedule.TimeSlots[0].Available = false
+
+		stored, err := repo.GetByID("memory-1")
+		require.NoError(t, err)
+		assert.Equal(t, "メモリテスト", stored.Comment)
+		assert.True(t, stored.TimeSlots[0].Available)
+	})
+
+	t.Run("取得したスケジュールを変更してもUpdateしなければ保存内容は変わらない", func(t *testing.T) {
+		repo := NewMemoryScheduleRepository()
+		require.NoError(t, repo.Create(newMemoryTestSchedule("memory-2", "token-2")))
+
+		fetched, err := repo.GetByID("memory-2")
+		require.NoError(t, err)
+		fetched.Comment = "未保存の変更"
+		fetched.TimeSlots = nil
+
+		byToken, err := repo.GetByEditToken("token-2")
+		require.NoError(t, err)
+		byToken.Comment = "未保存の変更"
+
+		stored, err := repo.GetByID("memory-2")
+		require.NoError(t, err)
+		assert.Equal(t, "メモリテスト", stored.Comment)
+		assert.Len(t, stored.TimeSlots, 1)
+	})
+
+	t.Run("Updateで保存した内容が反映される", func(t *testing.T) {
+		repo := NewMemoryScheduleRepository()
+		require.NoError(t, repo.Create(newMemoryTestSchedule("memory-3", "token-3")))
+
+		fetched, err := repo.GetByID("memory-3")
+		require.NoError(t, err)
+		fetched.Comment = "更新後"
+		require.NoError(t, repo.Update(fetched))
+
+		fetched.Comment = "Update後の変更"
+
+		stored, err := repo.GetByID("memory-3")
+		require.NoError(t, err)
+		assert.Equal(t, "更新後", stored.Comment)
+	})
+}
+
+func TestMemoryScheduleRepository_ShortCode(t *testing.T) {
+	t.Run("短縮コードで取得できる", func(t *testing.T) {
+		repo := NewMemoryScheduleRepository()
+		schedule := newMemoryTestSchedule("short-1", "short-token-1")
+		schedule.ShortCode = "7K3M9QX2"
+		require.NoError(t, repo.Create(schedule))
+
+		found, err := repo.GetByShortCode("7K3M9QX2")
+		require.NoError(t, err)
+		assert.Equal(t, "short-1", found.ID)
+
+		_, err = repo.GetByShortCode("AAAAAAAA")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		_, err = repo.GetByShortCode("")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+
+	t.Run("他のスケジュールと短縮コードが衝突するとErrShortCodeTakenを返す", func(t *testing.T) {
+		repo := NewMemoryScheduleRepository()
+		first := newMemoryTestSchedule("short-a", "short-token-a")
+		first.ShortCode = "7K3M9QX2"
+		require.NoError(t, repo.Create(first))
+
+		second := newMemoryTestSchedule("short-b", "short-token-b")
+		second.ShortCode = "7K3M9QX2"
+		assert.ErrorIs(t, repo.Create(second), model.ErrShortCodeTaken)
+
+		_, err := repo.GetByID("short-b")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+}
+
+func TestMemoryScheduleRepository_Reminder(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("失効日時が範囲内のスケジュールだけを取得できる", func(t *testing.T) {
+		repo := NewMemoryScheduleRepository()
+		for id, expiresIn := range map[string]time.Duration{
+			"expiring-soon":  12 * time.Hour,
+			"expiring-later": 48 * time.Hour,
+			"expired":        -time.Hour,
+		} {
+			schedule := newMemoryTestSchedule(id, "token-"+id)
+			schedule.ExpiresAt = now.Add(expiresIn)
+			require.NoError(t, repo.Create(schedule))
+		}
+
+		schedules, err := repo.ListExpiringBetween(ctx, now, now.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		assert.Equal(t, "expiring-soon", schedules[0].ID)
+	})
+
+	t.Run("リマインダーの送信は失効日時ごとに1回だけ記録できる", func(t *testing.T) {
+		repo := NewMemoryScheduleRepository()
+		schedule := newMemoryTestSchedule("memory-reminder", "token-reminder")
+		require.NoError(t, repo.Create(schedule))
+
+		claimed, err := repo.ClaimReminder(ctx, schedule)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		claimed, err = repo.ClaimReminder(ctx, schedule)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		schedule.ExpiresAt = schedule.ExpiresAt.Add(24 * time.Hour)
+		claimed, err = repo.ClaimReminder(ctx, schedule)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		require.NoError(t, repo.Delete(schedule.ID))
+		claimed, err = repo.ClaimReminder(ctx, schedule)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+	})
+}
+
+func TestMemoryScheduleRepository_Concurrency(t *testing.T) {
+	// go test -race で実行したときにデータ競合が検出されないこと
+	repo := NewMemoryScheduleRepository()
+	require.NoError(t, repo.Create(newMemoryTestSchedule("memory-concurrent", "token-concurrent")))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				schedule, err := repo.GetByID("memory-concurrent")
+				if assert.NoError(t, err) {
+					_ = schedule.Comment
+					_ = len(schedule.TimeSlots)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				schedule, err := repo.GetByEditToken("token-concurrent")
+				if !assert.NoError(t, err) {
+					return
+				}
+				schedule.Comment = "並行更新"
+				schedule.TimeSlots = append(schedule.TimeSlots, schedule.TimeSlots[0])
+				assert.NoError(t, repo.Update(schedule))
+			}
+		}()
+	}
+	wg.Wait()
+}
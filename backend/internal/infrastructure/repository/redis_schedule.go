@@ -0,0 +1,479 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+)
+
+const defaultRedisKeyPrefix = "kareru:"
+
+// RedisScheduleRepository はRedisプロトコル互換のストアにスケジュールを保存するリポジトリ
+// スケジュール本体と編集トークン・短縮コードの逆引きキーにExpiresAtまでのTTLを設定するため、
+// 失効したスケジュールはスイーパー無しでストアから消える
+// 失効日時で検索できるよう、IDをExpiresAtをスコアとするソート済みセットにも登録する
+// 各操作はWithContextで渡されたリクエストのコンテキストを引き継ぐ
+type RedisScheduleRepository struct {
+	client goredis.UniversalClient
+	prefix string
+	now    func() time.Time
+	ctx    context.Context
+}
+
+var (
+	_ handlers.ScheduleRepository = (*RedisScheduleRepository)(nil)
+	_ handlers.ContextBinder      = (*RedisScheduleRepository)(nil)
+)
+
+// redisSchedule はRedisに保存するスケジュールのJSON表現
+type redisSchedule struct {
+	ID        string          `json:"id"`
+	EditToken string          `json:"editToken"`
+	ShortCode string          `json:"shortCode,omitempty"`
+	TimeSlots []redisTimeSlot `json:"timeSlots"`
+	Comment   string          `json:"comment"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+	// UpdatedAt は最終更新日時（記録される前に保存されたスケジュールではゼロ値）
+	UpdatedAt time.Time `json:"updatedAt"`
+	// ViewPasswordHash は閲覧パスワードのハッシュ（保護されていない場合は省略）
+	ViewPasswordHash string `json:"viewPasswordHash,omitempty"`
+	// WebhookURLs と WebhookSecret は登録されたWebhookの通知先と署名鍵（登録されていない場合は省略）
+	WebhookURLs   []string `json:"webhookURLs,omitempty"`
+	WebhookSecret string   `json:"webhookSecret,omitempty"`
+	// NotifyEmail と NotifyLocale は通知メールの宛先と言語（指定されていない場合は省略）
+	NotifyEmail  string `json:"notifyEmail,omitempty"`
+	NotifyLocale string `json:"notifyLocale,omitempty"`
+}
+
+type redisTimeSlot struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Available bool      `json:"available"`
+}
+
+func NewRedisScheduleRepository(client goredis.UniversalClient) *RedisScheduleRepository {
+	return &RedisScheduleRepository{
+		client: client,
+		prefix: defaultRedisKeyPrefix,
+		now:    time.Now,
+		ctx:    context.Background(),
+	}
+}
+
+// WithContext はctxで操作するリポジトリを返す
+// リクエストが中断された場合はRedisへのコマンドも中断される
+func (r *RedisScheduleRepository) WithContext(ctx context.Context) handlers.ScheduleRepository {
+	bound := *r
+	bound.ctx = ctx
+	return &bound
+}
+
+func (r *RedisScheduleRepository) scheduleKey(id string) string {
+	return r.prefix + "schedule:" + id
+}
+
+func (r *RedisScheduleRepository) editTokenKey(token string) string {
+	return r.prefix + "edit-token:" + token
+}
+
+func (r *RedisScheduleRepository) shortCodeKey(code string) string {
+	return r.prefix + "short-code:" + code
+}
+
+// expiresAtKey はスケジュールのIDをExpiresAt（ミリ秒）順に並べたソート済みセットのキー
+func (r *RedisScheduleRepository) expiresAtKey() string {
+	return r.prefix + "expires-at"
+}
+
+// reminderKey はスケジュールのExpiresAtに対するリマインダーの送信済みを表すキー
+// 失効日時が変わった場合は別のキーになり、新しい失効日時に対して改めて送信できる
+func (r *RedisScheduleRepository) reminderKey(id string, expiresAt time.Time) string {
+	return r.prefix + "reminder:" + id + ":" + strconv.FormatInt(expiresAt.UnixMilli(), 10)
+}
+
+func (r *RedisScheduleRepository) Create(schedule *model.Schedule) error {
+	ctx := r.ctx
+
+	ttl := schedule.ExpiresAt.Sub(r.now())
+	if ttl <= 0 {
+		// 既に失効しているスケジュールは保存しない
+		return model.ErrScheduleExpired
+	}
+
+	data, err := json.Marshal(r.convertScheduleToRedis(schedule))
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule: %w", err)
+	}
+
+	// スケジュール本体はSETNXで作成し、同じIDのスケジュールを上書きして古い編集トークンの逆引きキーを残さないようにする
+	created, err := r.client.SetNX(ctx, r.scheduleKey(schedule.ID), data, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	if !created {
+		return model.ErrScheduleExists
+	}
+
+	// 短縮コードも確保し、他のスケジュールと衝突した場合は作成した本体を削除する
+	if schedule.ShortCode != "" {
+		if err := r.reserveShortCode(ctx, schedule, ttl); err != nil {
+			r.cleanupCreate(ctx, schedule, false)
+			return err
+		}
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Set(ctx, r.editTokenKey(schedule.EditToken), schedule.ID, ttl)
+		pipe.ZAdd(ctx, r.expiresAtKey(), r.expiresAtMember(schedule))
+		return nil
+	})
+	if err != nil {
+		r.cleanupCreate(ctx, schedule, schedule.ShortCode != "")
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisScheduleRepository) expiresAtMember(schedule *model.Schedule) goredis.Z {
+	return goredis.Z{Score: float64(schedule.ExpiresAt.UnixMilli()), Member: schedule.ID}
+}
+
+// cleanupCreate は作成の途中で失敗した場合に、作成済みのキーを削除する
+// 削除にも失敗した場合はTTLで消えるのを待つ
+func (r *RedisScheduleRepository) cleanupCreate(ctx context.Context, schedule *model.Schedule, shortCode bool) {
+	keys := []string{r.scheduleKey(schedule.ID)}
+	if shortCode {
+		keys = append(keys, r.shortCodeKey(schedule.ShortCode))
+	}
+	r.client.Del(ctx, keys...)
+}
+
+// reserveShortCode は短縮コードの逆引きキーを作成する
+// 同じスケジュールが既に確保している場合は成功として扱う
+func (r *RedisScheduleRepository) reserveShortCode(ctx context.Context, schedule *model.Schedule, ttl time.Duration) error {
+	key := r.shortCodeKey(schedule.ShortCode)
+	ok, err := r.client.SetNX(ctx, key, schedule.ID, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to reserve short code: %w", err)
+	}
+	if ok {
+		return nil
+	}
+
+	owner, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, goredis.Nil) {
+		// 確認までの間に失効した場合はもう一度確保を試みる
+		return r.reserveShortCode(ctx, schedule, ttl)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reserve short code: %w", err)
+	}
+	if owner != schedule.ID {
+		return model.ErrShortCodeTaken
+	}
+	return nil
+}
+
+func (r *RedisScheduleRepository) GetByID(id string) (*model.Schedule, error) {
+	return r.get(r.ctx, id)
+}
+
+func (r *RedisScheduleRepository) GetByEditToken(token string) (*model.Schedule, error) {
+	ctx := r.ctx
+
+	id, err := r.client.Get(ctx, r.editTokenKey(token)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, model.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	return r.get(ctx, id)
+}
+
+// GetByShortCode は短縮コードの逆引きキーからスケジュールを取得する
+func (r *RedisScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	ctx := r.ctx
+
+	if code == "" {
+		return nil, model.ErrScheduleNotFound
+	}
+	id, err := r.client.Get(ctx, r.shortCodeKey(code)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, model.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	return r.get(ctx, id)
+}
+
+func (r *RedisScheduleRepository) Update(schedule *model.Schedule) error {
+	ctx := r.ctx
+	key := r.scheduleKey(schedule.ID)
+
+	data, err := json.Marshal(r.convertScheduleToRedis(schedule))
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule: %w", err)
+	}
+
+	// 存在確認と書き込みの間に削除・更新されないようWATCHで楽観ロックする
+	err = r.client.Watch(ctx, func(tx *goredis.Tx) error {
+		current, err := r.getWith(ctx, tx, schedule.ID)
+		if err != nil {
+			return err
+		}
+
+		ttl := schedule.ExpiresAt.Sub(r.now())
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			if current.EditToken != schedule.EditToken {
+				pipe.Del(ctx, r.editTokenKey(current.EditToken))
+			}
+			if current.ShortCode != "" && current.ShortCode != schedule.ShortCode {
+				pipe.Del(ctx, r.shortCodeKey(current.ShortCode))
+			}
+			if ttl <= 0 {
+				pipe.Del(ctx, key, r.editTokenKey(schedule.EditToken))
+				if schedule.ShortCode != "" {
+					pipe.Del(ctx, r.shortCodeKey(schedule.ShortCode))
+				}
+				pipe.ZRem(ctx, r.expiresAtKey(), schedule.ID)
+				return nil
+			}
+			pipe.Set(ctx, key, data, ttl)
+			pipe.Set(ctx, r.editTokenKey(schedule.EditToken), schedule.ID, ttl)
+			pipe.ZAdd(ctx, r.expiresAtKey(), r.expiresAtMember(schedule))
+			if schedule.ShortCode != "" {
+				pipe.Set(ctx, r.shortCodeKey(schedule.ShortCode), schedule.ID, ttl)
+			}
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, model.ErrScheduleNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisScheduleRepository) Delete(id string) error {
+	ctx := r.ctx
+	key := r.scheduleKey(id)
+
+	err := r.client.Watch(ctx, func(tx *goredis.Tx) error {
+		current, err := r.getWith(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			pipe.Del(ctx, key, r.editTokenKey(current.EditToken), r.reminderKey(id, current.ExpiresAt))
+			if current.ShortCode != "" {
+				pipe.Del(ctx, r.shortCodeKey(current.ShortCode))
+			}
+			pipe.ZRem(ctx, r.expiresAtKey(), id)
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, model.ErrScheduleNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return nil
+}
+
+// List は保存されている全てのスケジュールを作成日時順で返す
+func (r *RedisScheduleRepository) List() ([]*model.Schedule, error) {
+	ctx := r.ctx
+
+	var keys []string
+	iter := r.client.Scan(ctx, 0, r.scheduleKey("*"), 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	schedules, err := r.getMany(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+// ListExpiringBetween はExpiresAtがfromより後でto以前のスケジュールを作成日時順で返す
+// ソート済みセットから失効日時の範囲で探すため、全てのキーを走査しない
+func (r *RedisScheduleRepository) ListExpiringBetween(ctx context.Context, from, to time.Time) ([]*model.Schedule, error) {
+	// スケジュール本体はTTLで消えるため、失効済みのIDはここでソート済みセットから取り除く
+	if err := r.client.ZRemRangeByScore(ctx, r.expiresAtKey(), "-inf", strconv.FormatInt(r.now().UnixMilli(), 10)).Err(); err != nil {
+		return nil, fmt.Errorf("failed to list expiring schedules: %w", err)
+	}
+
+	ids, err := r.client.ZRangeByScore(ctx, r.expiresAtKey(), &goredis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(from.UnixMilli(), 10),
+		Max: strconv.FormatInt(to.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring schedules: %w", err)
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = r.scheduleKey(id)
+	}
+	schedules, err := r.getMany(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring schedules: %w", err)
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+// ClaimReminder はスケジュールの現在のExpiresAtに対するリマインダーの送信をSETNXで記録する
+// 既に記録されている場合や、スケジュールが失効している場合はfalseを返す
+// 記録のキーにはExpiresAtまでのTTLを設定し、スケジュールと一緒に消えるようにする
+func (r *RedisScheduleRepository) ClaimReminder(ctx context.Context, schedule *model.Schedule) (bool, error) {
+	ttl := schedule.ExpiresAt.Sub(r.now())
+	if ttl <= 0 {
+		return false, nil
+	}
+	claimed, err := r.client.SetNX(ctx, r.reminderKey(schedule.ID, schedule.ExpiresAt), "1", ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+	return claimed, nil
+}
+
+// getMany はスケジュール本体のキーから100件ずつまとめて取得する
+// 取得するまでの間に失効したキーは結果に含めない
+func (r *RedisScheduleRepository) getMany(ctx context.Context, keys []string) ([]*model.Schedule, error) {
+	schedules := make([]*model.Schedule, 0, len(keys))
+	for start := 0; start < len(keys); start += 100 {
+		end := start + 100
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		values, err := r.client.MGet(ctx, keys[start:end]...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			// 失効したキーはnilになる
+			data, ok := value.(string)
+			if !ok {
+				continue
+			}
+			var stored redisSchedule
+			if err := json.Unmarshal([]byte(data), &stored); err != nil {
+				return nil, fmt.Errorf("failed to decode schedule: %w", err)
+			}
+			schedules = append(schedules, r.convertRedisToSchedule(&stored))
+		}
+	}
+	return schedules, nil
+}
+
+func (r *RedisScheduleRepository) get(ctx context.Context, id string) (*model.Schedule, error) {
+	schedule, err := r.getWith(ctx, r.client, id)
+	if errors.Is(err, model.ErrScheduleNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+func (r *RedisScheduleRepository) getWith(ctx context.Context, cmd goredis.Cmdable, id string) (*model.Schedule, error) {
+	data, err := cmd.Get(ctx, r.scheduleKey(id)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, model.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored redisSchedule
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode schedule: %w", err)
+	}
+	return r.convertRedisToSchedule(&stored), nil
+}
+
+func (r *RedisScheduleRepository) convertScheduleToRedis(schedule *model.Schedule) *redisSchedule {
+	slots := make([]redisTimeSlot, len(schedule.TimeSlots))
+	for i, slot := range schedule.TimeSlots {
+		slots[i] = redisTimeSlot{
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Available: slot.Available,
+		}
+	}
+
+	return &redisSchedule{
+		ID:               schedule.ID,
+		EditToken:        schedule.EditToken,
+		ShortCode:        schedule.ShortCode,
+		TimeSlots:        slots,
+		Comment:          schedule.Comment,
+		CreatedAt:        schedule.CreatedAt,
+		UpdatedAt:        schedule.UpdatedAt,
+		ExpiresAt:        schedule.ExpiresAt,
+		ViewPasswordHash: schedule.ViewPasswordHash,
+		WebhookURLs:      schedule.WebhookURLs,
+		WebhookSecret:    schedule.WebhookSecret,
+		NotifyEmail:      schedule.NotifyEmail,
+		NotifyLocale:     schedule.NotifyLocale,
+	}
+}
+
+func (r *RedisScheduleRepository) convertRedisToSchedule(stored *redisSchedule) *model.Schedule {
+	slots := make([]model.TimeSlot, len(stored.TimeSlots))
+	for i, slot := range stored.TimeSlots {
+		slots[i] = model.TimeSlot{
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Available: slot.Available,
+		}
+	}
+
+	return &model.Schedule{
+		ID:               stored.ID,
+		EditToken:        stored.EditToken,
+		ShortCode:        stored.ShortCode,
+		TimeSlots:        slots,
+		Comment:          stored.Comment,
+		CreatedAt:        stored.CreatedAt,
+		UpdatedAt:        stored.UpdatedAt,
+		ExpiresAt:        stored.ExpiresAt,
+		ViewPasswordHash: stored.ViewPasswordHash,
+		WebhookURLs:      stored.WebhookURLs,
+		WebhookSecret:    stored.WebhookSecret,
+		NotifyEmail:      stored.NotifyEmail,
+		NotifyLocale:     stored.NotifyLocale,
+	}
+}
+
+// Ping はRedisへの疎通を確認する
+func (r *RedisScheduleRepository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
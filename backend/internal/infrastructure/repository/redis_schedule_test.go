@@ -0,0 +1,435 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+func newTestRedisRepository(t *testing.T) (*RedisScheduleRepository, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisScheduleRepository(client), mr
+}
+
+func TestRedisScheduleRepository_CRUD(t *testing.T) {
+	t.Run("作成したスケジュールをIDと編集トークンで取得できる", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-1", "redis-token-1")
+		require.NoError(t, repo.Create(schedule))
+
+		byID, err := repo.GetByID("redis-1")
+		require.NoError(t, err)
+		assert.Equal(t, schedule.ID, byID.ID)
+		assert.Equal(t, schedule.EditToken, byID.EditToken)
+		assert.Equal(t, schedule.Comment, byID.Comment)
+		require.Len(t, byID.TimeSlots, 1)
+		assert.True(t, schedule.TimeSlots[0].StartTime.Equal(byID.TimeSlots[0].StartTime))
+		assert.True(t, byID.TimeSlots[0].Available)
+		assert.True(t, schedule.ExpiresAt.Equal(byID.ExpiresAt))
+		assert.True(t, schedule.UpdatedAt.Equal(byID.UpdatedAt))
+
+		byToken, err := repo.GetByEditToken("redis-token-1")
+		require.NoError(t, err)
+		assert.Equal(t, "redis-1", byToken.ID)
+	})
+
+	t.Run("閲覧パスワードのハッシュが保存される", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-protected", "redis-token-protected")
+		require.NoError(t, schedule.SetViewPassword("secret"))
+		require.NoError(t, repo.Create(schedule))
+
+		stored, err := repo.GetByID("redis-protected")
+		require.NoError(t, err)
+		assert.Equal(t, schedule.ViewPasswordHash, stored.ViewPasswordHash)
+		assert.NoError(t, stored.VerifyViewPassword("secret"))
+	})
+
+	t.Run("Webhookの通知先と署名鍵が保存される", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-webhook", "redis-token-webhook")
+		require.NoError(t, schedule.SetWebhookURLs([]string{"https://hooks.example.com/kareru"}))
+		require.NoError(t, repo.Create(schedule))
+
+		stored, err := repo.GetByID("redis-webhook")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://hooks.example.com/kareru"}, stored.WebhookURLs)
+		assert.Equal(t, schedule.WebhookSecret, stored.WebhookSecret)
+	})
+
+	t.Run("通知メールの宛先と言語が保存される", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-email", "redis-token-email")
+		require.NoError(t, schedule.SetNotifyEmail("taro@example.com", model.LocaleEnglish))
+		require.NoError(t, repo.Create(schedule))
+
+		stored, err := repo.GetByID("redis-email")
+		require.NoError(t, err)
+		assert.Equal(t, "taro@example.com", stored.NotifyEmail)
+		assert.Equal(t, model.LocaleEnglish, stored.NotifyLocale)
+	})
+
+	t.Run("同じIDのスケジュールは作成し直せない", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		require.NoError(t, repo.Create(newMemoryTestSchedule("redis-dup", "redis-dup-token-1")))
+
+		assert.ErrorIs(t, repo.Create(newMemoryTestSchedule("redis-dup", "redis-dup-token-2")), model.ErrScheduleExists)
+
+		got, err := repo.GetByEditToken("redis-dup-token-1")
+		require.NoError(t, err)
+		assert.Equal(t, "redis-dup", got.ID)
+		_, err = repo.GetByEditToken("redis-dup-token-2")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+
+	t.Run("存在しないスケジュールはErrScheduleNotFoundを返す", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+
+		_, err := repo.GetByID("missing")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		_, err = repo.GetByEditToken("missing-token")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		assert.ErrorIs(t, repo.Update(newMemoryTestSchedule("missing", "t")), model.ErrScheduleNotFound)
+		assert.ErrorIs(t, repo.Delete("missing"), model.ErrScheduleNotFound)
+	})
+
+	t.Run("更新内容が保存される", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		require.NoError(t, repo.Create(newMemoryTestSchedule("redis-2", "redis-token-2")))
+
+		schedule, err := repo.GetByID("redis-2")
+		require.NoError(t, err)
+		schedule.Comment = "更新後"
+		schedule.TimeSlots = append(schedule.TimeSlots, model.TimeSlot{
+			StartTime: schedule.TimeSlots[0].EndTime,
+			EndTime:   schedule.TimeSlots[0].EndTime.Add(time.Hour),
+		})
+		require.NoError(t, repo.Update(schedule))
+
+		updated, err := repo.GetByEditToken("redis-token-2")
+		require.NoError(t, err)
+		assert.Equal(t, "更新後", updated.Comment)
+		assert.Len(t, updated.TimeSlots, 2)
+	})
+
+	t.Run("削除すると編集トークンのキーも削除される", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		require.NoError(t, repo.Create(newMemoryTestSchedule("redis-3", "redis-token-3")))
+
+		require.NoError(t, repo.Delete("redis-3"))
+
+		_, err := repo.GetByID("redis-3")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		_, err = repo.GetByEditToken("redis-token-3")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		assert.Empty(t, mr.Keys())
+	})
+}
+
+func TestRedisScheduleRepository_ShortCode(t *testing.T) {
+	t.Run("短縮コードで取得でき、逆引きキーにもTTLが設定される", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-short", "redis-short-token")
+		schedule.ShortCode = "7K3M9QX2"
+		schedule.ExpiresAt = time.Now().Add(time.Hour)
+		require.NoError(t, repo.Create(schedule))
+
+		found, err := repo.GetByShortCode("7K3M9QX2")
+		require.NoError(t, err)
+		assert.Equal(t, "redis-short", found.ID)
+		assert.Equal(t, "7K3M9QX2", found.ShortCode)
+		assert.Equal(t, mr.TTL(repo.scheduleKey("redis-short")), mr.TTL(repo.shortCodeKey("7K3M9QX2")))
+
+		_, err = repo.GetByShortCode("AAAAAAAA")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+
+	t.Run("他のスケジュールと短縮コードが衝突するとErrShortCodeTakenを返す", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		first := newMemoryTestSchedule("redis-short-a", "redis-short-token-a")
+		first.ShortCode = "7K3M9QX2"
+		require.NoError(t, repo.Create(first))
+
+		second := newMemoryTestSchedule("redis-short-b", "redis-short-token-b")
+		second.ShortCode = "7K3M9QX2"
+		assert.ErrorIs(t, repo.Create(second), model.ErrShortCodeTaken)
+
+		_, err := repo.GetByID("redis-short-b")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		_, err = repo.GetByEditToken("redis-short-token-b")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+
+	t.Run("作成の途中で失敗すると確保した短縮コードを解放する", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-short-fail", "redis-short-token-fail")
+		schedule.ShortCode = "9Z8Y7X6W"
+		repo.client.AddHook(failingKeyHook{key: repo.editTokenKey("redis-short-token-fail")})
+
+		assert.Error(t, repo.Create(schedule))
+		assert.Empty(t, mr.Keys())
+	})
+
+	t.Run("削除すると短縮コードのキーも削除される", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-short-del", "redis-short-token-del")
+		schedule.ShortCode = "AB12CD34"
+		require.NoError(t, repo.Create(schedule))
+
+		require.NoError(t, repo.Delete("redis-short-del"))
+
+		_, err := repo.GetByShortCode("AB12CD34")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		assert.Empty(t, mr.Keys())
+	})
+}
+
+func TestRedisScheduleRepository_TTL(t *testing.T) {
+	t.Run("ExpiresAtまでのTTLが設定され、経過後は取得できない", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-ttl", "redis-ttl-token")
+		schedule.ExpiresAt = time.Now().Add(time.Hour)
+		require.NoError(t, repo.Create(schedule))
+
+		ttl := mr.TTL(repo.scheduleKey("redis-ttl"))
+		assert.InDelta(t, time.Hour.Seconds(), ttl.Seconds(), 5)
+		assert.Equal(t, ttl, mr.TTL(repo.editTokenKey("redis-ttl-token")))
+
+		mr.FastForward(time.Hour + time.Second)
+
+		_, err := repo.GetByID("redis-ttl")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+		_, err = repo.GetByEditToken("redis-ttl-token")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	})
+
+	t.Run("失効済みのスケジュールは保存されない", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-expired", "redis-expired-token")
+		schedule.ExpiresAt = time.Now().Add(-time.Minute)
+
+		assert.ErrorIs(t, repo.Create(schedule), model.ErrScheduleExpired)
+		assert.Empty(t, mr.Keys())
+	})
+}
+
+func TestRedisScheduleRepository_List(t *testing.T) {
+	t.Run("全てのスケジュールを作成日時順で取得できる", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		base := time.Now()
+		offsets := map[string]time.Duration{"list-2": 2 * time.Minute, "list-1": 0, "list-3": 3 * time.Minute}
+		for _, id := range []string{"list-2", "list-1", "list-3"} {
+			schedule := newMemoryTestSchedule(id, "token-"+id)
+			schedule.CreatedAt = base.Add(offsets[id])
+			require.NoError(t, repo.Create(schedule))
+		}
+
+		schedules, err := repo.List()
+		require.NoError(t, err)
+		require.Len(t, schedules, 3)
+		assert.Equal(t, "list-1", schedules[0].ID)
+		assert.Equal(t, "list-2", schedules[1].ID)
+		assert.Equal(t, "list-3", schedules[2].ID)
+	})
+}
+
+func TestRedisScheduleRepository_Reminder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("失効日時が範囲内のスケジュールだけを取得できる", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		now := time.Now()
+		for id, expiresIn := range map[string]time.Duration{
+			"expiring-soon":  12 * time.Hour,
+			"expiring-later": 48 * time.Hour,
+		} {
+			schedule := newMemoryTestSchedule(id, "token-"+id)
+			schedule.ExpiresAt = now.Add(expiresIn)
+			require.NoError(t, repo.Create(schedule))
+		}
+
+		schedules, err := repo.ListExpiringBetween(ctx, now, now.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		assert.Equal(t, "expiring-soon", schedules[0].ID)
+
+		// 更新で失効日時が変わると索引も更新される
+		later, err := repo.GetByID("expiring-later")
+		require.NoError(t, err)
+		later.ExpiresAt = now.Add(6 * time.Hour)
+		require.NoError(t, repo.Update(later))
+
+		schedules, err = repo.ListExpiringBetween(ctx, now, now.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, schedules, 2)
+	})
+
+	t.Run("失効したスケジュールは索引から取り除かれる", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-index-expired", "redis-index-expired-token")
+		schedule.ExpiresAt = time.Now().Add(time.Hour)
+		require.NoError(t, repo.Create(schedule))
+
+		mr.FastForward(time.Hour + time.Second)
+		repo.now = func() time.Time { return time.Now().Add(time.Hour + time.Second) }
+
+		schedules, err := repo.ListExpiringBetween(ctx, time.Now(), time.Now().Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, schedules)
+		assert.Empty(t, mr.Keys())
+	})
+
+	t.Run("リマインダーの送信は失効日時ごとに1回だけ記録できる", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-reminder", "redis-reminder-token")
+		require.NoError(t, repo.Create(schedule))
+
+		claimed, err := repo.ClaimReminder(ctx, schedule)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		claimed, err = repo.ClaimReminder(ctx, schedule)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.InDelta(t, mr.TTL(repo.scheduleKey(schedule.ID)).Seconds(), mr.TTL(repo.reminderKey(schedule.ID, schedule.ExpiresAt)).Seconds(), 5)
+
+		extended := *schedule
+		extended.ExpiresAt = schedule.ExpiresAt.Add(24 * time.Hour)
+		claimed, err = repo.ClaimReminder(ctx, &extended)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	})
+
+	t.Run("削除すると索引と送信の記録も削除される", func(t *testing.T) {
+		repo, mr := newTestRedisRepository(t)
+		schedule := newMemoryTestSchedule("redis-reminder-del", "redis-reminder-del-token")
+		require.NoError(t, repo.Create(schedule))
+		_, err := repo.ClaimReminder(ctx, schedule)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Delete(schedule.ID))
+		assert.Empty(t, mr.Keys())
+	})
+}
+
+// contextRecordingHook はコマンドに渡されたコンテキストの値を記録するテスト用のフック
+type contextRecordingHook struct {
+	values *[]any
+}
+
+func (h contextRecordingHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h contextRecordingHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		*h.values = append(*h.values, ctx.Value(contextKey{}))
+		return next(ctx, cmd)
+	}
+}
+
+func (h contextRecordingHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		*h.values = append(*h.values, ctx.Value(contextKey{}))
+		return next(ctx, cmds)
+	}
+}
+
+func TestRedisScheduleRepository_WithContext(t *testing.T) {
+	t.Run("WithContextで渡したコンテキストで全ての操作のコマンドを送る", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		var values []any
+		client.AddHook(contextRecordingHook{values: &values})
+		repo := NewRedisScheduleRepository(client)
+
+		bound := repo.WithContext(context.WithValue(context.Background(), contextKey{}, "request"))
+		schedule := newMemoryTestSchedule("redis-ctx-1", "redis-ctx-token-1")
+		require.NoError(t, bound.Create(schedule))
+		_, err := bound.GetByID("redis-ctx-1")
+		require.NoError(t, err)
+		_, err = bound.GetByEditToken("redis-ctx-token-1")
+		require.NoError(t, err)
+		schedule.Comment = "変更"
+		require.NoError(t, bound.Update(schedule))
+		_, err = bound.(*RedisScheduleRepository).List()
+		require.NoError(t, err)
+		require.NoError(t, bound.Delete("redis-ctx-1"))
+
+		require.NotEmpty(t, values)
+		for _, value := range values {
+			assert.Equal(t, "request", value)
+		}
+
+		// 元のリポジトリには影響しない
+		values = nil
+		_, err = repo.GetByID("redis-ctx-1")
+		assert.True(t, errors.Is(err, model.ErrScheduleNotFound))
+		require.NotEmpty(t, values)
+		assert.Nil(t, values[0])
+	})
+
+	t.Run("リクエストが中断されるとコマンドも中断する", func(t *testing.T) {
+		repo, _ := newTestRedisRepository(t)
+		require.NoError(t, repo.Create(newMemoryTestSchedule("redis-ctx-2", "redis-ctx-token-2")))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := repo.WithContext(ctx).GetByID("redis-ctx-2")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRedisScheduleRepository_Ping(t *testing.T) {
+	repo, mr := newTestRedisRepository(t)
+
+	assert.NoError(t, repo.Ping(context.Background()))
+
+	mr.Close()
+	assert.Error(t, repo.Ping(context.Background()))
+}
+
+// failingKeyHook は指定したキーへのコマンドを失敗させるテスト用のフック
+type failingKeyHook struct {
+	key string
+}
+
+func (h failingKeyHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h failingKeyHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		if len(cmd.Args()) > 1 && cmd.Args()[1] == h.key {
+			err := errors.New("injected failure")
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h failingKeyHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		for _, cmd := range cmds {
+			if len(cmd.Args()) > 1 && cmd.Args()[1] == h.key {
+				err := errors.New("injected failure")
+				for _, cmd := range cmds {
+					cmd.SetErr(err)
+				}
+				return err
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
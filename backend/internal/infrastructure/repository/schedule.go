@@ -2,8 +2,15 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"kareru-backend/internal/domain/model"
 	firestoreClient "kareru-backend/internal/infrastructure/firestore"
 )
@@ -19,15 +26,44 @@ func NewScheduleRepository(client *firestoreClient.Client) *ScheduleRepository {
 }
 
 func (r *ScheduleRepository) Create(ctx context.Context, schedule *model.Schedule) error {
-	doc := r.client.Collection("schedules").Doc(schedule.ID)
-	_, err := doc.Set(ctx, map[string]interface{}{
+	schedules := r.client.Collection("schedules")
+	doc := schedules.Doc(schedule.ID)
+	data := map[string]interface{}{
 		"id":        schedule.ID,
 		"editToken": schedule.EditToken,
+		"shortCode": schedule.ShortCode,
 		"timeSlots": r.convertTimeSlotsToFirestore(schedule.TimeSlots),
 		"comment":   schedule.Comment,
 		"createdAt": schedule.CreatedAt,
+		"updatedAt": schedule.UpdatedAt,
 		"expiresAt": schedule.ExpiresAt,
+		// 閲覧パスワードのハッシュ（保護されていない場合は空文字）
+		"viewPasswordHash": schedule.ViewPasswordHash,
+		// 登録されたWebhookの通知先と署名鍵
+		"webhookURLs":   schedule.WebhookURLs,
+		"webhookSecret": schedule.WebhookSecret,
+		// 通知メールの宛先と言語
+		"notifyEmail":  schedule.NotifyEmail,
+		"notifyLocale": schedule.NotifyLocale,
+	}
+
+	// 短縮コードの重複確認と書き込みをトランザクションで行う
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if schedule.ShortCode != "" {
+			query := schedules.Where("shortCode", "==", schedule.ShortCode).Limit(1)
+			docs, err := tx.Documents(query).GetAll()
+			if err != nil {
+				return err
+			}
+			if len(docs) > 0 && docs[0].Ref.ID != schedule.ID {
+				return model.ErrShortCodeTaken
+			}
+		}
+		return tx.Set(doc, data)
 	})
+	if errors.Is(err, model.ErrShortCodeTaken) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create schedule: %w", err)
 	}
@@ -36,6 +72,9 @@ func (r *ScheduleRepository) Create(ctx context.Context, schedule *model.Schedul
 
 func (r *ScheduleRepository) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
 	doc, err := r.client.Collection("schedules").Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, model.ErrScheduleNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get schedule: %w", err)
 	}
@@ -44,6 +83,167 @@ func (r *ScheduleRepository) GetByID(ctx context.Context, id string) (*model.Sch
 	return r.convertFirestoreToSchedule(data)
 }
 
+func (r *ScheduleRepository) GetByEditToken(ctx context.Context, token string) (*model.Schedule, error) {
+	docs, err := r.client.Collection("schedules").Where("editToken", "==", token).Limit(1).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, model.ErrScheduleNotFound
+	}
+
+	return r.convertFirestoreToSchedule(docs[0].Data())
+}
+
+// GetByShortCode は短縮コードでスケジュールを取得する
+func (r *ScheduleRepository) GetByShortCode(ctx context.Context, code string) (*model.Schedule, error) {
+	if code == "" {
+		return nil, model.ErrScheduleNotFound
+	}
+	docs, err := r.client.Collection("schedules").Where("shortCode", "==", code).Limit(1).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, model.ErrScheduleNotFound
+	}
+
+	return r.convertFirestoreToSchedule(docs[0].Data())
+}
+
+func (r *ScheduleRepository) Update(ctx context.Context, schedule *model.Schedule) error {
+	// 存在しないドキュメントを作成しないよう、Updateで既存ドキュメントのみ更新する
+	_, err := r.client.Collection("schedules").Doc(schedule.ID).Update(ctx, []firestore.Update{
+		{Path: "editToken", Value: schedule.EditToken},
+		{Path: "timeSlots", Value: r.convertTimeSlotsToFirestore(schedule.TimeSlots)},
+		{Path: "comment", Value: schedule.Comment},
+		{Path: "createdAt", Value: schedule.CreatedAt},
+		{Path: "updatedAt", Value: schedule.UpdatedAt},
+		{Path: "expiresAt", Value: schedule.ExpiresAt},
+		{Path: "viewPasswordHash", Value: schedule.ViewPasswordHash},
+		{Path: "webhookURLs", Value: schedule.WebhookURLs},
+		{Path: "webhookSecret", Value: schedule.WebhookSecret},
+		{Path: "notifyEmail", Value: schedule.NotifyEmail},
+		{Path: "notifyLocale", Value: schedule.NotifyLocale},
+	})
+	if status.Code(err) == codes.NotFound {
+		return model.ErrScheduleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+	return nil
+}
+
+func (r *ScheduleRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.client.Collection("schedules").Doc(id).Delete(ctx, firestore.Exists)
+	if status.Code(err) == codes.NotFound {
+		return model.ErrScheduleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return nil
+}
+
+// List は全てのスケジュールを作成日時順で返す
+func (r *ScheduleRepository) List(ctx context.Context) ([]*model.Schedule, error) {
+	docs, err := r.client.Collection("schedules").OrderBy("createdAt", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	schedules := make([]*model.Schedule, 0, len(docs))
+	for _, doc := range docs {
+		schedule, err := r.convertFirestoreToSchedule(doc.Data())
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// ListExpiringBetween はExpiresAtがfromより後でto以前のスケジュールを失効日時順で返す
+// expiresAtの単一フィールドの範囲検索のため、Firestoreが自動で作成するインデックスで検索できる
+func (r *ScheduleRepository) ListExpiringBetween(ctx context.Context, from, to time.Time) ([]*model.Schedule, error) {
+	docs, err := r.client.Collection("schedules").
+		Where("expiresAt", ">", from).
+		Where("expiresAt", "<=", to).
+		OrderBy("expiresAt", firestore.Asc).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring schedules: %w", err)
+	}
+
+	schedules := make([]*model.Schedule, 0, len(docs))
+	for _, doc := range docs {
+		schedule, err := r.convertFirestoreToSchedule(doc.Data())
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// ClaimReminder はスケジュールの現在のExpiresAtに対するリマインダーの送信を記録する
+// remindersコレクションにスケジュールのIDと失効日時から決まるドキュメントをCreateで作成し、
+// 既に存在する場合はfalseを返す（expiresAtにTTLポリシーを設定すると失効後に削除される）
+func (r *ScheduleRepository) ClaimReminder(ctx context.Context, schedule *model.Schedule) (bool, error) {
+	id := schedule.ID + "_" + strconv.FormatInt(schedule.ExpiresAt.UnixMilli(), 10)
+	_, err := r.client.Collection("reminders").Doc(id).Create(ctx, map[string]interface{}{
+		"scheduleId": schedule.ID,
+		"expiresAt":  schedule.ExpiresAt,
+		"claimedAt":  time.Now(),
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+	return true, nil
+}
+
+// ScheduleDocument はFirestoreに保存されたスケジュールドキュメントの生データ
+type ScheduleDocument struct {
+	ID   string
+	Data map[string]interface{}
+}
+
+// ListDocuments はドキュメントID順に最大pageSize件の生データを返す
+// startAfterに前ページ最後のドキュメントIDを渡すと続きを取得できる
+func (r *ScheduleRepository) ListDocuments(ctx context.Context, pageSize int, startAfter string) ([]ScheduleDocument, error) {
+	query := r.client.Collection("schedules").OrderBy(firestore.DocumentID, firestore.Asc).Limit(pageSize)
+	if startAfter != "" {
+		query = query.StartAfter(startAfter)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule documents: %w", err)
+	}
+
+	result := make([]ScheduleDocument, len(docs))
+	for i, doc := range docs {
+		result[i] = ScheduleDocument{
+			ID:   doc.Ref.ID,
+			Data: doc.Data(),
+		}
+	}
+	return result, nil
+}
+
+// Ping はschedulesコレクションを1件だけ読み取ってFirestoreへの疎通を確認する
+func (r *ScheduleRepository) Ping(ctx context.Context) error {
+	_, err := r.client.Collection("schedules").Limit(1).Documents(ctx).Next()
+	if err != nil && !errors.Is(err, iterator.Done) {
+		return err
+	}
+	return nil
+}
+
 func (r *ScheduleRepository) convertTimeSlotsToFirestore(slots []model.TimeSlot) []map[string]interface{} {
 	result := make([]map[string]interface{}, len(slots))
 	for i, slot := range slots {
@@ -67,9 +267,70 @@ func (r *ScheduleRepository) convertFirestoreToSchedule(data map[string]interfac
 		schedule.EditToken = editToken
 	}
 
+	if shortCode, ok := data["shortCode"].(string); ok {
+		schedule.ShortCode = shortCode
+	}
+
 	if comment, ok := data["comment"].(string); ok {
 		schedule.Comment = comment
 	}
 
+	if hash, ok := data["viewPasswordHash"].(string); ok {
+		schedule.ViewPasswordHash = hash
+	}
+
+	if urls, ok := data["webhookURLs"].([]interface{}); ok {
+		for _, raw := range urls {
+			if u, ok := raw.(string); ok {
+				schedule.WebhookURLs = append(schedule.WebhookURLs, u)
+			}
+		}
+	}
+
+	if secret, ok := data["webhookSecret"].(string); ok {
+		schedule.WebhookSecret = secret
+	}
+
+	if email, ok := data["notifyEmail"].(string); ok {
+		schedule.NotifyEmail = email
+	}
+
+	if locale, ok := data["notifyLocale"].(string); ok {
+		schedule.NotifyLocale = locale
+	}
+
+	if createdAt, ok := data["createdAt"].(time.Time); ok {
+		schedule.CreatedAt = createdAt
+	}
+
+	if updatedAt, ok := data["updatedAt"].(time.Time); ok {
+		schedule.UpdatedAt = updatedAt
+	}
+
+	if expiresAt, ok := data["expiresAt"].(time.Time); ok {
+		schedule.ExpiresAt = expiresAt
+	}
+
+	if slots, ok := data["timeSlots"].([]interface{}); ok {
+		schedule.TimeSlots = make([]model.TimeSlot, 0, len(slots))
+		for _, raw := range slots {
+			slotData, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var slot model.TimeSlot
+			if startTime, ok := slotData["startTime"].(time.Time); ok {
+				slot.StartTime = startTime
+			}
+			if endTime, ok := slotData["endTime"].(time.Time); ok {
+				slot.EndTime = endTime
+			}
+			if available, ok := slotData["available"].(bool); ok {
+				slot.Available = available
+			}
+			schedule.TimeSlots = append(schedule.TimeSlots, slot)
+		}
+	}
+
 	return schedule, nil
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -32,7 +33,7 @@ func setupTestEnvironment() {
 func TestScheduleRepository_Create(t *testing.T) {
 	// テスト環境でFirestoreエミュレータを使用
 	setupTestEnvironment()
-	
+
 	ctx := context.Background()
 	client, err := firestore.NewClient(ctx)
 	require.NoError(t, err)
@@ -62,7 +63,7 @@ func TestScheduleRepository_Create(t *testing.T) {
 func TestScheduleRepository_GetByID(t *testing.T) {
 	// テスト環境でFirestoreエミュレータを使用
 	setupTestEnvironment()
-	
+
 	ctx := context.Background()
 	client, err := firestore.NewClient(ctx)
 	require.NoError(t, err)
@@ -98,7 +99,7 @@ func TestScheduleRepository_GetByID(t *testing.T) {
 func TestScheduleRepository_GetByID_NotFound(t *testing.T) {
 	// テスト環境でFirestoreエミュレータを使用
 	setupTestEnvironment()
-	
+
 	ctx := context.Background()
 	client, err := firestore.NewClient(ctx)
 	require.NoError(t, err)
@@ -110,3 +111,164 @@ func TestScheduleRepository_GetByID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestScheduleRepository_ListDocuments(t *testing.T) {
+	// テスト環境でFirestoreエミュレータを使用
+	setupTestEnvironment()
+
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	repo := NewScheduleRepository(client)
+
+	for _, id := range []string{"test-list-a", "test-list-b", "test-list-c"} {
+		err := repo.Create(ctx, &model.Schedule{
+			ID:        id,
+			EditToken: id + "-token",
+			Comment:   "一覧テスト用",
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	// ページングで全件を取得できること
+	var ids []string
+	startAfter := ""
+	for {
+		docs, err := repo.ListDocuments(ctx, 2, startAfter)
+		require.NoError(t, err)
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+			assert.Equal(t, doc.ID, doc.Data["id"])
+		}
+		if len(docs) < 2 {
+			break
+		}
+		startAfter = docs[len(docs)-1].ID
+	}
+
+	assert.Subset(t, ids, []string{"test-list-a", "test-list-b", "test-list-c"})
+}
+
+func TestScheduleRepository_UpdateAndDelete(t *testing.T) {
+	// テスト環境でFirestoreエミュレータを使用
+	setupTestEnvironment()
+
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	repo := NewScheduleRepository(client)
+
+	schedule := &model.Schedule{
+		ID:        "test-update-uuid",
+		EditToken: "test-update-token",
+		Comment:   "更新前",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, schedule))
+
+	// 編集トークンで取得できること
+	retrieved, err := repo.GetByEditToken(ctx, "test-update-token")
+	require.NoError(t, err)
+	assert.Equal(t, "test-update-uuid", retrieved.ID)
+
+	// 更新が反映されること
+	retrieved.Comment = "更新後"
+	require.NoError(t, repo.Update(ctx, retrieved))
+	updated, err := repo.GetByID(ctx, "test-update-uuid")
+	require.NoError(t, err)
+	assert.Equal(t, "更新後", updated.Comment)
+
+	// 削除後は取得できないこと
+	require.NoError(t, repo.Delete(ctx, "test-update-uuid"))
+	_, err = repo.GetByID(ctx, "test-update-uuid")
+	assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, "test-update-uuid"), model.ErrScheduleNotFound)
+	assert.ErrorIs(t, repo.Update(ctx, retrieved), model.ErrScheduleNotFound)
+}
+
+func TestScheduleRepository_ShortCode(t *testing.T) {
+	// テスト環境でFirestoreエミュレータを使用
+	setupTestEnvironment()
+
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	repo := NewScheduleRepository(client)
+
+	schedule := &model.Schedule{
+		ID:        "test-short-code-uuid",
+		EditToken: "test-short-code-token",
+		ShortCode: "7K3M9QX2",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, schedule))
+	defer repo.Delete(ctx, schedule.ID)
+
+	// 短縮コードで取得できること
+	retrieved, err := repo.GetByShortCode(ctx, "7K3M9QX2")
+	require.NoError(t, err)
+	assert.Equal(t, "test-short-code-uuid", retrieved.ID)
+	assert.Equal(t, "7K3M9QX2", retrieved.ShortCode)
+
+	// 他のスケジュールと衝突する短縮コードでは作成できないこと
+	duplicate := &model.Schedule{
+		ID:        "test-short-code-duplicate",
+		EditToken: "test-short-code-duplicate-token",
+		ShortCode: "7K3M9QX2",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+	}
+	assert.ErrorIs(t, repo.Create(ctx, duplicate), model.ErrShortCodeTaken)
+
+	_, err = repo.GetByShortCode(ctx, "AAAAAAAA")
+	assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+}
+
+func TestScheduleRepository_Reminder(t *testing.T) {
+	// テスト環境でFirestoreエミュレータを使用
+	setupTestEnvironment()
+
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	repo := NewScheduleRepository(client)
+
+	now := time.Now()
+	schedule := &model.Schedule{
+		ID:        "test-reminder-uuid",
+		EditToken: "test-reminder-token",
+		CreatedAt: now,
+		ExpiresAt: now.Add(12 * time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, schedule))
+	defer repo.Delete(ctx, schedule.ID)
+
+	// 失効日時が範囲内のスケジュールを取得できること
+	schedules, err := repo.ListExpiringBetween(ctx, now, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	ids := make([]string, len(schedules))
+	for i, s := range schedules {
+		ids[i] = s.ID
+	}
+	assert.Contains(t, ids, "test-reminder-uuid")
+
+	// リマインダーの送信は1回だけ記録できること
+	defer client.Collection("reminders").Doc("test-reminder-uuid_" + strconv.FormatInt(schedule.ExpiresAt.UnixMilli(), 10)).Delete(ctx)
+	claimed, err := repo.ClaimReminder(ctx, schedule)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = repo.ClaimReminder(ctx, schedule)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
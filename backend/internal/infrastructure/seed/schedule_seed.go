@@ -2,59 +2,163 @@ package seed
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
 	"time"
 
 	"kareru-backend/internal/domain/model"
-	"kareru-backend/internal/infrastructure/firestore"
-	"kareru-backend/internal/infrastructure/repository"
 )
 
-func SeedSchedules(ctx context.Context, client *firestore.Client) error {
-	repo := repository.NewScheduleRepository(client)
+// Destination はシードデータの保存先
+type Destination interface {
+	Create(ctx context.Context, schedule *model.Schedule) error
+}
+
+// Options はシードデータ生成の設定
+type Options struct {
+	// Count は生成するスケジュール数
+	Count int
+	// From と Days はタイムスロットを配置する日付の範囲
+	From time.Time
+	Days int
+	// Interval はタイムスロットの刻み（30分/1時間/3時間/1日）
+	Interval time.Duration
+	// Density は営業時間内の各枠がタイムスロットとして選ばれる確率（0〜1）
+	Density float64
+	// ExpiredRatio は作成から7日以上経過した（失効済みの）スケジュールの割合（0〜1）
+	ExpiredRatio float64
+	// Seed は乱数のシード。同じ値なら同じデータが生成される
+	Seed int64
+	// Now は作成日時の基準時刻
+	Now time.Time
+}
 
+// DefaultOptions はデフォルトの生成設定を返す
+func DefaultOptions() Options {
 	now := time.Now()
+	return Options{
+		Count:        10,
+		From:         time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+		Days:         7,
+		Interval:     time.Hour,
+		Density:      0.3,
+		ExpiredRatio: 0,
+		Seed:         1,
+		Now:          now,
+	}
+}
 
-	schedules := []*model.Schedule{
-		{
-			ID:        "sample-schedule-1",
-			EditToken: "token-123",
-			TimeSlots: []model.TimeSlot{
-				{
-					StartTime: now.Add(1 * time.Hour),
-					EndTime:   now.Add(2 * time.Hour),
-					Available: true,
-				},
-				{
-					StartTime: now.Add(3 * time.Hour),
-					EndTime:   now.Add(4 * time.Hour),
-					Available: false,
-				},
-			},
-			Comment:   "サンプルスケジュール1",
-			CreatedAt: now,
-			ExpiresAt: now.Add(7 * 24 * time.Hour),
-		},
-		{
-			ID:        "sample-schedule-2",
-			EditToken: "token-456",
-			TimeSlots: []model.TimeSlot{
-				{
-					StartTime: now.Add(24 * time.Hour),
-					EndTime:   now.Add(27 * time.Hour),
-					Available: true,
-				},
-			},
-			Comment:   "明日の予定",
-			CreatedAt: now,
-			ExpiresAt: now.Add(7 * 24 * time.Hour),
-		},
+// Validate は設定値が生成可能な範囲かを確認する
+func (o Options) Validate() error {
+	switch {
+	case o.Count < 0:
+		return errors.New("count must not be negative")
+	case o.Days <= 0:
+		return errors.New("days must be positive")
+	case o.Interval <= 0:
+		return errors.New("interval must be positive")
+	case o.Density < 0 || o.Density > 1:
+		return errors.New("density must be between 0 and 1")
+	case o.ExpiredRatio < 0 || o.ExpiredRatio > 1:
+		return errors.New("expired ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+var sampleComments = []string{
+	"打ち合わせ候補日です",
+	"来週の定例ミーティング",
+	"1on1の日程調整",
+	"採用面接の候補",
+	"プロジェクトキックオフ",
+	"飲み会の日程",
+	"レビュー会の候補日",
+	"",
+}
+
+// Generate は設定に従ってスケジュールを生成する
+func Generate(opts Options) ([]*model.Schedule, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	manager := model.NewTimeSlotManager()
+	const validity = 7 * 24 * time.Hour
+
+	schedules := make([]*model.Schedule, 0, opts.Count)
+	for i := 0; i < opts.Count; i++ {
+		createdAt := opts.Now.Add(-time.Duration(rng.Int63n(int64(24 * time.Hour))))
+		if rng.Float64() < opts.ExpiredRatio {
+			// 失効済みにするため、有効期間より前に作成されたことにする
+			createdAt = opts.Now.Add(-validity - time.Duration(rng.Int63n(int64(validity))) - time.Minute)
+		}
+
+		var slots []model.TimeSlot
+		for day := 0; day < opts.Days; day++ {
+			dayStart := opts.From.AddDate(0, 0, day)
+			candidates := manager.GenerateSlots(dayStart, dayStart.AddDate(0, 0, 1), opts.Interval)
+			if opts.Interval < 24*time.Hour {
+				candidates = manager.FilterBusinessHours(candidates)
+			}
+			for _, candidate := range candidates {
+				if rng.Float64() >= opts.Density {
+					continue
+				}
+				slots = append(slots, model.TimeSlot{
+					StartTime: candidate.StartTime,
+					EndTime:   candidate.EndTime,
+					Available: rng.Float64() < 0.7,
+				})
+			}
+		}
+
+		schedule := &model.Schedule{
+			ID:        generateUUID(rng),
+			EditToken: generateToken(rng),
+			TimeSlots: slots,
+			Comment:   sampleComments[rng.Intn(len(sampleComments))],
+			CreatedAt: createdAt,
+			ExpiresAt: createdAt.Add(validity),
+		}
+		if schedule.TimeSlots == nil {
+			schedule.TimeSlots = []model.TimeSlot{}
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+// SeedSchedules は設定に従ってスケジュールを生成し、保存先に投入する
+func SeedSchedules(ctx context.Context, dst Destination, opts Options) ([]*model.Schedule, error) {
+	schedules, err := Generate(opts)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, schedule := range schedules {
-		if err := repo.Create(ctx, schedule); err != nil {
-			return err
+		if err := dst.Create(ctx, schedule); err != nil {
+			return nil, fmt.Errorf("failed to seed schedule %s: %w", schedule.ID, err)
 		}
 	}
 
-	return nil
+	return schedules, nil
+}
+
+// generateUUID は乱数源からUUID v4形式のIDを生成する（シードを固定すれば再現可能）
+func generateUUID(rng *rand.Rand) string {
+	b := make([]byte, 16)
+	rng.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%12x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// generateToken は乱数源から編集トークンを生成する（シード投入専用で推測可能なため本番データには使わない）
+func generateToken(rng *rand.Rand) string {
+	b := make([]byte, 32)
+	rng.Read(b)
+	return fmt.Sprintf("%x", b)
 }
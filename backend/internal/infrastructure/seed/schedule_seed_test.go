@@ -0,0 +1,124 @@
+package seed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+type recordingDestination struct {
+	schedules []*model.Schedule
+}
+
+func (d *recordingDestination) Create(ctx context.Context, schedule *model.Schedule) error {
+	d.schedules = append(d.schedules, schedule)
+	return nil
+}
+
+func testOptions() Options {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	return Options{
+		Count:    20,
+		From:     time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC),
+		Days:     5,
+		Interval: time.Hour,
+		Density:  0.5,
+		Seed:     42,
+		Now:      now,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("同じシードからは同じデータが生成される", func(t *testing.T) {
+		first, err := Generate(testOptions())
+		require.NoError(t, err)
+		second, err := Generate(testOptions())
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+
+		opts := testOptions()
+		opts.Seed = 43
+		other, err := Generate(opts)
+		require.NoError(t, err)
+		assert.NotEqual(t, first[0].ID, other[0].ID)
+	})
+
+	t.Run("指定した件数の有効なスケジュールが生成される", func(t *testing.T) {
+		opts := testOptions()
+		schedules, err := Generate(opts)
+		require.NoError(t, err)
+		require.Len(t, schedules, opts.Count)
+
+		end := opts.From.AddDate(0, 0, opts.Days)
+		for _, schedule := range schedules {
+			assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, schedule.ID)
+			assert.Len(t, schedule.EditToken, 64)
+			assert.NoError(t, schedule.ValidateTimeSlots())
+			assert.True(t, opts.Now.Before(schedule.ExpiresAt))
+			for _, slot := range schedule.TimeSlots {
+				assert.False(t, slot.StartTime.Before(opts.From))
+				assert.False(t, slot.EndTime.After(end))
+				assert.GreaterOrEqual(t, slot.StartTime.Hour(), 9)
+				assert.LessOrEqual(t, slot.EndTime.Hour(), 18)
+			}
+		}
+	})
+
+	t.Run("密度0ではタイムスロットが生成されない", func(t *testing.T) {
+		opts := testOptions()
+		opts.Density = 0
+		schedules, err := Generate(opts)
+		require.NoError(t, err)
+		for _, schedule := range schedules {
+			assert.Empty(t, schedule.TimeSlots)
+		}
+	})
+
+	t.Run("失効済みの割合を指定できる", func(t *testing.T) {
+		opts := testOptions()
+		opts.Count = 200
+		opts.ExpiredRatio = 0.25
+
+		schedules, err := Generate(opts)
+		require.NoError(t, err)
+
+		expired := 0
+		for _, schedule := range schedules {
+			if !opts.Now.Before(schedule.ExpiresAt) {
+				expired++
+			}
+		}
+		assert.InDelta(t, 50, expired, 20)
+
+		opts.ExpiredRatio = 1
+		schedules, err = Generate(opts)
+		require.NoError(t, err)
+		for _, schedule := range schedules {
+			assert.True(t, schedule.ExpiresAt.Before(opts.Now))
+		}
+	})
+
+	t.Run("不正な設定はエラーになる", func(t *testing.T) {
+		opts := testOptions()
+		opts.Density = 1.5
+		_, err := Generate(opts)
+		assert.Error(t, err)
+
+		opts = testOptions()
+		opts.Days = 0
+		_, err = Generate(opts)
+		assert.Error(t, err)
+	})
+}
+
+func TestSeedSchedules(t *testing.T) {
+	dst := &recordingDestination{}
+	schedules, err := SeedSchedules(context.Background(), dst, testOptions())
+	require.NoError(t, err)
+	assert.Equal(t, schedules, dst.schedules)
+}
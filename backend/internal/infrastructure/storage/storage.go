@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/infrastructure/firestore"
+	"kareru-backend/internal/infrastructure/redis"
+	"kareru-backend/internal/infrastructure/repository"
+)
+
+// 利用可能なストレージバックエンド
+const (
+	BackendMemory    = "memory"
+	BackendFirestore = "firestore"
+	BackendRedis     = "redis"
+)
+
+// Store は管理コマンドから各バックエンドを共通に扱うためのインターフェース
+type Store interface {
+	List(ctx context.Context) ([]*model.Schedule, error)
+	Create(ctx context.Context, schedule *model.Schedule) error
+	Close() error
+}
+
+// Open は指定されたバックエンドに接続する
+// 接続先は各クライアントと同じ環境変数（FIRESTORE_*, REDIS_*）で設定する
+func Open(ctx context.Context, backend string) (Store, error) {
+	switch backend {
+	case BackendMemory:
+		return &repositoryStore{repo: repository.NewMemoryScheduleRepository()}, nil
+	case BackendFirestore:
+		client, err := firestore.NewClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &firestoreStore{
+			ScheduleRepository: repository.NewScheduleRepository(client),
+			client:             client,
+		}, nil
+	case BackendRedis:
+		client, err := redis.NewClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &repositoryStore{
+			repo:   repository.NewRedisScheduleRepository(client),
+			closer: client.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %q", backend)
+	}
+}
+
+type firestoreStore struct {
+	*repository.ScheduleRepository
+	client *firestore.Client
+}
+
+func (s *firestoreStore) Close() error {
+	return s.client.Close()
+}
+
+// contextFreeRepository はコンテキストを受け取らないリポジトリ（メモリ・Redis）
+type contextFreeRepository interface {
+	List() ([]*model.Schedule, error)
+	Create(schedule *model.Schedule) error
+}
+
+type repositoryStore struct {
+	repo   contextFreeRepository
+	closer func() error
+}
+
+func (s *repositoryStore) List(ctx context.Context) ([]*model.Schedule, error) {
+	return s.repo.List()
+}
+
+func (s *repositoryStore) Create(ctx context.Context, schedule *model.Schedule) error {
+	return s.repo.Create(schedule)
+}
+
+func (s *repositoryStore) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer()
+}
@@ -0,0 +1,139 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kareru-backend/internal/handlers"
+)
+
+// Policy は失敗回数に応じたロック時間の決め方
+// MaxAttempts 回連続で失敗するとBaseDelayだけロックし、その後は失敗するたびにロック時間を倍にする
+type Policy struct {
+	// MaxAttempts はロックされるまでに許容する連続失敗回数
+	MaxAttempts int
+	// BaseDelay は最初のロック時間
+	BaseDelay time.Duration
+	// MaxDelay はロック時間の上限
+	MaxDelay time.Duration
+	// ResetAfter は最後の失敗から失敗回数を忘れるまでの時間（MaxDelay以上にする）
+	ResetAfter time.Duration
+}
+
+// DefaultPolicy はデフォルトのPolicyを返す
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		ResetAfter:  24 * time.Hour,
+	}
+}
+
+// Delay は連続失敗回数に対するロック時間を返す（0はロックしない）
+func (p Policy) Delay(failures int) time.Duration {
+	if failures < p.MaxAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.MaxAttempts; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+type entry struct {
+	failures      int
+	lastFailureAt time.Time
+	lockedUntil   time.Time
+}
+
+// MemoryTracker はプロセス内で失敗回数を保持するEditLockout
+// 複数インスタンスで動かす場合は、インスタンスを渡り歩く総当たりを防ぐためRedisTrackerを使う
+type MemoryTracker struct {
+	mu            sync.Mutex
+	policy        Policy
+	entries       map[string]*entry
+	now           func() time.Time
+	cleanupEvery  time.Duration
+	lastCleanupAt time.Time
+}
+
+var _ handlers.EditLockout = (*MemoryTracker)(nil)
+
+func NewMemoryTracker(policy Policy) *MemoryTracker {
+	return &MemoryTracker{
+		policy:       policy,
+		entries:      make(map[string]*entry),
+		now:          time.Now,
+		cleanupEvery: time.Minute,
+	}
+}
+
+func (t *MemoryTracker) Status(ctx context.Context, key string) (handlers.EditLockoutStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.cleanup(now)
+	e, ok := t.entries[key]
+	if !ok {
+		return handlers.EditLockoutStatus{}, nil
+	}
+	return e.status(now), nil
+}
+
+func (t *MemoryTracker) RecordFailure(ctx context.Context, key string) (handlers.EditLockoutStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.cleanup(now)
+	e, ok := t.entries[key]
+	if !ok {
+		e = &entry{}
+		t.entries[key] = e
+	}
+	e.failures++
+	e.lastFailureAt = now
+	if delay := t.policy.Delay(e.failures); delay > 0 {
+		e.lockedUntil = now.Add(delay)
+	}
+	return e.status(now), nil
+}
+
+func (t *MemoryTracker) Reset(ctx context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+	return nil
+}
+
+func (e *entry) status(now time.Time) handlers.EditLockoutStatus {
+	status := handlers.EditLockoutStatus{Failures: e.failures}
+	if now.Before(e.lockedUntil) {
+		status.RetryAfter = e.lockedUntil.Sub(now)
+	}
+	return status
+}
+
+// cleanup は最後の失敗からResetAfterが経過し、ロックも解けた記録を削除する
+func (t *MemoryTracker) cleanup(now time.Time) {
+	if now.Sub(t.lastCleanupAt) < t.cleanupEvery {
+		return
+	}
+	t.lastCleanupAt = now
+	for id, e := range t.entries {
+		if now.Sub(e.lastFailureAt) >= t.policy.ResetAfter && !now.Before(e.lockedUntil) {
+			delete(t.entries, id)
+		}
+	}
+}
+
+// Len は保持している記録の数を返す
+func (t *MemoryTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.entries)
+}
@@ -0,0 +1,169 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/handlers"
+)
+
+// fakeClock はテスト用の時計
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time          { return f.now }
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+var testPolicy = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Minute,
+	MaxDelay:    10 * time.Minute,
+	ResetAfter:  time.Hour,
+}
+
+func TestPolicyDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{5, 4 * time.Minute},
+		{6, 8 * time.Minute},
+		{7, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, testPolicy.Delay(tt.failures), "failures=%d", tt.failures)
+	}
+}
+
+// trackerTests は全てのEditLockout実装で共通の振る舞いを確認する
+func trackerTests(t *testing.T, newTracker func(clock *fakeClock) handlers.EditLockout) {
+	ctx := context.Background()
+
+	t.Run("連続した失敗が上限に達するとロックし、失敗のたびにロック時間が倍になる", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker := newTracker(clock)
+
+		for i := 1; i <= 2; i++ {
+			status, err := tracker.RecordFailure(ctx, "schedule-1")
+			require.NoError(t, err)
+			assert.Equal(t, i, status.Failures)
+			assert.False(t, status.Locked())
+		}
+
+		status, err := tracker.RecordFailure(ctx, "schedule-1")
+		require.NoError(t, err)
+		assert.True(t, status.Locked())
+		assert.Equal(t, time.Minute, status.RetryAfter)
+
+		clock.Advance(20 * time.Second)
+		status, err = tracker.Status(ctx, "schedule-1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, status.Failures)
+		assert.Equal(t, 40*time.Second, status.RetryAfter)
+
+		clock.Advance(time.Minute)
+		status, err = tracker.Status(ctx, "schedule-1")
+		require.NoError(t, err)
+		assert.False(t, status.Locked())
+
+		status, err = tracker.RecordFailure(ctx, "schedule-1")
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, status.RetryAfter)
+	})
+
+	t.Run("スケジュールごとに独立して記録する", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker := newTracker(clock)
+
+		for i := 0; i < 3; i++ {
+			_, err := tracker.RecordFailure(ctx, "schedule-1")
+			require.NoError(t, err)
+		}
+		status, err := tracker.Status(ctx, "schedule-2")
+		require.NoError(t, err)
+		assert.Equal(t, handlers.EditLockoutStatus{}, status)
+	})
+
+	t.Run("リセットすると失敗回数が消える", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker := newTracker(clock)
+
+		_, err := tracker.RecordFailure(ctx, "schedule-1")
+		require.NoError(t, err)
+		require.NoError(t, tracker.Reset(ctx, "schedule-1"))
+
+		status, err := tracker.Status(ctx, "schedule-1")
+		require.NoError(t, err)
+		assert.Equal(t, 0, status.Failures)
+	})
+}
+
+func TestMemoryTracker(t *testing.T) {
+	trackerTests(t, func(clock *fakeClock) handlers.EditLockout {
+		tracker := NewMemoryTracker(testPolicy)
+		tracker.now = clock.Now
+		return tracker
+	})
+
+	t.Run("最後の失敗からResetAfterが経過した記録は削除される", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker := NewMemoryTracker(testPolicy)
+		tracker.now = clock.Now
+
+		_, err := tracker.RecordFailure(context.Background(), "schedule-1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, tracker.Len())
+
+		clock.Advance(2 * time.Hour)
+		status, err := tracker.Status(context.Background(), "schedule-1")
+		require.NoError(t, err)
+		assert.Equal(t, 0, status.Failures)
+		assert.Equal(t, 0, tracker.Len())
+	})
+}
+
+func TestRedisTracker(t *testing.T) {
+	trackerTests(t, func(clock *fakeClock) handlers.EditLockout {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		tracker := NewRedisTracker(client, testPolicy)
+		tracker.now = clock.Now
+		return tracker
+	})
+
+	t.Run("記録には最後の失敗からResetAfterのTTLが設定される", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		defer client.Close()
+
+		_, err := NewRedisTracker(client, testPolicy).RecordFailure(context.Background(), "schedule-1")
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, mr.TTL("kareru:lockout:schedule-1"))
+	})
+
+	t.Run("Redisに接続できない場合はエラーを返す", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		defer client.Close()
+		mr.Close()
+
+		_, err := NewRedisTracker(client, testPolicy).Status(context.Background(), "schedule-1")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,100 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"kareru-backend/internal/handlers"
+)
+
+// RedisTracker は複数インスタンスで失敗回数を共有するEditLockout
+// 記録はハッシュ（failures, locked_until）に保存し、最後の失敗からResetAfterで自動的に削除させる
+type RedisTracker struct {
+	client goredis.UniversalClient
+	policy Policy
+	prefix string
+	now    func() time.Time
+}
+
+var _ handlers.EditLockout = (*RedisTracker)(nil)
+
+func NewRedisTracker(client goredis.UniversalClient, policy Policy) *RedisTracker {
+	return &RedisTracker{
+		client: client,
+		policy: policy,
+		prefix: "kareru:lockout:",
+		now:    time.Now,
+	}
+}
+
+func (t *RedisTracker) Status(ctx context.Context, key string) (handlers.EditLockoutStatus, error) {
+	values, err := t.client.HMGet(ctx, t.prefix+key, "failures", "locked_until").Result()
+	if err != nil {
+		return handlers.EditLockoutStatus{}, fmt.Errorf("lockout: %w", err)
+	}
+	failures, err := parseField(values[0])
+	if err != nil {
+		return handlers.EditLockoutStatus{}, err
+	}
+	lockedUntil, err := parseField(values[1])
+	if err != nil {
+		return handlers.EditLockoutStatus{}, err
+	}
+	return t.status(int(failures), lockedUntil), nil
+}
+
+func (t *RedisTracker) RecordFailure(ctx context.Context, key string) (handlers.EditLockoutStatus, error) {
+	redisKey := t.prefix + key
+
+	// ResetAfterはMaxDelay以上のため、TTLはロック中に切れることはない
+	var incr *goredis.IntCmd
+	if _, err := t.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		incr = pipe.HIncrBy(ctx, redisKey, "failures", 1)
+		pipe.PExpire(ctx, redisKey, t.policy.ResetAfter)
+		return nil
+	}); err != nil {
+		return handlers.EditLockoutStatus{}, fmt.Errorf("lockout: %w", err)
+	}
+
+	failures := int(incr.Val())
+	var lockedUntil int64
+	if delay := t.policy.Delay(failures); delay > 0 {
+		lockedUntil = t.now().Add(delay).UnixMilli()
+		if err := t.client.HSet(ctx, redisKey, "locked_until", lockedUntil).Err(); err != nil {
+			return handlers.EditLockoutStatus{}, fmt.Errorf("lockout: %w", err)
+		}
+	}
+	return t.status(failures, lockedUntil), nil
+}
+
+func (t *RedisTracker) Reset(ctx context.Context, key string) error {
+	if err := t.client.Del(ctx, t.prefix+key).Err(); err != nil {
+		return fmt.Errorf("lockout: %w", err)
+	}
+	return nil
+}
+
+// status はロック解除時刻（Unixミリ秒）から現在の状況を作る
+func (t *RedisTracker) status(failures int, lockedUntil int64) handlers.EditLockoutStatus {
+	status := handlers.EditLockoutStatus{Failures: failures}
+	if remaining := time.UnixMilli(lockedUntil).Sub(t.now()); lockedUntil > 0 && remaining > 0 {
+		status.RetryAfter = remaining
+	}
+	return status
+}
+
+// parseField はHMGETの値を整数に変換する（存在しないフィールドは0）
+func parseField(value interface{}) (int64, error) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("lockout: invalid value %q: %w", s, err)
+	}
+	return n, nil
+}
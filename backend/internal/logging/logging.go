@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted は秘匿情報を置き換える文字列
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys は値を出力しない属性名・JSONキー（小文字で比較する）
+var sensitiveKeys = map[string]bool{
+	"edittoken":     true,
+	"edit_token":    true,
+	"token":         true,
+	"password":      true,
+	"authorization": true,
+	"x-edit-token":  true,
+	"viewpassword":  true,
+	"viewtoken":     true,
+	"x-view-token":  true,
+}
+
+// tokenPathPattern はパス中の編集トークン（/edit/<token>）に一致する
+// ルートテンプレートの /edit/:token は対象外
+var tokenPathPattern = regexp.MustCompile(`(/edit/)[^/?#\s":][^/?#\s"]*`)
+
+// tokenQueryPattern はクエリ文字列中のトークン（token=, editToken=）に一致する
+var tokenQueryPattern = regexp.MustCompile(`(?i)((?:edit_?)?token=)[^&#\s"]+`)
+
+// jsonTokenPattern は（途中で切れたものを含む）JSON文字列中の秘匿すべきキーの値に一致する
+var jsonTokenPattern = regexp.MustCompile(`(?i)("(?:(?:edit_?|view_?)?token|(?:view_?)?password)"\s*:\s*")[^"]*`)
+
+// IsSensitiveKey はキーが秘匿すべき値を表すかを返す
+func IsSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// RedactString は文字列中のパスやクエリに含まれる編集トークンを伏せる
+func RedactString(s string) string {
+	s = tokenPathPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = tokenQueryPattern.ReplaceAllString(s, "${1}"+Redacted)
+	return jsonTokenPattern.ReplaceAllString(s, "${1}"+Redacted)
+}
+
+// New はJSON形式で出力するロガーを作成する
+// 秘匿すべきキーの値と、文字列中の編集トークンは自動的に伏せられ、
+// コンテキストにリクエストIDやトレースがあればそれぞれ request_id, trace_id として出力される
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	})
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// ParseLevel は設定のログレベルをslog.Levelに変換する
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if IsSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	if attr.Value.Kind() == slog.KindString {
+		return slog.String(attr.Key, RedactString(attr.Value.String()))
+	}
+	return attr
+}
+
+// contextHandler はコンテキストからリクエストIDとトレースIDを取り出してログに付与する
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,213 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// logLines は出力されたJSONログを1行ずつ読み込む
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func newTestRouter(logger *slog.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Middleware(logger), Recovery(logger))
+	return router
+}
+
+func TestRedactString(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"パス中の編集トークン", "/api/v1/schedules/edit/abc123def", "/api/v1/schedules/edit/[REDACTED]"},
+		{"後続のパスやクエリは残す", "/api/v1/schedules/edit/abc123/extra?x=1", "/api/v1/schedules/edit/[REDACTED]/extra?x=1"},
+		{"ルートテンプレートは伏せない", "/api/v1/schedules/edit/:token", "/api/v1/schedules/edit/:token"},
+		{"クエリ中のトークン", "/s/abc?editToken=secret&lang=ja", "/s/abc?editToken=[REDACTED]&lang=ja"},
+		{"JSON中のトークン", `{"editToken":"secret","comment":"hi"}`, `{"editToken":"[REDACTED]","comment":"hi"}`},
+		{"JSON中の閲覧パスワード", `{"viewPassword":"secret","comment":"hi"}`, `{"viewPassword":"[REDACTED]","comment":"hi"}`},
+		{"JSON中の閲覧トークン", `{"viewToken":"secret","expiresAt":"x"}`, `{"viewToken":"[REDACTED]","expiresAt":"x"}`},
+		{"トークンを含まない文字列", "/api/v1/schedules/uuid-1", "/api/v1/schedules/uuid-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RedactString(tt.input))
+		})
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	body := RedactBody([]byte(`{"editToken":"secret","comment":"hello","nested":{"password":"pw"},"slots":[{"token":"t"}]}`))
+
+	assert.NotContains(t, body, "secret")
+	assert.NotContains(t, body, `"pw"`)
+	assert.NotContains(t, body, `"t"`)
+	assert.Contains(t, body, `"comment":"hello"`)
+
+	// 途中で切れたJSONも伏せる
+	assert.Equal(t, `{"comment":"a","editToken":"[REDACTED]`, RedactBody([]byte(`{"comment":"a","editToken":"secr`)))
+}
+
+func TestNew(t *testing.T) {
+	t.Run("秘匿すべきキーと文字列中のトークンを伏せる", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(&buf, slog.LevelInfo)
+		logger.Info("updated", "editToken", "secret", "path", "/api/v1/schedules/edit/secret")
+
+		entry := logLines(t, &buf)[0]
+		assert.Equal(t, Redacted, entry["editToken"])
+		assert.Equal(t, "/api/v1/schedules/edit/[REDACTED]", entry["path"])
+		assert.NotContains(t, buf.String(), "secret")
+	})
+
+	t.Run("コンテキストのリクエストIDとトレースIDを付与する", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(&buf, slog.LevelInfo)
+
+		provider := sdktrace.NewTracerProvider()
+		ctx, span := provider.Tracer("test").Start(WithRequestID(context.Background(), "req-1"), "span")
+		defer span.End()
+		logger.InfoContext(ctx, "hello")
+
+		entry := logLines(t, &buf)[0]
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Equal(t, span.SpanContext().TraceID().String(), entry["trace_id"])
+	})
+
+	t.Run("レベル未満のログは出力しない", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(&buf, ParseLevel("warn"))
+		logger.Info("ignored")
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	router := newTestRouter(New(&bytes.Buffer{}, slog.LevelInfo))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, RequestIDFromContext(c.Request.Context()))
+	})
+
+	t.Run("X-Request-IDを引き継ぐ", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(RequestIDHeader, "upstream-id-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "upstream-id-123", w.Body.String())
+		assert.Equal(t, "upstream-id-123", w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("ヘッダーがない場合は新しく発行する", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+		assert.Len(t, w.Body.String(), 32)
+		assert.Equal(t, w.Body.String(), w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("不正な文字を含むIDは採用しない", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(RequestIDHeader, "bad id\n{\"injected\":true}")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Len(t, w.Body.String(), 32)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("ルートテンプレートで記録し、パスの編集トークンを伏せる", func(t *testing.T) {
+		var buf bytes.Buffer
+		router := newTestRouter(New(&buf, slog.LevelInfo))
+		router.GET("/api/v1/schedules/edit/:token", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/schedules/edit/secret-token", nil)
+		req.Header.Set(RequestIDHeader, "req-42")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		entry := logLines(t, &buf)[0]
+		assert.Equal(t, "request", entry["msg"])
+		assert.Equal(t, "/api/v1/schedules/edit/:token", entry["route"])
+		assert.Equal(t, "/api/v1/schedules/edit/[REDACTED]", entry["path"])
+		assert.Equal(t, float64(http.StatusOK), entry["status"])
+		assert.Equal(t, "req-42", entry["request_id"])
+		assert.NotContains(t, buf.String(), "secret-token")
+	})
+
+	t.Run("ハンドラーのエラーをリクエストIDとともに出力する", func(t *testing.T) {
+		var buf bytes.Buffer
+		router := newTestRouter(New(&buf, slog.LevelInfo))
+		router.GET("/fail", func(c *gin.Context) {
+			c.Error(errors.New("firestore: unavailable"))
+			c.Status(http.StatusInternalServerError)
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+		entry := logLines(t, &buf)[0]
+		assert.Equal(t, "ERROR", entry["level"])
+		assert.Contains(t, entry["error"], "firestore: unavailable")
+		assert.NotEmpty(t, entry["request_id"])
+	})
+
+	t.Run("debugレベルではボディを伏せて出力し、ハンドラーも読める", func(t *testing.T) {
+		var buf bytes.Buffer
+		router := newTestRouter(New(&buf, slog.LevelDebug))
+		var received map[string]string
+		router.PUT("/schedules/:uuid", func(c *gin.Context) {
+			require.NoError(t, c.ShouldBindJSON(&received))
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/schedules/uuid-1", strings.NewReader(`{"editToken":"secret","comment":"hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, "secret", received["editToken"])
+		entry := logLines(t, &buf)[0]
+		assert.Contains(t, entry["body"], `"comment":"hi"`)
+		assert.NotContains(t, buf.String(), "secret")
+	})
+
+	t.Run("パニックを回復して500を返す", func(t *testing.T) {
+		var buf bytes.Buffer
+		router := newTestRouter(New(&buf, slog.LevelInfo))
+		router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), w.Header().Get(RequestIDHeader))
+		lines := logLines(t, &buf)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "panic recovered", lines[0]["msg"])
+		assert.Equal(t, "boom", lines[0]["panic"])
+	})
+}
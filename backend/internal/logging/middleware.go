@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLoggedBodyBytes はdebugレベルで出力するリクエストボディの上限
+const maxLoggedBodyBytes = 4 << 10
+
+// Middleware はリクエストごとにアクセスログを出力するginミドルウェア（gin.Loggerの置き換え）
+// パスはルートテンプレート（/api/v1/schedules/edit/:token）で記録し、生のパスは編集トークンを伏せて記録する
+// ハンドラーがc.Errorで登録したエラーは同じログ行に出力される
+func Middleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		var body []byte
+		if logger.Enabled(c.Request.Context(), slog.LevelDebug) && c.Request.Body != nil {
+			body = peekBody(c.Request)
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.RequestURI()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		if len(body) > 0 {
+			attrs = append(attrs, slog.String("body", RedactBody(body)))
+		}
+
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery はパニックを回復して500を返し、スタックトレースをリクエストIDとともにログに出力する
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logger.ErrorContext(c.Request.Context(), "panic recovered",
+			slog.String("panic", fmt.Sprint(recovered)),
+			slog.String("stack", string(debug.Stack())),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal server error",
+			"requestId": RequestIDFromContext(c.Request.Context()),
+		})
+	})
+}
+
+// peekBody はハンドラーが読めるようにボディを戻しつつ、先頭を読み取る
+func peekBody(r *http.Request) []byte {
+	head, err := io.ReadAll(io.LimitReader(r.Body, maxLoggedBodyBytes))
+	if err != nil {
+		return nil
+	}
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), r.Body), Closer: r.Body}
+	return head
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// RedactBody はJSONボディ中の秘匿すべきキーの値を伏せる
+// JSONとして解釈できない場合は文字列として編集トークンを伏せる
+func RedactBody(body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return RedactString(string(body))
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return Redacted
+	}
+	return string(redacted)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if IsSensitiveKey(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	case string:
+		return RedactString(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader はリクエストIDを受け渡すヘッダー
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength を超える、または使えない文字を含むリクエストIDは採用せずに新しく発行する
+// （ログへの注入や肥大化を防ぐ）
+const maxRequestIDLength = 128
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+type requestIDKey struct{}
+
+// WithRequestID はリクエストIDを持つコンテキストを返す
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext はコンテキストのリクエストIDを返す（なければ空文字）
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestID はリクエストIDを決定してコンテキストとレスポンスヘッダーに設定するginミドルウェア
+// 上流（ロードバランサーなど）が付与したX-Request-IDがあればそれを引き継ぐ
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if len(requestID) > maxRequestIDLength || !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
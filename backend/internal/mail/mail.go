@@ -0,0 +1,205 @@
+// Package mail はスケジュールの作成者に共有URLと編集URL、失効前のリマインダーをメールで送る
+//
+// リマインダーのメールはreminder.Scannerが送信を記録できたスケジュールにだけ送るため、
+// 複数のインスタンスで動かしても作成者に重複して届かない
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// SMTPサーバーとの接続の暗号化方式
+const (
+	// TLSStartTLS は平文で接続してからSTARTTLSで暗号化する（サーバーが対応していない場合は送信しない）
+	TLSStartTLS = "starttls"
+	// TLSImplicit は最初からTLSで接続する（SMTPS、通常は465番ポート）
+	TLSImplicit = "tls"
+	// TLSNone は暗号化しない（同じホストのリレーや開発用のサーバーに送る場合だけ使う）
+	TLSNone = "none"
+)
+
+// ErrInvalidMessage は宛先や件名がメールのヘッダーに書けない場合に返される
+var ErrInvalidMessage = errors.New("mail: invalid message")
+
+// Message は1通のテキストメール
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer はメールを送信する
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPConfig はSMTPサーバーの設定
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username が空の場合は認証しない
+	Username string
+	Password string
+	// From は差出人（"Kareru <noreply@example.com>" のように表示名を含められる）
+	From string
+	// TLS は TLSStartTLS, TLSImplicit, TLSNone のいずれか（空の場合はTLSStartTLS）
+	TLS string
+	// Timeout は接続から送信完了までのタイムアウト
+	Timeout time.Duration
+}
+
+// SMTPMailer はSMTPサーバーを経由してメールを送信する
+type SMTPMailer struct {
+	config SMTPConfig
+	from   *netmail.Address
+	// tlsConfig はテストで自己署名の証明書を信頼するために差し替える
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+// NewSMTPMailer は新しいSMTPMailerを作成する
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	from, err := netmail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid from address %q: %w", config.From, err)
+	}
+	switch config.TLS {
+	case "":
+		config.TLS = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("mail: unknown TLS mode %q", config.TLS)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &SMTPMailer{
+		config: config,
+		from:   from,
+		tlsConfig: &tls.Config{
+			ServerName: config.Host,
+			MinVersion: tls.VersionTLS12,
+		},
+		now: time.Now,
+	}, nil
+}
+
+// Send はメールを1通送信する
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := model.ValidateEmail(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("%w: subject must be a single line", ErrInvalidMessage)
+	}
+	data, err := m.build(to, msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port)))
+	if err != nil {
+		return err
+	}
+	if m.config.TLS == TLSImplicit {
+		conn = tls.Client(conn, m.tlsConfig)
+	}
+	// net/smtpはcontextを受け取らないため、期限と中断は接続に設定する
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if m.config.TLS == TLSStartTLS {
+		// 認証情報や編集URLを平文で送らないよう、STARTTLSに対応していないサーバーには送信しない
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("mail: server does not support STARTTLS")
+		}
+		if err := client.StartTLS(m.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(m.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// build はヘッダーと本文をRFC 5322の形式にする
+// 件名はMIMEエンコードし、本文はUTF-8のquoted-printableにする
+func (m *SMTPMailer) build(to string, msg Message) ([]byte, error) {
+	messageID, err := model.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	domain := m.from.Address[strings.LastIndex(m.from.Address, "@")+1:]
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", m.from.String())
+	header("To", to)
+	header("Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	header("Date", m.now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+messageID+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
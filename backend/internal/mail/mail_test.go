@@ -0,0 +1,380 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+// received はSMTPサーバーの代わりが受け取ったメール
+type received struct {
+	Auth string
+	From string
+	To   []string
+	Data string
+}
+
+// smtpServer はテスト用のSMTPサーバーの代わり（STARTTLSには対応しない）
+// rcptReplies に応答を入れておくと、RCPT TOにその応答を順に返す（空になったら250）
+type smtpServer struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	rcptReplies []string
+	messages    []received
+	wg          sync.WaitGroup
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpServer{listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+func (s *smtpServer) config() SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := net.LookupPort("tcp", port)
+	return SMTPConfig{Host: host, Port: p, From: "Kareru <noreply@kareru.example.com>", TLS: TLSNone, Timeout: 5 * time.Second}
+}
+
+func (s *smtpServer) received() []received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]received(nil), s.messages...)
+}
+
+func (s *smtpServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(textproto.NewConn(conn))
+		}()
+	}
+}
+
+func (s *smtpServer) handle(conn *textproto.Conn) {
+	var msg received
+	conn.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			conn.PrintfLine("250-localhost")
+			conn.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			msg.Auth = arg
+			conn.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			msg.From = strings.TrimPrefix(arg, "FROM:")
+			conn.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			reply := "250 OK"
+			if len(s.rcptReplies) > 0 {
+				reply, s.rcptReplies = s.rcptReplies[0], s.rcptReplies[1:]
+			}
+			s.mu.Unlock()
+			if strings.HasPrefix(reply, "250") {
+				msg.To = append(msg.To, strings.TrimPrefix(arg, "TO:"))
+			}
+			conn.PrintfLine("%s", reply)
+		case "DATA":
+			conn.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(conn.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = received{}
+			conn.PrintfLine("250 OK: queued")
+		case "RSET":
+			msg = received{}
+			conn.PrintfLine("250 OK")
+		case "QUIT":
+			conn.PrintfLine("221 Bye")
+			return
+		default:
+			conn.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// parseMessage は受け取ったメールの件名と本文を復号する
+func parseMessage(t *testing.T, data string) (*netmail.Message, string, string) {
+	t.Helper()
+	msg, err := netmail.ReadMessage(bufio.NewReader(strings.NewReader(data)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	return msg, subject, string(body)
+}
+
+func TestSMTPMailer(t *testing.T) {
+	t.Run("件名をMIMEエンコードし、本文をquoted-printableで送る", func(t *testing.T) {
+		server := newSMTPServer(t)
+		config := server.config()
+		config.Username = "kareru"
+		config.Password = "secret"
+		mailer, err := NewSMTPMailer(config)
+		require.NoError(t, err)
+
+		body := "共有用URL\nhttps://kareru.example.com/schedule/" + strings.Repeat("a", 80) + "\n"
+		require.NoError(t, mailer.Send(context.Background(), Message{To: "taro@example.com", Subject: "【Kareru】作成しました", Body: body}))
+
+		messages := server.received()
+		require.Len(t, messages, 1)
+		assert.Equal(t, "<noreply@kareru.example.com>", messages[0].From)
+		assert.Equal(t, []string{"<taro@example.com>"}, messages[0].To)
+		auth, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(messages[0].Auth, "PLAIN "))
+		require.NoError(t, err)
+		assert.Equal(t, "\x00kareru\x00secret", string(auth))
+
+		msg, subject, decoded := parseMessage(t, messages[0].Data)
+		assert.Equal(t, "【Kareru】作成しました", subject)
+		assert.Equal(t, `"Kareru" <noreply@kareru.example.com>`, msg.Header.Get("From"))
+		assert.Equal(t, "taro@example.com", msg.Header.Get("To"))
+		assert.Contains(t, msg.Header.Get("Message-ID"), "@kareru.example.com>")
+		assert.Equal(t, "text/plain; charset=UTF-8", msg.Header.Get("Content-Type"))
+		// 長い行も折り返されずに復元される
+		assert.Equal(t, body, decoded)
+	})
+
+	t.Run("STARTTLSに対応していないサーバーには送らない", func(t *testing.T) {
+		server := newSMTPServer(t)
+		config := server.config()
+		config.TLS = TLSStartTLS
+		mailer, err := NewSMTPMailer(config)
+		require.NoError(t, err)
+
+		err = mailer.Send(context.Background(), Message{To: "taro@example.com", Subject: "test", Body: "test"})
+		assert.ErrorContains(t, err, "STARTTLS")
+		assert.Empty(t, server.received())
+	})
+
+	t.Run("ヘッダーに書けない宛先と件名は送らない", func(t *testing.T) {
+		server := newSMTPServer(t)
+		mailer, err := NewSMTPMailer(server.config())
+		require.NoError(t, err)
+
+		for _, msg := range []Message{
+			{To: "taro@example.com\r\nBcc: victim@example.com", Subject: "test"},
+			{To: "Taro <taro@example.com>", Subject: "test"},
+			{To: "taro@example.com", Subject: "test\r\nBcc: victim@example.com"},
+		} {
+			assert.ErrorIs(t, mailer.Send(context.Background(), msg), ErrInvalidMessage)
+		}
+		assert.Empty(t, server.received())
+	})
+
+	t.Run("不正な設定はエラーになる", func(t *testing.T) {
+		_, err := NewSMTPMailer(SMTPConfig{Host: "localhost", Port: 25, From: "not an address"})
+		assert.Error(t, err)
+		_, err = NewSMTPMailer(SMTPConfig{Host: "localhost", Port: 25, From: "noreply@example.com", TLS: "ssl"})
+		assert.Error(t, err)
+	})
+}
+
+func TestRender(t *testing.T) {
+	data := TemplateData{
+		ShareURL:  "https://kareru.example.com/schedule/id",
+		EditURL:   "https://kareru.example.com/edit/token",
+		ExpiresAt: "2026年10月26日 12:00（JST）",
+		TimeSlots: 3,
+	}
+	for _, kind := range []string{KindCreated, KindExpiringSoon} {
+		for _, locale := range []string{model.LocaleJapanese, model.LocaleEnglish} {
+			subject, body, err := Render(kind, locale, data)
+			require.NoError(t, err, kind+"."+locale)
+			assert.NotEmpty(t, subject)
+			assert.NotContains(t, subject, "\n")
+			assert.Contains(t, body, data.ShareURL)
+			assert.Contains(t, body, data.EditURL)
+			assert.Contains(t, body, data.ExpiresAt)
+		}
+	}
+
+	ja, _, err := Render(KindCreated, model.LocaleJapanese, data)
+	require.NoError(t, err)
+	fallback, _, err := Render(KindCreated, "fr", data)
+	require.NoError(t, err)
+	assert.Equal(t, ja, fallback)
+
+	_, _, err = Render("unknown", model.LocaleJapanese, data)
+	assert.Error(t, err)
+
+	// リマインダーを送る場合だけ、作成時のメールで予告する
+	for locale, notice := range map[string]string{
+		model.LocaleJapanese: "失効が近づいたら",
+		model.LocaleEnglish:  "about to expire",
+	} {
+		_, body, err := Render(KindCreated, locale, data)
+		require.NoError(t, err)
+		assert.NotContains(t, body, notice, locale)
+
+		withReminders := data
+		withReminders.Reminders = true
+		_, body, err = Render(KindCreated, locale, withReminders)
+		require.NoError(t, err)
+		assert.Contains(t, body, notice, locale)
+	}
+
+	// 日時には指定したタイムゾーンの略称を添える
+	expiresAt := time.Date(2026, 10, 26, 3, 0, 0, 0, time.UTC)
+	jst := time.FixedZone("JST", 9*60*60)
+	assert.Equal(t, "2026年10月26日 12:00（JST）", FormatTime(expiresAt, model.LocaleJapanese, jst))
+	assert.Equal(t, "Oct 26, 2026 12:00 (JST)", FormatTime(expiresAt, model.LocaleEnglish, jst))
+	assert.Equal(t, "Oct 26, 2026 03:00 (UTC)", FormatTime(expiresAt, model.LocaleEnglish, time.UTC))
+}
+
+func TestNotifier(t *testing.T) {
+	links := Links{
+		Share: func(schedule *model.Schedule) string { return "https://kareru.example.com/s/" + schedule.ShortCode },
+		Edit:  func(token string) string { return "https://kareru.example.com/edit/" + token },
+	}
+	schedule := func(email, locale string) *model.Schedule {
+		return &model.Schedule{
+			ID:           "schedule-id",
+			EditToken:    "edit-token",
+			ShortCode:    "7K3M9QX2",
+			ExpiresAt:    time.Date(2026, 10, 26, 3, 0, 0, 0, time.UTC),
+			NotifyEmail:  email,
+			NotifyLocale: locale,
+		}
+	}
+	setup := func(t *testing.T, opts Options) (*smtpServer, *Notifier) {
+		server := newSMTPServer(t)
+		mailer, err := NewSMTPMailer(server.config())
+		require.NoError(t, err)
+		notifier := NewNotifier(mailer, links, opts)
+		notifier.Start()
+		return server, notifier
+	}
+
+	t.Run("作成時に共有用URLと編集用URLを送り、失効前にリマインダーを送る", func(t *testing.T) {
+		server, notifier := setup(t, Options{RetryDelay: 10 * time.Millisecond})
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("taro@example.com", model.LocaleJapanese))
+		notifier.Notify(context.Background(), model.EventScheduleUpdated, schedule("taro@example.com", model.LocaleJapanese))
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("", ""))
+		notifier.Notify(context.Background(), model.EventScheduleExpiringSoon, schedule("john@example.com", model.LocaleEnglish))
+		require.NoError(t, notifier.Shutdown(context.Background()))
+
+		messages := server.received()
+		require.Len(t, messages, 2)
+
+		_, subject, body := parseMessage(t, messages[0].Data)
+		assert.Equal(t, []string{"<taro@example.com>"}, messages[0].To)
+		assert.Equal(t, "【Kareru】スケジュールを作成しました", subject)
+		assert.Contains(t, body, "https://kareru.example.com/s/7K3M9QX2")
+		assert.Contains(t, body, "https://kareru.example.com/edit/edit-token")
+		assert.Contains(t, body, "2026年10月26日 12:00（JST）")
+		assert.NotContains(t, body, "失効が近づいたら")
+
+		_, subject, body = parseMessage(t, messages[1].Data)
+		assert.Equal(t, []string{"<john@example.com>"}, messages[1].To)
+		assert.Equal(t, "[Kareru] Your schedule is about to expire", subject)
+		assert.Contains(t, body, "Oct 26, 2026 12:00 (JST)")
+	})
+
+	t.Run("一時的な失敗は再試行し、恒久的な失敗は再試行しない", func(t *testing.T) {
+		server, notifier := setup(t, Options{RetryDelay: 10 * time.Millisecond})
+		server.mu.Lock()
+		server.rcptReplies = []string{"451 4.3.0 Try again later"}
+		server.mu.Unlock()
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("taro@example.com", model.LocaleJapanese))
+		require.Eventually(t, func() bool { return len(server.received()) == 1 }, 5*time.Second, 5*time.Millisecond)
+
+		server.mu.Lock()
+		server.rcptReplies = []string{"550 5.1.1 No such user"}
+		server.mu.Unlock()
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("hanako@example.com", model.LocaleJapanese))
+		// 停止すると再試行を待たなくなるため、全ての応答を返し終えてから停止する
+		require.Eventually(t, func() bool {
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			return len(server.rcptReplies) == 0
+		}, 5*time.Second, 5*time.Millisecond)
+		require.NoError(t, notifier.Shutdown(context.Background()))
+
+		// 1通目は451の後に再試行で届き、2通目は550で送らない
+		messages := server.received()
+		require.Len(t, messages, 1)
+		assert.Equal(t, []string{"<taro@example.com>"}, messages[0].To)
+	})
+
+	t.Run("リマインダーを送る場合は作成時のメールで予告する", func(t *testing.T) {
+		server, notifier := setup(t, Options{Reminders: true})
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("taro@example.com", model.LocaleJapanese))
+		require.NoError(t, notifier.Shutdown(context.Background()))
+
+		messages := server.received()
+		require.Len(t, messages, 1)
+		_, _, body := parseMessage(t, messages[0].Data)
+		assert.Contains(t, body, "失効が近づいたら、このアドレスにもう一度お知らせします。")
+	})
+
+	t.Run("再試行を待っている間も他のメールを送り、停止時に再試行をあきらめる", func(t *testing.T) {
+		server, notifier := setup(t, Options{RetryDelay: time.Hour})
+		server.rcptReplies = []string{"451 4.3.0 Try again later"}
+
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("taro@example.com", model.LocaleJapanese))
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("hanako@example.com", model.LocaleJapanese))
+		require.Eventually(t, func() bool { return len(server.received()) == 1 }, 5*time.Second, 5*time.Millisecond)
+
+		// 1時間の待機を待たずに停止できる
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, notifier.Shutdown(ctx))
+
+		messages := server.received()
+		require.Len(t, messages, 1)
+		assert.Equal(t, []string{"<hanako@example.com>"}, messages[0].To)
+		notifier.mu.RLock()
+		defer notifier.mu.RUnlock()
+		assert.Empty(t, notifier.retries)
+	})
+
+	t.Run("停止後の依頼は送らない", func(t *testing.T) {
+		server, notifier := setup(t, Options{RetryDelay: 10 * time.Millisecond})
+		require.NoError(t, notifier.Shutdown(context.Background()))
+		notifier.Notify(context.Background(), model.EventScheduleCreated, schedule("taro@example.com", model.LocaleJapanese))
+		assert.Empty(t, server.received())
+	})
+}
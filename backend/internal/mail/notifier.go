@@ -0,0 +1,290 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// ErrNotifierClosed は停止したNotifierに送信を依頼した場合に返される
+var ErrNotifierClosed = errors.New("mail: notifier is closed")
+
+// Links はメールに載せるフロントエンドのURLを作る
+type Links struct {
+	// Share はスケジュールから共有用URLを作る
+	Share func(schedule *model.Schedule) string
+	// Edit は編集トークンから編集用URLを作る
+	Edit func(token string) string
+}
+
+// Options はNotifierの設定
+type Options struct {
+	// QueueSize は送信待ちにできるメールの数（溢れたメールは送らない）
+	QueueSize int
+	// MaxAttempts は1通の送信を試みる最大回数（初回を含む）
+	MaxAttempts int
+	// RetryDelay は最初の再試行までの待ち時間。以降は再試行のたびに倍になる
+	RetryDelay time.Duration
+	// Location はメールに載せる日時のタイムゾーン（nilの場合は日本時間）
+	// 日時にはこのタイムゾーンの略称を添える
+	Location *time.Location
+	// Reminders は失効前のリマインダーを送るか（作成時のメールで予告するかどうかに使う）
+	Reminders bool
+	// Logger は送信の失敗などの出力先（nilの場合はslogのデフォルト）
+	Logger *slog.Logger
+}
+
+// DefaultOptions はデフォルトの設定を返す
+func DefaultOptions() Options {
+	return Options{
+		QueueSize:   100,
+		MaxAttempts: 3,
+		RetryDelay:  30 * time.Second,
+	}
+}
+
+// Notifier はスケジュールのイベントのうち、作成と失効前のリマインダーを作成者にメールで送る
+// 送信はバックグラウンドで1通ずつ行い、Notifyは送信を待たない
+// 一時的な失敗の再試行はタイマーで予約し、待っている間も他のメールを送る
+type Notifier struct {
+	mailer Mailer
+	links  Links
+	opts   Options
+	logger *slog.Logger
+
+	queue chan envelope
+	// ctx は強制停止のときに送信中のメールを中断するために使う
+	ctx    context.Context
+	cancel context.CancelFunc
+	// mu はclosedとretriesを守り、停止後にキューへ送らないようにする
+	mu     sync.RWMutex
+	closed bool
+	// retries は再試行を待っているメール
+	retries map[*retry]struct{}
+	done    chan struct{}
+}
+
+// envelope は送信待ちのメール
+type envelope struct {
+	message    Message
+	kind       string
+	scheduleID string
+	// attempt は何回目の試行か（1から数える）
+	attempt int
+}
+
+// retry は再試行を待っているメール
+type retry struct {
+	envelope envelope
+	timer    *time.Timer
+	err      error
+}
+
+// NewNotifier はmailerで送信するNotifierを作成する（Startを呼ぶまで送信しない）
+func NewNotifier(mailer Mailer, links Links, opts Options) *Notifier {
+	defaults := DefaultOptions()
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaults.QueueSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaults.RetryDelay
+	}
+	if opts.Location == nil {
+		opts.Location = time.FixedZone("JST", 9*60*60)
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Notifier{
+		mailer:  mailer,
+		links:   links,
+		opts:    opts,
+		logger:  logger,
+		queue:   make(chan envelope, opts.QueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		retries: make(map[*retry]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start は送信するワーカーを起動する
+func (n *Notifier) Start() {
+	go func() {
+		defer close(n.done)
+		for e := range n.queue {
+			n.deliver(e)
+		}
+	}()
+}
+
+// Notify は作成と失効前のイベントで、通知先のアドレスが登録されていればメールをキューに入れる
+func (n *Notifier) Notify(ctx context.Context, event model.ScheduleEvent, schedule *model.Schedule) {
+	var kind string
+	switch event {
+	case model.EventScheduleCreated:
+		kind = KindCreated
+	case model.EventScheduleExpiringSoon:
+		kind = KindExpiringSoon
+	default:
+		return
+	}
+	if schedule.NotifyEmail == "" {
+		return
+	}
+
+	subject, body, err := Render(kind, schedule.NotifyLocale, TemplateData{
+		ShareURL:  n.links.Share(schedule),
+		EditURL:   n.links.Edit(schedule.EditToken),
+		ExpiresAt: FormatTime(schedule.ExpiresAt, schedule.NotifyLocale, n.opts.Location),
+		TimeSlots: len(schedule.TimeSlots),
+		Reminders: n.opts.Reminders,
+	})
+	if err != nil {
+		n.logger.ErrorContext(ctx, "failed to render notification email", slog.String("kind", kind), slog.String("error", err.Error()))
+		return
+	}
+
+	e := envelope{
+		message:    Message{To: schedule.NotifyEmail, Subject: subject, Body: body},
+		kind:       kind,
+		scheduleID: schedule.ID,
+		attempt:    1,
+	}
+	if err := n.enqueue(e); err != nil {
+		n.logger.WarnContext(ctx, "notification email was not queued",
+			slog.String("kind", kind),
+			slog.String("schedule_id", schedule.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (n *Notifier) enqueue(e envelope) error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return ErrNotifierClosed
+	}
+	select {
+	case n.queue <- e:
+		return nil
+	default:
+		return errors.New("mail: queue is full")
+	}
+}
+
+// Shutdown は新しいメールの受け付けを止め、キューに残ったメールを送り終えるまで待つ
+// 停止中は再試行を待たずにあきらめる。ctxが終了した場合は送信中のメールを中断する
+func (n *Notifier) Shutdown(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		for r := range n.retries {
+			r.timer.Stop()
+			n.failed(r.envelope, notRetried(r.err, "the notifier is shutting down"))
+		}
+		clear(n.retries)
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		n.cancel()
+		return nil
+	case <-ctx.Done():
+		n.cancel()
+		<-n.done
+		return ctx.Err()
+	}
+}
+
+// deliver は1回分の送信を試み、一時的な失敗であれば再試行を予約する
+func (n *Notifier) deliver(e envelope) {
+	err := n.mailer.Send(n.ctx, e.message)
+	if err == nil {
+		return
+	}
+	if !temporary(err) || e.attempt >= n.opts.MaxAttempts {
+		n.failed(e, err)
+		return
+	}
+	n.scheduleRetry(&retry{envelope: e, err: err}, n.opts.RetryDelay<<(e.attempt-1))
+}
+
+// scheduleRetry はwaitの後にメールをキューへ戻すタイマーを設定する
+// 停止が始まっている場合は再試行せずにあきらめる
+func (n *Notifier) scheduleRetry(r *retry, wait time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		n.failed(r.envelope, notRetried(r.err, "the notifier is shutting down"))
+		return
+	}
+	n.retries[r] = struct{}{}
+	r.timer = time.AfterFunc(wait, func() { n.requeue(r) })
+}
+
+// requeue は待ち時間が過ぎたメールを次の試行としてキューへ戻す
+// キューがいっぱいの場合は再試行せずにあきらめる
+func (n *Notifier) requeue(r *retry) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.retries[r]; !ok {
+		// 停止時にあきらめ済み
+		return
+	}
+	delete(n.retries, r)
+
+	next := r.envelope
+	next.attempt++
+	select {
+	case n.queue <- next:
+	default:
+		n.failed(r.envelope, notRetried(r.err, "the queue is full"))
+	}
+}
+
+// notRetried は再試行しなかった理由を最後の失敗に添える
+func notRetried(err error, reason string) error {
+	return fmt.Errorf("%w (not retried because %s)", err, reason)
+}
+
+// failed は送信をあきらめたメールを記録する
+// 宛先は個人情報のため、ドメインだけを出力する
+func (n *Notifier) failed(e envelope, err error) {
+	n.logger.Error("failed to send notification email",
+		slog.String("kind", e.kind),
+		slog.String("schedule_id", e.scheduleID),
+		slog.String("recipient_domain", e.message.To[strings.LastIndex(e.message.To, "@")+1:]),
+		slog.Int("attempts", e.attempt),
+		slog.String("error", err.Error()),
+	)
+}
+
+// temporary は再試行すれば送れる可能性がある失敗かを返す
+// SMTPの5xx応答と不正なメールは何度送っても失敗するため再試行しない
+func temporary(err error) bool {
+	if errors.Is(err, ErrInvalidMessage) {
+		return false
+	}
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code < 500
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// 送信するメールの種類（templates/<種類>.<言語>.tmpl に対応する）
+const (
+	KindCreated      = "created"
+	KindExpiringSoon = "expiring_soon"
+)
+
+// dateFormats は言語ごとの日時の書式（タイムゾーンの略称を添える）
+var dateFormats = map[string]string{
+	model.LocaleJapanese: "2006年1月2日 15:04（MST）",
+	model.LocaleEnglish:  "Jan 2, 2006 15:04 (MST)",
+}
+
+// templates は種類と言語（"created.ja" など）ごとのテンプレート
+var templates = mustParseTemplates()
+
+// TemplateData はテンプレートに渡す値
+// 第三者に送られても悪用されにくいよう、作成者が入力したコメントは含めない
+type TemplateData struct {
+	ShareURL  string
+	EditURL   string
+	ExpiresAt string
+	TimeSlots int
+	// Reminders は失効前のリマインダーを送るか
+	Reminders bool
+}
+
+func mustParseTemplates() map[string]*template.Template {
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+	parsed := make(map[string]*template.Template)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".tmpl")
+		parsed[name] = template.Must(template.New(name).Option("missingkey=error").ParseFS(templateFS, "templates/"+entry.Name()))
+	}
+	return parsed
+}
+
+// FormatTime はメールに載せる日時を言語ごとの書式で返す
+func FormatTime(t time.Time, locale string, loc *time.Location) string {
+	format, ok := dateFormats[locale]
+	if !ok {
+		format = dateFormats[model.LocaleJapanese]
+	}
+	return t.In(loc).Format(format)
+}
+
+// Render は種類と言語に対応するテンプレートから件名と本文を作る
+// 対応するテンプレートがない言語は日本語にする
+func Render(kind, locale string, data TemplateData) (subject, body string, err error) {
+	tmpl, ok := templates[kind+"."+locale]
+	if !ok {
+		tmpl, ok = templates[kind+"."+model.LocaleJapanese]
+	}
+	if !ok {
+		return "", "", fmt.Errorf("mail: unknown template %q", kind)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+	buf.Reset()
+	if err := tmpl.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", err
+	}
+	return subject, buf.String(), nil
+}
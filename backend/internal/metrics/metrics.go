@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+)
+
+const namespace = "kareru"
+
+// unmatchedRoute はどのルートにも一致しなかったリクエストのラベル
+// 生のパスをラベルにするとカーディナリティが際限なく増えるためまとめる
+const unmatchedRoute = "unmatched"
+
+// ScheduleLister は有効なスケジュール数を数えるために全件を取得できるリポジトリ
+type ScheduleLister interface {
+	List() ([]*model.Schedule, error)
+}
+
+// WebhookDispatcher はWebhookの配信状況を公開するディスパッチャー
+type WebhookDispatcher interface {
+	// DeadLetterCount は起動してから配信をあきらめたイベントの数を返す
+	DeadLetterCount() uint64
+	// PendingRetries は再試行を待っている配信の数を返す
+	PendingRetries() int
+}
+
+// Metrics はPrometheusで公開するメトリクスをまとめたもの
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	repoDuration  *prometheus.HistogramVec
+	repoErrors    *prometheus.CounterVec
+	expiredOnRead prometheus.Counter
+	tokenFailures prometheus.Counter
+}
+
+var _ handlers.ScheduleMetrics = (*Metrics)(nil)
+
+// New はメトリクスを作成し、専用のレジストリに登録する
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Schedule repository operation latency by backend and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_errors_total",
+			Help:      "Schedule repository operation errors by backend and operation (not found is not counted).",
+		}, []string{"backend", "operation"}),
+		expiredOnRead: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "expired_schedule_reads_total",
+			Help:      "Number of requests that hit an expired schedule.",
+		}),
+		tokenFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_verification_failures_total",
+			Help:      "Number of failed edit token verifications.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.repoDuration,
+		m.repoErrors,
+		m.expiredOnRead,
+		m.tokenFailures,
+	)
+	return m
+}
+
+// Handler は /metrics で公開するハンドラーを返す
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware はリクエスト数とレイテンシをルートテンプレート単位で記録するginミドルウェア
+// （/api/v1/schedules/:uuid のように記録し、UUIDや編集トークンはラベルに含めない）
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		m.httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) ExpiredOnRead() {
+	m.expiredOnRead.Inc()
+}
+
+func (m *Metrics) TokenVerificationFailed() {
+	m.tokenFailures.Inc()
+}
+
+// RegisterActiveSchedules は有効な（失効していない）スケジュール数のゲージを登録する
+// 全件取得は重いため、結果をrefreshの間キャッシュする
+func (m *Metrics) RegisterActiveSchedules(lister ScheduleLister, refresh time.Duration) {
+	counter := &activeScheduleCounter{lister: lister, refresh: refresh, now: time.Now}
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_schedules",
+		Help:      "Number of schedules that have not expired yet.",
+	}, counter.count))
+}
+
+// RegisterWebhookDispatcher はWebhookのデッドレターの総数と再試行待ちの配信数を登録する
+// デッドレターの内容はログに出力し、メトリクスには件数だけを含める
+func (m *Metrics) RegisterWebhookDispatcher(dispatcher WebhookDispatcher) {
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_dead_letters_total",
+			Help:      "Total number of webhook deliveries that were given up.",
+		}, func() float64 { return float64(dispatcher.DeadLetterCount()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "webhook_retries_pending",
+			Help:      "Number of webhook deliveries waiting to be retried.",
+		}, func() float64 { return float64(dispatcher.PendingRetries()) }),
+	)
+}
+
+type activeScheduleCounter struct {
+	lister  ScheduleLister
+	refresh time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	value     float64
+	updatedAt time.Time
+}
+
+func (a *activeScheduleCounter) count() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if !a.updatedAt.IsZero() && now.Sub(a.updatedAt) < a.refresh {
+		return a.value
+	}
+
+	schedules, err := a.lister.List()
+	if err != nil {
+		// 取得に失敗した場合は前回の値を返し、次のスクレイプで再試行する
+		log.Printf("Failed to count active schedules: %v", err)
+		return a.value
+	}
+
+	active := 0
+	for _, schedule := range schedules {
+		if now.Before(schedule.ExpiresAt) {
+			active++
+		}
+	}
+	a.value = float64(active)
+	a.updatedAt = now
+	return a.value
+}
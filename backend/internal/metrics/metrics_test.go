@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+	"kareru-backend/internal/infrastructure/repository"
+)
+
+// failingRepository は全ての操作で指定したエラーを返すテスト用リポジトリ
+type failingRepository struct {
+	handlers.ScheduleRepository
+	err error
+}
+
+func (f failingRepository) GetByID(id string) (*model.Schedule, error) {
+	return nil, f.err
+}
+
+// contextRepository はWithContextで受け取ったコンテキストを記録するテスト用リポジトリ
+type contextRepository struct {
+	handlers.ScheduleRepository
+	ctx context.Context
+}
+
+func (r *contextRepository) WithContext(ctx context.Context) handlers.ScheduleRepository {
+	return &contextRepository{ScheduleRepository: r.ScheduleRepository, ctx: ctx}
+}
+
+func (r *contextRepository) GetByID(id string) (*model.Schedule, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.ScheduleRepository.GetByID(id)
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMetrics_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := New()
+
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/api/v1/schedules/:uuid", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/schedules/edit/:token", func(c *gin.Context) { c.Status(http.StatusForbidden) })
+
+	for _, path := range []string{
+		"/api/v1/schedules/11111111-1111-4111-8111-111111111111",
+		"/api/v1/schedules/22222222-2222-4222-8222-222222222222",
+		"/api/v1/schedules/edit/secret-edit-token",
+		"/unknown/path",
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/v1/schedules/:uuid", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/v1/schedules/edit/:token", "403")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `kareru_http_request_duration_seconds_count{method="GET",route="/api/v1/schedules/:uuid"} 2`)
+	assert.NotContains(t, body, "11111111-1111-4111-8111-111111111111")
+	assert.NotContains(t, body, "secret-edit-token")
+	assert.NotContains(t, body, "/unknown/path")
+}
+
+func TestInstrumentedScheduleRepository(t *testing.T) {
+	t.Run("操作ごとのレイテンシを記録し、not foundはエラーとして数えない", func(t *testing.T) {
+		m := New()
+		repo := m.InstrumentRepository("memory", repository.NewMemoryScheduleRepository())
+
+		schedule := &model.Schedule{ID: "id-1", EditToken: "token-1", ExpiresAt: time.Now().Add(time.Hour)}
+		require.NoError(t, repo.Create(schedule))
+		_, err := repo.GetByID("id-1")
+		require.NoError(t, err)
+		_, err = repo.GetByID("missing")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+
+		body := scrape(t, m)
+		assert.Contains(t, body, `kareru_repository_operation_duration_seconds_count{backend="memory",operation="create"} 1`)
+		assert.Contains(t, body, `kareru_repository_operation_duration_seconds_count{backend="memory",operation="get_by_id"} 2`)
+		assert.Equal(t, 0, testutil.CollectAndCount(m.repoErrors))
+	})
+
+	t.Run("バックエンドのエラーを数える", func(t *testing.T) {
+		m := New()
+		repo := m.InstrumentRepository("firestore", failingRepository{err: errors.New("unavailable")})
+
+		_, err := repo.GetByID("id-1")
+		assert.Error(t, err)
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.repoErrors.WithLabelValues("firestore", "get_by_id")))
+	})
+
+	t.Run("WithContextで下位のリポジトリにコンテキストを引き継ぐ", func(t *testing.T) {
+		m := New()
+		inner := repository.NewMemoryScheduleRepository()
+		require.NoError(t, inner.Create(&model.Schedule{ID: "id-1", EditToken: "token-1", ExpiresAt: time.Now().Add(time.Hour)}))
+		repo := m.InstrumentRepository("firestore", &contextRepository{ScheduleRepository: inner, ctx: context.Background()})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := repo.WithContext(ctx).GetByID("id-1")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.repoErrors.WithLabelValues("firestore", "get_by_id")))
+
+		_, err = repo.GetByID("id-1")
+		assert.NoError(t, err)
+	})
+}
+
+func TestMetrics_ScheduleEvents(t *testing.T) {
+	m := New()
+	m.ExpiredOnRead()
+	m.TokenVerificationFailed()
+	m.TokenVerificationFailed()
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.expiredOnRead))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.tokenFailures))
+}
+
+// fakeWebhookDispatcher は配信状況を返すテスト用のディスパッチャー
+type fakeWebhookDispatcher struct {
+	deadLetters uint64
+	pending     int
+}
+
+func (f fakeWebhookDispatcher) DeadLetterCount() uint64 { return f.deadLetters }
+func (f fakeWebhookDispatcher) PendingRetries() int     { return f.pending }
+
+func TestMetrics_RegisterWebhookDispatcher(t *testing.T) {
+	m := New()
+	m.RegisterWebhookDispatcher(fakeWebhookDispatcher{deadLetters: 3, pending: 2})
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "kareru_webhook_dead_letters_total 3")
+	assert.Contains(t, body, "kareru_webhook_retries_pending 2")
+}
+
+func TestActiveScheduleCounter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := repository.NewMemoryScheduleRepository()
+	require.NoError(t, repo.Create(&model.Schedule{ID: "active", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.Create(&model.Schedule{ID: "expired", ExpiresAt: now.Add(-time.Hour)}))
+
+	counter := &activeScheduleCounter{lister: repo, refresh: time.Minute, now: func() time.Time { return now }}
+	assert.Equal(t, float64(1), counter.count())
+
+	// 更新間隔内はキャッシュした値を返す
+	require.NoError(t, repo.Create(&model.Schedule{ID: "new", ExpiresAt: now.Add(time.Hour)}))
+	assert.Equal(t, float64(1), counter.count())
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, float64(2), counter.count())
+}
+
+func TestMetrics_RegisterActiveSchedules(t *testing.T) {
+	m := New()
+	repo := repository.NewMemoryScheduleRepository()
+	require.NoError(t, repo.Create(&model.Schedule{ID: "active", ExpiresAt: time.Now().Add(time.Hour)}))
+	m.RegisterActiveSchedules(repo, time.Minute)
+
+	body := scrape(t, m)
+	assert.True(t, strings.Contains(body, "kareru_active_schedules 1"), body)
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+)
+
+// InstrumentedScheduleRepository はリポジトリの各操作のレイテンシとエラーを記録するデコレーター
+type InstrumentedScheduleRepository struct {
+	repo    handlers.ScheduleRepository
+	backend string
+	metrics *Metrics
+}
+
+var (
+	_ handlers.ScheduleRepository = (*InstrumentedScheduleRepository)(nil)
+	_ handlers.ContextBinder      = (*InstrumentedScheduleRepository)(nil)
+)
+
+// InstrumentRepository はbackendをラベルとして操作を計測するリポジトリを返す
+func (m *Metrics) InstrumentRepository(backend string, repo handlers.ScheduleRepository) *InstrumentedScheduleRepository {
+	return &InstrumentedScheduleRepository{
+		repo:    repo,
+		backend: backend,
+		metrics: m,
+	}
+}
+
+// WithContext は下位のリポジトリにctxを引き継いで計測するリポジトリを返す
+// 下位のリポジトリがコンテキストを受け取らない場合はそのまま返す
+func (r *InstrumentedScheduleRepository) WithContext(ctx context.Context) handlers.ScheduleRepository {
+	binder, ok := r.repo.(handlers.ContextBinder)
+	if !ok {
+		return r
+	}
+	bound := *r
+	bound.repo = binder.WithContext(ctx)
+	return &bound
+}
+
+func (r *InstrumentedScheduleRepository) observe(operation string, start time.Time, err error) {
+	r.metrics.repoDuration.WithLabelValues(r.backend, operation).Observe(time.Since(start).Seconds())
+	// 存在しないIDやトークンへのアクセスは正常な結果として扱う
+	if err != nil && !errors.Is(err, model.ErrScheduleNotFound) {
+		r.metrics.repoErrors.WithLabelValues(r.backend, operation).Inc()
+	}
+}
+
+func (r *InstrumentedScheduleRepository) Create(schedule *model.Schedule) error {
+	start := time.Now()
+	err := r.repo.Create(schedule)
+	r.observe("create", start, err)
+	return err
+}
+
+func (r *InstrumentedScheduleRepository) GetByID(id string) (*model.Schedule, error) {
+	start := time.Now()
+	schedule, err := r.repo.GetByID(id)
+	r.observe("get_by_id", start, err)
+	return schedule, err
+}
+
+func (r *InstrumentedScheduleRepository) GetByEditToken(token string) (*model.Schedule, error) {
+	start := time.Now()
+	schedule, err := r.repo.GetByEditToken(token)
+	r.observe("get_by_edit_token", start, err)
+	return schedule, err
+}
+
+func (r *InstrumentedScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	start := time.Now()
+	schedule, err := r.repo.GetByShortCode(code)
+	r.observe("get_by_short_code", start, err)
+	return schedule, err
+}
+
+func (r *InstrumentedScheduleRepository) Update(schedule *model.Schedule) error {
+	start := time.Now()
+	err := r.repo.Update(schedule)
+	r.observe("update", start, err)
+	return err
+}
+
+func (r *InstrumentedScheduleRepository) Delete(id string) error {
+	start := time.Now()
+	err := r.repo.Delete(id)
+	r.observe("delete", start, err)
+	return err
+}
+
+// Ping は下位のリポジトリの疎通確認をそのまま呼び出す（計測はしない）
+func (r *InstrumentedScheduleRepository) Ping(ctx context.Context) error {
+	if pinger, ok := r.repo.(handlers.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
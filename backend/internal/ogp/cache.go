@@ -0,0 +1,71 @@
+package ogp
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache は描画済みの画像をバージョンをキーにして保持するLRUキャッシュ
+// バージョンにスケジュールIDと内容が含まれるため、更新された場合は別のキーになり古い画像はいずれ追い出される
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+// NewCache は最大capacity枚の画像を保持するキャッシュを作成する
+func NewCache(capacity int) *Cache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get はキャッシュされた画像を返す
+// 呼び出し側は返されたスライスを変更してはいけない
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).data, true
+}
+
+// Add は画像をキャッシュし、容量を超えた場合は最も古く使われた画像を追い出す
+func (c *Cache) Add(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).data = data
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, data: data})
+	for c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Len は保持している画像の数を返す
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
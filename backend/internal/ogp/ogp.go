@@ -0,0 +1,365 @@
+package ogp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"kareru-backend/internal/domain/model"
+)
+
+// OGP画像の推奨サイズ（1.91:1）
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// layoutVersion はレイアウトを変更したときに上げ、キャッシュ済みの画像を無効にする
+const layoutVersion = "1"
+
+// mplus1pRegular は日本語を描画するためのフォント（M+ FONTS、ライセンスは fonts/LICENSE）
+//
+//go:embed fonts/mplus-1p-regular.ttf
+var mplus1pRegular []byte
+
+var parseFont = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(mplus1pRegular)
+})
+
+// Variant は描画する画像の種類
+type Variant int
+
+const (
+	// VariantActive はコメント・日付の範囲・空き時間の数・週のヒートマップを描画する
+	VariantActive Variant = iota
+	// VariantExpired は期限切れであることだけを描画する
+	VariantExpired
+	// VariantProtected はパスワード保護されていることだけを描画する（内容はプレビューに出さない）
+	VariantProtected
+)
+
+// VariantOf はスケジュールの状態から描画する画像の種類を決める
+func VariantOf(schedule *model.Schedule, now time.Time) Variant {
+	switch {
+	case now.After(schedule.ExpiresAt):
+		return VariantExpired
+	case schedule.HasViewPassword():
+		return VariantProtected
+	default:
+		return VariantActive
+	}
+}
+
+// Version は画像の内容を決める値から計算したバージョン
+// 内容が変わらない限り同じ値になるため、キャッシュのキーやETagに使う
+func Version(schedule *model.Schedule, variant Variant) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00", layoutVersion, schedule.ID, variant)
+	if variant == VariantActive {
+		fmt.Fprintf(h, "%s\x00", schedule.Comment)
+		for _, slot := range schedule.TimeSlots {
+			fmt.Fprintf(h, "%d-%d\x00", slot.StartTime.UnixNano(), slot.EndTime.UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+var (
+	colorBackground = color.RGBA{0xf8, 0xfa, 0xfc, 0xff}
+	colorAccent     = color.RGBA{0x25, 0x63, 0xeb, 0xff} // blue-600（フロントエンドのテーマカラー）
+	colorMuted      = color.RGBA{0x9c, 0xa3, 0xaf, 0xff} // gray-400
+	colorText       = color.RGBA{0x11, 0x18, 0x27, 0xff} // gray-900
+	colorSubText    = color.RGBA{0x4b, 0x55, 0x63, 0xff} // gray-600
+	colorCellEmpty  = color.RGBA{0xe5, 0xe7, 0xeb, 0xff} // gray-200
+	colorCellFull   = colorAccent
+)
+
+var weekdays = []string{"日", "月", "火", "水", "木", "金", "土"}
+
+// Renderer はスケジュールのOGP画像を描画する
+type Renderer struct {
+	// Location は日付と時刻を表示するタイムゾーン
+	Location *time.Location
+}
+
+// NewRenderer は日本時間で表示するRendererを作成する
+func NewRenderer() *Renderer {
+	return &Renderer{
+		Location: time.FixedZone("Asia/Tokyo", 9*60*60),
+	}
+}
+
+// canvas は1枚の画像の描画中の状態
+type canvas struct {
+	img  *image.RGBA
+	font *opentype.Font
+}
+
+// Render はスケジュールのOGP画像をPNGで返す
+func (r *Renderer) Render(schedule *model.Schedule, variant Variant) ([]byte, error) {
+	f, err := parseFont()
+	if err != nil {
+		return nil, fmt.Errorf("ogp: failed to parse font: %w", err)
+	}
+
+	c := &canvas{
+		img:  image.NewRGBA(image.Rect(0, 0, Width, Height)),
+		font: f,
+	}
+	accent := colorAccent
+	if variant == VariantExpired {
+		accent = colorMuted
+	}
+	c.fill(c.img.Bounds(), colorBackground)
+	c.fill(image.Rect(0, 0, Width, 12), accent)
+	if err := c.text("Kareru", 36, accent, 64, 92); err != nil {
+		return nil, err
+	}
+
+	switch variant {
+	case VariantExpired:
+		err = r.drawMessage(c, "このスケジュールは期限切れです", "新しいスケジュールの共有を依頼してください")
+	case VariantProtected:
+		err = r.drawMessage(c, "パスワードで保護されたスケジュール", "リンクを開いてパスワードを入力すると閲覧できます")
+	default:
+		err = r.drawSchedule(c, schedule)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("ogp: failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Renderer) drawMessage(c *canvas, title, detail string) error {
+	if err := c.text(title, 56, colorText, 64, 300); err != nil {
+		return err
+	}
+	return c.text(detail, 32, colorSubText, 64, 380)
+}
+
+func (r *Renderer) drawSchedule(c *canvas, schedule *model.Schedule) error {
+	// 左側にテキスト、右側に週のヒートマップを配置する
+	const textWidth = 620
+
+	comment := schedule.Comment
+	if comment == "" {
+		comment = "空いている日程の共有"
+	}
+	lines, err := c.wrap(comment, 56, textWidth, 2)
+	if err != nil {
+		return err
+	}
+	for i, line := range lines {
+		if err := c.text(line, 56, colorText, 64, 200+i*72); err != nil {
+			return err
+		}
+	}
+
+	slots := sortedSlots(schedule.TimeSlots)
+	if len(slots) == 0 {
+		return c.text("候補の日時はまだありません", 32, colorSubText, 64, 420)
+	}
+	if err := c.text(r.dateRange(slots), 32, colorSubText, 64, 420); err != nil {
+		return err
+	}
+	if err := c.text("空き時間 "+strconv.Itoa(len(slots))+" 枠", 40, colorAccent, 64, 500); err != nil {
+		return err
+	}
+	return r.drawHeatmap(c, slots, image.Rect(740, 150, Width-64, Height-64))
+}
+
+// dateRange は最初と最後の候補日を「10/20(月) 〜 10/26(日)」の形式で返す
+func (r *Renderer) dateRange(slots []model.TimeSlot) string {
+	first := slots[0].StartTime.In(r.Location)
+	last := slots[len(slots)-1].EndTime.In(r.Location)
+	format := func(t time.Time) string {
+		return fmt.Sprintf("%d/%d(%s)", t.Month(), t.Day(), weekdays[t.Weekday()])
+	}
+	if sameDay(first, last) {
+		return format(first)
+	}
+	return format(first) + " 〜 " + format(last)
+}
+
+// drawHeatmap は最初の候補日から7日間について、1時間ごとに空き時間が占める割合を色の濃さで描画する
+func (r *Renderer) drawHeatmap(c *canvas, slots []model.TimeSlot, area image.Rectangle) error {
+	first := slots[0].StartTime.In(r.Location)
+	start := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, r.Location)
+
+	// 表示する時間帯は8時〜22時を基本に、範囲外の候補があれば広げる（日をまたぐ候補があれば終日）
+	fromHour, toHour := 8, 22
+	end := start.AddDate(0, 0, 7)
+	for _, slot := range slots {
+		s := slot.StartTime.In(r.Location)
+		e := slot.EndTime.In(r.Location).Add(-time.Nanosecond)
+		if !s.Before(end) {
+			break
+		}
+		if !sameDay(s, e) {
+			fromHour, toHour = 0, 24
+			break
+		}
+		fromHour = min(fromHour, s.Hour())
+		toHour = max(toHour, e.Hour()+1)
+	}
+
+	const labelHeight = 44
+	const gap = 4
+	columnWidth := (area.Dx() - gap*6) / 7
+	rowHeight := (area.Dy() - labelHeight - gap*(toHour-fromHour-1)) / (toHour - fromHour)
+
+	for day := 0; day < 7; day++ {
+		date := start.AddDate(0, 0, day)
+		x := area.Min.X + day*(columnWidth+gap)
+		label := weekdays[date.Weekday()]
+		if err := c.text(label, 24, colorSubText, x+columnWidth/2-12, area.Min.Y+28); err != nil {
+			return err
+		}
+
+		for hour := fromHour; hour < toHour; hour++ {
+			cellStart := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, r.Location)
+			ratio := coverage(slots, cellStart, cellStart.Add(time.Hour))
+			y := area.Min.Y + labelHeight + (hour-fromHour)*(rowHeight+gap)
+			c.fill(image.Rect(x, y, x+columnWidth, y+rowHeight), blend(colorCellEmpty, colorCellFull, ratio))
+		}
+	}
+	return nil
+}
+
+// coverage は[from, to)のうち候補の時間帯が占める割合を返す（候補同士は重ならない）
+func coverage(slots []model.TimeSlot, from, to time.Time) float64 {
+	var covered time.Duration
+	for _, slot := range slots {
+		s, e := slot.StartTime, slot.EndTime
+		if s.Before(from) {
+			s = from
+		}
+		if e.After(to) {
+			e = to
+		}
+		if e.After(s) {
+			covered += e.Sub(s)
+		}
+	}
+	return float64(covered) / float64(to.Sub(from))
+}
+
+func sortedSlots(slots []model.TimeSlot) []model.TimeSlot {
+	sorted := make([]model.TimeSlot, len(slots))
+	copy(sorted, slots)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+	return sorted
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func blend(from, to color.RGBA, ratio float64) color.RGBA {
+	if ratio > 1 {
+		ratio = 1
+	}
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*ratio)
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
+
+func (c *canvas) fill(rect image.Rectangle, col color.Color) {
+	draw.Draw(c.img, rect, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// face は指定したサイズのフォントを返す
+// opentypeのFaceは並行に使えないため、描画ごとに作成する
+func (c *canvas) face(size float64) (font.Face, error) {
+	face, err := opentype.NewFace(c.font, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ogp: failed to create font face: %w", err)
+	}
+	return face, nil
+}
+
+// text はベースラインの左端を(x, y)として文字列を描画する
+func (c *canvas) text(s string, size float64, col color.Color, x, y int) error {
+	face, err := c.face(size)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+	return nil
+}
+
+// wrap は幅に収まるように文字単位で折り返し、maxLinesを超える部分は省略記号で切り詰める
+// 日本語は単語の区切りが無いため、英語の単語の途中でも折り返す
+func (c *canvas) wrap(s string, size float64, width, maxLines int) ([]string, error) {
+	face, err := c.face(size)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	limit := fixed.I(width)
+	var lines []string
+	line := ""
+	for _, r := range s {
+		if r == '\n' || r == '\r' {
+			r = ' '
+		}
+		if font.MeasureString(face, line+string(r)) <= limit {
+			line += string(r)
+			continue
+		}
+		lines = append(lines, line)
+		line = string(r)
+		if len(lines) == maxLines {
+			break
+		}
+	}
+	if len(lines) < maxLines {
+		return append(lines, line), nil
+	}
+
+	// 収まらなかった場合は最後の行の末尾を省略記号に置き換える
+	last := lines[maxLines-1]
+	for last != "" && font.MeasureString(face, last+"…") > limit {
+		_, n := utf8.DecodeLastRuneInString(last)
+		last = last[:len(last)-n]
+	}
+	lines[maxLines-1] = last + "…"
+	return lines, nil
+}
@@ -0,0 +1,149 @@
+package ogp
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+func newTestSchedule() *model.Schedule {
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	start := time.Date(2026, 10, 20, 10, 0, 0, 0, jst)
+	return &model.Schedule{
+		ID:      "ogp-uuid",
+		Comment: "定例ミーティングの候補日",
+		TimeSlots: []model.TimeSlot{
+			{StartTime: start.AddDate(0, 0, 2), EndTime: start.AddDate(0, 0, 2).Add(90 * time.Minute)},
+			{StartTime: start, EndTime: start.Add(2 * time.Hour)},
+		},
+		CreatedAt: start.Add(-24 * time.Hour),
+		ExpiresAt: start.AddDate(0, 0, 7),
+	}
+}
+
+func TestVariantOf(t *testing.T) {
+	schedule := newTestSchedule()
+	assert.Equal(t, VariantActive, VariantOf(schedule, schedule.CreatedAt))
+	assert.Equal(t, VariantExpired, VariantOf(schedule, schedule.ExpiresAt.Add(time.Second)))
+
+	require.NoError(t, schedule.SetViewPassword("secret"))
+	assert.Equal(t, VariantProtected, VariantOf(schedule, schedule.CreatedAt))
+	// 期限切れの表示を優先する
+	assert.Equal(t, VariantExpired, VariantOf(schedule, schedule.ExpiresAt.Add(time.Second)))
+}
+
+func TestVersion(t *testing.T) {
+	schedule := newTestSchedule()
+	version := Version(schedule, VariantActive)
+	assert.Equal(t, version, Version(schedule.Clone(), VariantActive))
+
+	t.Run("表示する内容が変わるとバージョンが変わる", func(t *testing.T) {
+		changed := schedule.Clone()
+		changed.Comment = "別の予定"
+		assert.NotEqual(t, version, Version(changed, VariantActive))
+
+		changed = schedule.Clone()
+		changed.TimeSlots[0].EndTime = changed.TimeSlots[0].EndTime.Add(time.Hour)
+		assert.NotEqual(t, version, Version(changed, VariantActive))
+
+		assert.NotEqual(t, version, Version(schedule, VariantExpired))
+	})
+
+	t.Run("期限切れや保護された画像は内容に依存しない", func(t *testing.T) {
+		changed := schedule.Clone()
+		changed.Comment = "別の予定"
+		assert.Equal(t, Version(schedule, VariantExpired), Version(changed, VariantExpired))
+		assert.Equal(t, Version(schedule, VariantProtected), Version(changed, VariantProtected))
+	})
+}
+
+func TestRenderer(t *testing.T) {
+	renderer := NewRenderer()
+
+	for name, variant := range map[string]Variant{
+		"有効":      VariantActive,
+		"期限切れ":    VariantExpired,
+		"パスワード保護": VariantProtected,
+	} {
+		t.Run(name+"の画像を描画できる", func(t *testing.T) {
+			data, err := renderer.Render(newTestSchedule(), variant)
+			require.NoError(t, err)
+
+			img, err := png.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, Width, img.Bounds().Dx())
+			assert.Equal(t, Height, img.Bounds().Dy())
+		})
+	}
+
+	t.Run("候補が無い・日をまたぐ・長いコメントでも描画できる", func(t *testing.T) {
+		schedule := newTestSchedule()
+		schedule.Comment = strings.Repeat("とても長いコメント", 20)
+		_, err := renderer.Render(schedule, VariantActive)
+		assert.NoError(t, err)
+
+		schedule.TimeSlots[0].EndTime = schedule.TimeSlots[0].StartTime.Add(20 * time.Hour)
+		_, err = renderer.Render(schedule, VariantActive)
+		assert.NoError(t, err)
+
+		schedule.TimeSlots = nil
+		_, err = renderer.Render(schedule, VariantActive)
+		assert.NoError(t, err)
+	})
+
+	t.Run("候補の期間を曜日付きで表示する", func(t *testing.T) {
+		slots := sortedSlots(newTestSchedule().TimeSlots)
+		assert.Equal(t, "10/20(火) 〜 10/22(木)", renderer.dateRange(slots))
+		assert.Equal(t, "10/20(火)", renderer.dateRange(slots[:1]))
+	})
+}
+
+func TestWrap(t *testing.T) {
+	f, err := parseFont()
+	require.NoError(t, err)
+	c := &canvas{font: f}
+
+	lines, err := c.wrap("短いコメント", 56, 620, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"短いコメント"}, lines)
+
+	lines, err = c.wrap(strings.Repeat("あ", 40), 56, 620, 2)
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[1], "…"))
+}
+
+func TestCoverage(t *testing.T) {
+	from := time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC)
+	slots := []model.TimeSlot{
+		{StartTime: from.Add(-30 * time.Minute), EndTime: from.Add(15 * time.Minute)},
+		{StartTime: from.Add(45 * time.Minute), EndTime: from.Add(2 * time.Hour)},
+	}
+	assert.InDelta(t, 0.5, coverage(slots, from, from.Add(time.Hour)), 0.001)
+	assert.InDelta(t, 1.0, coverage(slots, from.Add(time.Hour), from.Add(2*time.Hour)), 0.001)
+	assert.Zero(t, coverage(slots, from.Add(3*time.Hour), from.Add(4*time.Hour)))
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache(2)
+	cache.Add("a", []byte("A"))
+	cache.Add("b", []byte("B"))
+
+	// aを使うとbが最も古くなる
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+	cache.Add("c", []byte("C"))
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	data, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("A"), data)
+	assert.Equal(t, 2, cache.Len())
+}
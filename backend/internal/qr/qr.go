@@ -0,0 +1,89 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// 画像サイズ（px）の下限。これより小さいとスマートフォンのカメラで読み取りにくい
+const MinSize = 64
+
+// ErrInvalidLevel は誤り訂正レベルの指定が正しくない場合のエラー
+var ErrInvalidLevel = errors.New("qr: invalid error correction level")
+
+// Level はQRコードの誤り訂正レベル
+type Level = qrcode.RecoveryLevel
+
+const (
+	// LevelL は約7%の欠損を復元できる
+	LevelL Level = qrcode.Low
+	// LevelM は約15%の欠損を復元できる
+	LevelM Level = qrcode.Medium
+	// LevelQ は約25%の欠損を復元できる
+	LevelQ Level = qrcode.High
+	// LevelH は約30%の欠損を復元できる
+	LevelH Level = qrcode.Highest
+)
+
+// ParseLevel は誤り訂正レベルの指定を解釈する
+// L/M/Q/H の1文字、または low/medium/quartile/high を大文字小文字を区別せずに受け付ける
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "l", "low":
+		return LevelL, nil
+	case "m", "medium":
+		return LevelM, nil
+	case "q", "quartile":
+		return LevelQ, nil
+	case "h", "high":
+		return LevelH, nil
+	}
+	return 0, fmt.Errorf("%w: %q (want L, M, Q or H)", ErrInvalidLevel, s)
+}
+
+// PNG はcontentをエンコードしたsize×size pxのPNG画像を返す
+// 収まらないほど小さいsizeを指定した場合は1モジュール1pxの最小サイズになる
+func PNG(content string, level Level, size int) ([]byte, error) {
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("qr: %w", err)
+	}
+	return code.PNG(size)
+}
+
+// SVG はcontentをエンコードしたSVG画像を返す
+// 1モジュールを1単位とするviewBoxで描画するため、sizeに関わらず拡大しても輪郭がぼやけない
+func SVG(content string, level Level, size int) ([]byte, error) {
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("qr: %w", err)
+	}
+	bitmap := code.Bitmap()
+	modules := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, modules, modules)
+	buf.WriteString(`<path fill="#000" d="`)
+	for y, row := range bitmap {
+		// 横に連続する黒モジュールを1つの矩形にまとめてパスを短くする
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
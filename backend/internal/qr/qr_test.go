@@ -0,0 +1,85 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testURL = "https://kareru.example/schedule/0b6f8e1c-3a2d-4f5e-9a7b-1c2d3e4f5a6b"
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Level
+	}{
+		{"L", LevelL},
+		{"m", LevelM},
+		{"quartile", LevelQ},
+		{"HIGH", LevelH},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+
+	_, err := ParseLevel("X")
+	assert.ErrorIs(t, err, ErrInvalidLevel)
+	_, err = ParseLevel("")
+	assert.ErrorIs(t, err, ErrInvalidLevel)
+}
+
+func TestPNG(t *testing.T) {
+	t.Run("指定したサイズのPNGを返す", func(t *testing.T) {
+		data, err := PNG(testURL, LevelM, 256)
+		require.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, 256, img.Bounds().Dx())
+		assert.Equal(t, 256, img.Bounds().Dy())
+	})
+
+	t.Run("エンコードできない長さはエラーになる", func(t *testing.T) {
+		_, err := PNG(strings.Repeat("a", 4000), LevelH, 256)
+		assert.Error(t, err)
+	})
+}
+
+func TestSVG(t *testing.T) {
+	parse := func(t *testing.T, data []byte) (width, viewBox string) {
+		var svg struct {
+			Width   string `xml:"width,attr"`
+			ViewBox string `xml:"viewBox,attr"`
+		}
+		require.NoError(t, xml.Unmarshal(data, &svg))
+		return svg.Width, svg.ViewBox
+	}
+
+	t.Run("整形式のSVGを返す", func(t *testing.T) {
+		data, err := SVG(testURL, LevelM, 300)
+		require.NoError(t, err)
+
+		width, viewBox := parse(t, data)
+		assert.Equal(t, "300", width)
+		assert.True(t, strings.HasPrefix(viewBox, "0 0 "), viewBox)
+		assert.Contains(t, string(data), `<path fill="#000" d="M`)
+	})
+
+	t.Run("誤り訂正レベルが高いほどモジュール数が増える", func(t *testing.T) {
+		low, err := SVG(testURL, LevelL, 300)
+		require.NoError(t, err)
+		high, err := SVG(testURL, LevelH, 300)
+		require.NoError(t, err)
+
+		_, lowViewBox := parse(t, low)
+		_, highViewBox := parse(t, high)
+		assert.NotEqual(t, lowViewBox, highViewBox)
+	})
+}
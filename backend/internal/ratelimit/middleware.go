@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"kareru-backend/internal/handlers"
+)
+
+// 予算（バケット）の種類
+const (
+	BudgetCreate = "create"
+	BudgetRead   = "read"
+	BudgetEdit   = "edit"
+)
+
+// Middleware はクライアントIPごとにbudgetの予算でリクエストを制限するginミドルウェア
+// クライアントIPはgin.Context.ClientIPで判定するため、X-Forwarded-Forは
+// Engine.SetTrustedProxiesで信頼したプロキシからのものだけが使われる
+// ストアの障害時はリクエストを通し、エラーをログに残す
+func Middleware(store Store, budget string, limit Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := budget + ":" + c.ClientIP()
+		result, err := store.Allow(c.Request.Context(), key, limit)
+		if err != nil {
+			c.Error(fmt.Errorf("rate limit (%s): %w", budget, err))
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			handlers.TooManyRequests(c, "リクエストが多すぎます。しばらく待ってから再度お試しください", result.RetryAfter)
+			c.Abort()
+			return
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit はトークンバケットの設定
+type Limit struct {
+	// Rate は1秒あたりに補充されるトークン数
+	Rate float64
+	// Burst はバケットの容量（連続して許可されるリクエスト数）
+	Burst int
+}
+
+// PerMinute は1分あたりn回を平均レートとするLimitを返す
+func PerMinute(n float64, burst int) Limit {
+	return Limit{Rate: n / 60, Burst: burst}
+}
+
+// Result はリクエストを許可するかの判定結果
+type Result struct {
+	Allowed bool
+	// Remaining は判定後に残っているトークン数
+	Remaining int
+	// RetryAfter は拒否された場合に次のトークンが補充されるまでの時間
+	RetryAfter time.Duration
+}
+
+// Store はキーごとのトークンバケットを保持する
+// 複数インスタンスで予算を共有する場合はRedisなどの共有ストアを実装する
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take は現在のトークン数から1つ消費できるかを判定する（各ストアで共通の計算）
+func take(tokens float64, limit Limit) (float64, Result) {
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	if limit.Rate <= 0 {
+		return tokens, Result{RetryAfter: time.Duration(math.MaxInt64)}
+	}
+	wait := time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	return tokens, Result{RetryAfter: wait}
+}
+
+// refill は経過時間に応じてトークンを補充する（容量を超えない）
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed > 0 {
+		tokens += elapsed.Seconds() * limit.Rate
+	}
+	return math.Min(tokens, float64(limit.Burst))
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+// MemoryStore はプロセス内でバケットを保持するStore
+// 満杯になったバケットは状態を持つ必要がないため、定期的に削除してメモリを解放する
+type MemoryStore struct {
+	mu            sync.Mutex
+	buckets       map[string]*bucket
+	now           func() time.Time
+	cleanupEvery  time.Duration
+	lastCleanupAt time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:      make(map[string]*bucket),
+		now:          time.Now,
+		cleanupEvery: time.Minute,
+	}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.cleanup(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = refill(b.tokens, now.Sub(b.updatedAt), limit)
+	b.updatedAt = now
+
+	var result Result
+	b.tokens, result = take(b.tokens, limit)
+	return result, nil
+}
+
+// cleanup は補充済みで満杯になっているバケットを削除する
+func (s *MemoryStore) cleanup(now time.Time) {
+	if now.Sub(s.lastCleanupAt) < s.cleanupEvery {
+		return
+	}
+	s.lastCleanupAt = now
+	for key, b := range s.buckets {
+		if refill(b.tokens, now.Sub(b.updatedAt), b.limit) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Len は保持しているバケット数を返す
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
@@ -0,0 +1,224 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/handlers"
+)
+
+// fakeClock はテスト用の時計
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time          { return f.now }
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+// storeTests は全てのStore実装で共通の振る舞いを確認する
+func storeTests(t *testing.T, newStore func(clock *fakeClock) Store) {
+	ctx := context.Background()
+	limit := PerMinute(60, 3) // 1秒に1トークン、容量3
+
+	t.Run("容量までは許可し、超えたら補充までの時間を返す", func(t *testing.T) {
+		clock := newFakeClock()
+		store := newStore(clock)
+
+		for i := 2; i >= 0; i-- {
+			result, err := store.Allow(ctx, "create:1.2.3.4", limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, i, result.Remaining)
+		}
+
+		result, err := store.Allow(ctx, "create:1.2.3.4", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.InDelta(t, time.Second, result.RetryAfter, float64(10*time.Millisecond))
+	})
+
+	t.Run("時間経過でトークンが補充される", func(t *testing.T) {
+		clock := newFakeClock()
+		store := newStore(clock)
+
+		for i := 0; i < 3; i++ {
+			_, err := store.Allow(ctx, "key", limit)
+			require.NoError(t, err)
+		}
+		clock.Advance(1500 * time.Millisecond)
+
+		result, err := store.Allow(ctx, "key", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = store.Allow(ctx, "key", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.InDelta(t, 500*time.Millisecond, result.RetryAfter, float64(10*time.Millisecond))
+	})
+
+	t.Run("キーごとに独立して制限する", func(t *testing.T) {
+		clock := newFakeClock()
+		store := newStore(clock)
+
+		for i := 0; i < 3; i++ {
+			_, err := store.Allow(ctx, "edit:1.1.1.1", limit)
+			require.NoError(t, err)
+		}
+		result, err := store.Allow(ctx, "edit:2.2.2.2", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = store.Allow(ctx, "read:1.1.1.1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	storeTests(t, func(clock *fakeClock) Store {
+		store := NewMemoryStore()
+		store.now = clock.Now
+		return store
+	})
+
+	t.Run("満杯まで補充されたバケットは削除される", func(t *testing.T) {
+		clock := newFakeClock()
+		store := NewMemoryStore()
+		store.now = clock.Now
+		limit := PerMinute(60, 3)
+
+		_, err := store.Allow(context.Background(), "a", limit)
+		require.NoError(t, err)
+		_, err = store.Allow(context.Background(), "b", limit)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.Len())
+
+		clock.Advance(2 * time.Minute)
+		_, err = store.Allow(context.Background(), "c", limit)
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.Len())
+	})
+}
+
+func TestRedisStore(t *testing.T) {
+	storeTests(t, func(clock *fakeClock) Store {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		store := NewRedisStore(client)
+		store.now = clock.Now
+		return store
+	})
+
+	t.Run("バケットには補充にかかる時間のTTLが設定される", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		defer client.Close()
+		store := NewRedisStore(client)
+
+		_, err := store.Allow(context.Background(), "create:1.2.3.4", PerMinute(60, 3))
+		require.NoError(t, err)
+
+		assert.True(t, mr.Exists("kareru:ratelimit:create:1.2.3.4"))
+		assert.Equal(t, 4*time.Second, mr.TTL("kareru:ratelimit:create:1.2.3.4"))
+	})
+
+	t.Run("Redisに接続できない場合はエラーを返す", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		defer client.Close()
+		mr.Close()
+
+		_, err := NewRedisStore(client).Allow(context.Background(), "key", PerMinute(60, 3))
+		assert.Error(t, err)
+	})
+}
+
+// failingStore は常にエラーを返すStore
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
+
+func newTestRouter(t *testing.T, store Store, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(trustedProxies))
+	router.POST("/schedules", Middleware(store, BudgetCreate, PerMinute(6, 2)), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func post(router *gin.Engine, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/schedules", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("予算を超えると429とRetry-Afterを返す", func(t *testing.T) {
+		router := newTestRouter(t, NewMemoryStore(), nil)
+
+		assert.Equal(t, http.StatusCreated, post(router, "1.2.3.4:1000", "").Code)
+		w := post(router, "1.2.3.4:1000", "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+		w = post(router, "1.2.3.4:1000", "")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+		var response handlers.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "RATE_LIMITED", response.Code)
+		assert.Equal(t, 10, response.RetryAfter)
+		assert.NotEmpty(t, response.Message)
+	})
+
+	t.Run("信頼しないプロキシのX-Forwarded-Forは無視する", func(t *testing.T) {
+		router := newTestRouter(t, NewMemoryStore(), nil)
+
+		// X-Forwarded-Forを偽装しても接続元IPで制限される
+		post(router, "1.2.3.4:1000", "10.0.0.1")
+		post(router, "1.2.3.4:1000", "10.0.0.2")
+		assert.Equal(t, http.StatusTooManyRequests, post(router, "1.2.3.4:1000", "10.0.0.3").Code)
+	})
+
+	t.Run("信頼するプロキシ経由の場合はX-Forwarded-ForのクライアントIPで制限する", func(t *testing.T) {
+		router := newTestRouter(t, NewMemoryStore(), []string{"192.168.0.0/16"})
+
+		post(router, "192.168.1.1:1000", "203.0.113.1")
+		post(router, "192.168.1.1:1000", "203.0.113.1")
+		assert.Equal(t, http.StatusTooManyRequests, post(router, "192.168.1.1:1000", "203.0.113.1").Code)
+		assert.Equal(t, http.StatusCreated, post(router, "192.168.1.1:1000", "203.0.113.2").Code)
+	})
+
+	t.Run("ストアの障害時はリクエストを通す", func(t *testing.T) {
+		router := newTestRouter(t, failingStore{}, nil)
+		assert.Equal(t, http.StatusCreated, post(router, "1.2.3.4:1000", "").Code)
+	})
+}
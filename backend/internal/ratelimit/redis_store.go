@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript はバケットの補充と消費をRedis上で原子的に行う
+// 戻り値は {許可されたか(1/0), 残りトークン数(文字列)}
+var tokenBucketScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local tokens = tonumber(redis.call('HGET', KEYS[1], 'tokens'))
+local updated = tonumber(redis.call('HGET', KEYS[1], 'updated'))
+if tokens == nil or updated == nil then
+  tokens = burst
+  updated = now
+end
+if now > updated then
+  tokens = math.min(burst, tokens + (now - updated) * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore は複数インスタンスでバケットを共有するStore
+// 時刻は各インスタンスの時計を使うため、インスタンス間の時計は同期されている必要がある
+type RedisStore struct {
+	client goredis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+var _ Store = (*RedisStore)(nil)
+
+func NewRedisStore(client goredis.UniversalClient) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: "kareru:ratelimit:",
+		now:    time.Now,
+	}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := float64(s.now().UnixMicro()) / 1e6
+
+	// 満杯まで補充される時間が経てばバケットは不要になるため自動的に削除させる
+	ttl := time.Minute
+	if limit.Rate > 0 {
+		ttl = time.Duration(float64(limit.Burst)/limit.Rate*float64(time.Second)) + time.Second
+	}
+
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.Rate, limit.Burst, strconv.FormatFloat(now, 'f', 6, 64), ttl.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: %w", err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected script result %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: invalid token count %q: %w", tokensStr, err)
+	}
+
+	if allowed == 1 {
+		return Result{Allowed: true, Remaining: int(tokens)}, nil
+	}
+	// 拒否された場合はトークンが1未満のため、待ち時間だけを計算する
+	_, result := take(tokens, limit)
+	return result, nil
+}
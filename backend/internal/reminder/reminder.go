@@ -0,0 +1,91 @@
+// Package reminder は失効が近づいたスケジュールを定期的に探し、作成者への通知を送らせる
+package reminder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// Store は失効が近いスケジュールを探し、リマインダーの送信を記録できるリポジトリ
+type Store interface {
+	// ListExpiringBetween はExpiresAtがfromより後でto以前のスケジュールを返す
+	ListExpiringBetween(ctx context.Context, from, to time.Time) ([]*model.Schedule, error)
+	// ClaimReminder はスケジュールの現在のExpiresAtに対するリマインダーの送信を記録する
+	// 既に記録されている場合はfalseを返す（複数のインスタンスから呼ばれても1回だけtrueになる）
+	ClaimReminder(ctx context.Context, schedule *model.Schedule) (bool, error)
+}
+
+// Notifier はスケジュールのイベントの通知先（handlers.ScheduleNotifierと同じ形）
+type Notifier interface {
+	Notify(ctx context.Context, event model.ScheduleEvent, schedule *model.Schedule)
+}
+
+// Scanner は失効までlead以内になったスケジュールにEventScheduleExpiringSoonを1回だけ通知する
+//
+// 確認するたびに失効までlead以内のスケジュールを全て探し、送信の記録を確保できたものだけを通知する。
+// そのため停止していた間に通知する時刻を迎えたスケジュールにも再開後に通知され、
+// 複数のインスタンスで動かしても重複して通知されない。
+// 記録を確保してから通知するため、確保した直後に停止した場合はそのリマインダーは送られない
+type Scanner struct {
+	store    Store
+	notifier Notifier
+	lead     time.Duration
+	interval time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewScanner はintervalごとに確認するScannerを作成する
+func NewScanner(store Store, notifier Notifier, lead, interval time.Duration) *Scanner {
+	return &Scanner{
+		store:    store,
+		notifier: notifier,
+		lead:     lead,
+		interval: interval,
+		logger:   slog.Default(),
+		now:      time.Now,
+	}
+}
+
+// Run はctxが終了するまでintervalごとにScanを実行する
+func (s *Scanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Scan(ctx); err != nil {
+			// 失敗した場合も記録を確保できなかったスケジュールは次の確認で改めて探す
+			s.logger.ErrorContext(ctx, "failed to scan schedules for expiry reminders", slog.String("error", err.Error()))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan は失効までlead以内のスケジュールのうち、まだ通知していないものを通知する
+// 有効期間がleadより短いスケジュールは作成後の最初の確認で通知する
+func (s *Scanner) Scan(ctx context.Context) error {
+	now := s.now()
+	schedules, err := s.store.ListExpiringBetween(ctx, now, now.Add(s.lead))
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		claimed, err := s.store.ClaimReminder(ctx, schedule)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			s.notifier.Notify(ctx, model.EventScheduleExpiringSoon, schedule)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+// fakeStore はメモリ上でリマインダーの送信を記録するテスト用のストア
+type fakeStore struct {
+	schedules []*model.Schedule
+	claimed   map[string]bool
+	err       error
+	claimErr  error
+}
+
+func (f *fakeStore) ListExpiringBetween(_ context.Context, from, to time.Time) ([]*model.Schedule, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []*model.Schedule
+	for _, schedule := range f.schedules {
+		if schedule.ExpiresAt.After(from) && !schedule.ExpiresAt.After(to) {
+			result = append(result, schedule)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeStore) ClaimReminder(_ context.Context, schedule *model.Schedule) (bool, error) {
+	if f.claimErr != nil {
+		return false, f.claimErr
+	}
+	key := schedule.ID + "@" + schedule.ExpiresAt.String()
+	if f.claimed[key] {
+		return false, nil
+	}
+	f.claimed[key] = true
+	return true, nil
+}
+
+type recordingNotifier struct {
+	notified []string
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, event model.ScheduleEvent, schedule *model.Schedule) {
+	r.notified = append(r.notified, string(event)+":"+schedule.ID)
+}
+
+func TestScanner(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	schedule := func(id string, expiresIn time.Duration) *model.Schedule {
+		return &model.Schedule{ID: id, CreatedAt: now.Add(-7 * 24 * time.Hour), ExpiresAt: now.Add(expiresIn)}
+	}
+	store := &fakeStore{
+		schedules: []*model.Schedule{
+			schedule("due", 24*time.Hour-time.Minute),
+			schedule("later", 24*time.Hour+3*time.Minute),
+			// 停止していた間に通知する時刻を迎えたスケジュールにも通知する
+			schedule("missed", 6*time.Hour),
+			schedule("expired", -time.Hour),
+			// 有効期間が1日より短いスケジュールは作成後の最初の確認で通知する
+			{ID: "short", CreatedAt: now.Add(-2 * time.Minute), ExpiresAt: now.Add(time.Hour)},
+		},
+		claimed: map[string]bool{},
+	}
+	notifier := &recordingNotifier{}
+	scanner := NewScanner(store, notifier, 24*time.Hour, 5*time.Minute)
+	clock := now
+	scanner.now = func() time.Time { return clock }
+
+	require.NoError(t, scanner.Scan(context.Background()))
+	assert.Equal(t, []string{"schedule.expiring_soon:due", "schedule.expiring_soon:missed", "schedule.expiring_soon:short"}, notifier.notified)
+
+	// 同じスケジュールには1回だけ通知する
+	notifier.notified = nil
+	clock = now.Add(5 * time.Minute)
+	require.NoError(t, scanner.Scan(context.Background()))
+	assert.Equal(t, []string{"schedule.expiring_soon:later"}, notifier.notified)
+
+	// 他のインスタンスのScannerが同じストアを使っても重複して通知しない
+	other := &recordingNotifier{}
+	otherScanner := NewScanner(store, other, 24*time.Hour, 5*time.Minute)
+	otherScanner.now = scanner.now
+	require.NoError(t, otherScanner.Scan(context.Background()))
+	assert.Empty(t, other.notified)
+
+	// 失敗した場合は記録していないスケジュールを次の確認で通知する
+	notifier.notified = nil
+	store.err = errors.New("storage unavailable")
+	store.schedules = append(store.schedules, schedule("retry", 24*time.Hour+7*time.Minute))
+	clock = now.Add(10 * time.Minute)
+	assert.Error(t, scanner.Scan(context.Background()))
+	store.err = nil
+	store.claimErr = errors.New("storage unavailable")
+	assert.Error(t, scanner.Scan(context.Background()))
+	store.claimErr = nil
+	require.NoError(t, scanner.Scan(context.Background()))
+	assert.Equal(t, []string{"schedule.expiring_soon:retry"}, notifier.notified)
+
+	// 失効日時が延長された場合は新しい失効日時に対して改めて通知する
+	notifier.notified = nil
+	store.schedules[0] = schedule("due", 24*time.Hour+13*time.Minute)
+	require.NoError(t, scanner.Scan(context.Background()))
+	assert.Empty(t, notifier.notified)
+	clock = now.Add(15 * time.Minute)
+	require.NoError(t, scanner.Scan(context.Background()))
+	assert.Equal(t, []string{"schedule.expiring_soon:due"}, notifier.notified)
+}
@@ -1,38 +1,124 @@
 package routes
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"kareru-backend/internal/handlers"
 )
 
+// RouteOptions はルートごとに追加するミドルウェア
+type RouteOptions struct {
+	// Create はスケジュール作成に適用される
+	Create []gin.HandlerFunc
+	// Read はUUIDや短縮コードでの閲覧に適用される
+	Read []gin.HandlerFunc
+	// Edit は更新・削除と、編集トークンや閲覧パスワードを使う全てのルートに適用される
+	Edit []gin.HandlerFunc
+	// DisableLegacyEditRoutes が true の場合、パスに編集トークンを含む /edit/:token ルートを登録しない
+	DisableLegacyEditRoutes bool
+}
+
+// LegacyEditRoutesDeprecatedAt は /edit/:token ルートを非推奨にした日時
+// 編集トークンはX-Edit-TokenまたはAuthorizationヘッダーで /schedules/:uuid に渡す
+var LegacyEditRoutesDeprecatedAt = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+// deprecated は非推奨のルートであることをDeprecationヘッダー（RFC 9745）で伝える
+func deprecated(c *gin.Context) {
+	c.Header("Deprecation", "@"+strconv.FormatInt(LegacyEditRoutesDeprecatedAt.Unix(), 10))
+	c.Next()
+}
+
 // SetupRoutes はAPIルートを設定する
 func SetupRoutes(router *gin.Engine, scheduleHandler *handlers.ScheduleHandler) {
+	SetupRoutesWithOptions(router, scheduleHandler, RouteOptions{})
+}
+
+// SetupRoutesWithOptions はルートごとのミドルウェア（レート制限など）を指定してAPIルートを設定する
+func SetupRoutesWithOptions(router *gin.Engine, scheduleHandler *handlers.ScheduleHandler, opts RouteOptions) {
+	with := func(middleware []gin.HandlerFunc, handler gin.HandlerFunc) []gin.HandlerFunc {
+		return append(append([]gin.HandlerFunc{}, middleware...), handler)
+	}
+
 	// API v1 グループ
 	v1 := router.Group("/api/v1")
 	{
 		// スケジュール関連のルート
 		schedules := v1.Group("/schedules")
 		{
-			schedules.POST("", scheduleHandler.CreateSchedule)
-			schedules.GET("/:uuid", scheduleHandler.GetSchedule)
-			schedules.PUT("/:uuid", scheduleHandler.UpdateSchedule)
-			schedules.DELETE("/:uuid", scheduleHandler.DeleteSchedule)
-			
-			// 編集トークンベースのエンドポイント
-			edit := schedules.Group("/edit")
-			{
-				edit.GET("/:token", scheduleHandler.GetScheduleByEditToken)
-				edit.PUT("/:token", scheduleHandler.UpdateScheduleByEditToken)
-				edit.DELETE("/:token", scheduleHandler.DeleteScheduleByEditToken)
+			schedules.POST("", with(opts.Create, scheduleHandler.CreateSchedule)...)
+			// .icsファイルの予定から空き時間を計算して作成する
+			schedules.POST("/import", with(opts.Create, scheduleHandler.ImportSchedule)...)
+			schedules.GET("/:uuid", with(opts.Read, scheduleHandler.GetSchedule)...)
+			schedules.PUT("/:uuid", with(opts.Edit, scheduleHandler.UpdateSchedule)...)
+			schedules.DELETE("/:uuid", with(opts.Edit, scheduleHandler.DeleteSchedule)...)
+			// 共有URLのQRコード（会議で投影する用途）
+			schedules.GET("/:uuid/qr.png", with(opts.Read, scheduleHandler.ScheduleQRPNG)...)
+			schedules.GET("/:uuid/qr.svg", with(opts.Read, scheduleHandler.ScheduleQRSVG)...)
+			// リンクプレビュー用のOGP画像
+			schedules.GET("/:uuid/og.png", with(opts.Read, scheduleHandler.ScheduleOGImage)...)
+			// カレンダーアプリで購読するiCalendarフィード（webcal://でも参照される）
+			schedules.GET("/:uuid/feed.ics", with(opts.Read, scheduleHandler.ScheduleFeed)...)
+			schedules.HEAD("/:uuid/feed.ics", with(opts.Read, scheduleHandler.ScheduleFeed)...)
+			// 閲覧者の回答（Webhookへの配信を伴うため、作成と同じCreateの予算）
+			schedules.POST("/:uuid/responses", with(opts.Create, scheduleHandler.SubmitResponse)...)
+			// 閲覧パスワードの検証（パスワードの総当たりを防ぐためEditの予算）
+			schedules.POST("/:uuid/unlock", with(opts.Edit, scheduleHandler.UnlockSchedule)...)
+
+			// 編集トークンをパスに含む旧エンドポイント（トークンの総当たりを防ぐため全てEditの予算）
+			// トークンでスケジュールを引くため、スケジュールごとの検証失敗のロックは適用されない
+			// トークンがプロキシのログやブラウザの履歴に残るため非推奨
+			if !opts.DisableLegacyEditRoutes {
+				edit := schedules.Group("/edit", deprecated)
+				{
+					edit.GET("/:token", with(opts.Edit, scheduleHandler.GetScheduleByEditToken)...)
+					edit.PUT("/:token", with(opts.Edit, scheduleHandler.UpdateScheduleByEditToken)...)
+					edit.DELETE("/:token", with(opts.Edit, scheduleHandler.DeleteScheduleByEditToken)...)
+				}
 			}
 		}
 	}
 
-	// ヘルスチェック
+	// 短縮コードでの共有URL（UUIDでの閲覧と同じReadの予算）
+	router.GET("/s/:code", with(opts.Read, scheduleHandler.ResolveShortCode)...)
+
+	// 読み取り専用のCalDAVカレンダーコレクション（カレンダーアプリにカレンダーとして追加する用途）
+	// クライアントによって末尾の/の有無が異なるため、リダイレクトさせずに両方で応答する
+	calendar := router.Group("/caldav/schedules/:uuid")
+	{
+		for _, path := range []string{"", "/"} {
+			calendar.OPTIONS(path, with(opts.Read, scheduleHandler.CalDAVOptions)...)
+			calendar.Handle("PROPFIND", path, with(opts.Read, scheduleHandler.CalDAVPropfind)...)
+			calendar.Handle("REPORT", path, with(opts.Read, scheduleHandler.CalDAVReport)...)
+			calendar.GET(path, with(opts.Read, scheduleHandler.ScheduleFeed)...)
+			calendar.HEAD(path, with(opts.Read, scheduleHandler.ScheduleFeed)...)
+		}
+		// タイムスロットごとのカレンダーオブジェクト
+		calendar.OPTIONS("/:resource", with(opts.Read, scheduleHandler.CalDAVOptions)...)
+		calendar.Handle("PROPFIND", "/:resource", with(opts.Read, scheduleHandler.CalDAVPropfind)...)
+		calendar.GET("/:resource", with(opts.Read, scheduleHandler.CalDAVGetObject)...)
+		calendar.HEAD("/:resource", with(opts.Read, scheduleHandler.CalDAVGetObject)...)
+	}
+
+	// ヘルスチェック（後方互換のため残している。デプロイ先のプローブには /livez, /readyz を使う）
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"status":  "ok",
 			"message": "Kareru backend is running",
 		})
 	})
-}
\ No newline at end of file
+}
+
+// SetupHealthRoutes はliveness/readinessのルートを設定する
+func SetupHealthRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler) {
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+}
+
+// SetupMetricsRoutes はPrometheusのメトリクス公開用のルートを設定する
+// 公開用のルーターではなく、管理用のアドレスで待ち受けるルーターに設定する
+func SetupMetricsRoutes(router *gin.Engine, metricsHandler http.Handler) {
+	router.GET("/metrics", gin.WrapH(metricsHandler))
+}
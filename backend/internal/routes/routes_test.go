@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"kareru-backend/internal/domain/model"
 	"kareru-backend/internal/handlers"
+	"kareru-backend/internal/infrastructure/repository"
 )
 
 // MockScheduleRepository はテスト用のモックリポジトリ
@@ -68,6 +71,18 @@ func (m *MockScheduleRepository) GetByEditToken(token string) (*model.Schedule,
 	return nil, nil
 }
 
+func (m *MockScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	for _, schedule := range m.schedules {
+		if code != "" && schedule.ShortCode == code {
+			return schedule, nil
+		}
+	}
+	return nil, model.ErrScheduleNotFound
+}
+
 func (m *MockScheduleRepository) Delete(id string) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
@@ -194,4 +209,232 @@ func TestScheduleAPIIntegration(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "ok")
 	})
-}
\ No newline at end of file
+}
+
+func TestScheduleAPIConcurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// メモリリポジトリを使い、go test -race でデータ競合が無いことを確認する
+	setup := func(t *testing.T) (*gin.Engine, handlers.CreateScheduleResponse) {
+		router := gin.New()
+		scheduleHandler := handlers.NewScheduleHandler(repository.NewMemoryScheduleRepository())
+		SetupRoutes(router, scheduleHandler)
+
+		now := time.Now()
+		createReq := handlers.CreateScheduleRequest{
+			TimeSlots: []handlers.TimeSlotRequest{
+				{StartTime: now.Add(1 * time.Hour), EndTime: now.Add(2 * time.Hour)},
+			},
+			Comment: "並行アクセステスト",
+		}
+		body, _ := json.Marshal(createReq)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/schedules", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var createResp handlers.CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &createResp))
+		return router, createResp
+	}
+
+	t.Run("GETとPUTを並行して実行してもデータ競合が発生しない", func(t *testing.T) {
+		router, created := setup(t)
+		now := time.Now()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/schedules/"+created.ID, nil)
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, req)
+					assert.Equal(t, http.StatusOK, w.Code)
+				}
+			}()
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					updateReq := handlers.UpdateScheduleRequest{
+						EditToken: created.EditToken,
+						TimeSlots: []handlers.TimeSlotRequest{
+							{StartTime: now.Add(time.Duration(i+1) * time.Hour), EndTime: now.Add(time.Duration(i+2) * time.Hour)},
+						},
+						Comment: "並行更新",
+					}
+					body, _ := json.Marshal(updateReq)
+					req := httptest.NewRequest(http.MethodPut, "/api/v1/schedules/"+created.ID, bytes.NewBuffer(body))
+					req.Header.Set("Content-Type", "application/json")
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, req)
+					assert.Equal(t, http.StatusOK, w.Code)
+				}
+			}(i)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					updateReq := handlers.UpdateScheduleByEditTokenRequest{
+						TimeSlots: []handlers.EditTimeSlotRequest{
+							{StartTime: now.Add(3 * time.Hour), EndTime: now.Add(4 * time.Hour), Available: true},
+						},
+						Comment: "トークン経由の並行更新",
+					}
+					body, _ := json.Marshal(updateReq)
+					req := httptest.NewRequest(http.MethodPut, "/api/v1/schedules/edit/"+created.EditToken, bytes.NewBuffer(body))
+					req.Header.Set("Content-Type", "application/json")
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, req)
+					assert.Equal(t, http.StatusOK, w.Code)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("バリデーションに失敗した更新は保存内容を変更しない", func(t *testing.T) {
+		router, created := setup(t)
+		now := time.Now()
+
+		// 重複したタイムスロットはバリデーションエラーになる
+		updateReq := handlers.UpdateScheduleByEditTokenRequest{
+			TimeSlots: []handlers.EditTimeSlotRequest{
+				{StartTime: now.Add(1 * time.Hour), EndTime: now.Add(3 * time.Hour)},
+				{StartTime: now.Add(2 * time.Hour), EndTime: now.Add(4 * time.Hour)},
+			},
+			Comment: "保存されないコメント",
+		}
+		body, _ := json.Marshal(updateReq)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/schedules/edit/"+created.EditToken, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/schedules/"+created.ID, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var getResp handlers.GetScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &getResp))
+		assert.Equal(t, "並行アクセステスト", getResp.Comment)
+		assert.Len(t, getResp.TimeSlots, 1)
+	})
+}
+
+func TestSetupHealthRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	healthHandler := handlers.NewHealthHandler(time.Second)
+	healthHandler.AddCheck("memory", repository.NewMemoryScheduleRepository())
+	SetupHealthRoutes(router, healthHandler)
+
+	for _, path := range []string{"/livez", "/readyz"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"status":"ok"`, path)
+	}
+}
+
+func TestSetupRoutesWithOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var applied []string
+	mark := func(name string) []gin.HandlerFunc {
+		return []gin.HandlerFunc{func(c *gin.Context) {
+			applied = append(applied, name)
+			c.AbortWithStatus(http.StatusTooManyRequests)
+		}}
+	}
+
+	router := gin.New()
+	SetupRoutesWithOptions(router, handlers.NewScheduleHandler(NewMockScheduleRepository()), RouteOptions{
+		Create: mark("create"),
+		Read:   mark("read"),
+		Edit:   mark("edit"),
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodPost, "/api/v1/schedules", "create"},
+		{http.MethodPost, "/api/v1/schedules/import", "create"},
+		{http.MethodGet, "/api/v1/schedules/uuid-1", "read"},
+		{http.MethodPut, "/api/v1/schedules/uuid-1", "edit"},
+		{http.MethodDelete, "/api/v1/schedules/uuid-1", "edit"},
+		{http.MethodPost, "/api/v1/schedules/uuid-1/unlock", "edit"},
+		{http.MethodPost, "/api/v1/schedules/uuid-1/responses", "create"},
+		{http.MethodGet, "/api/v1/schedules/uuid-1/qr.png", "read"},
+		{http.MethodGet, "/api/v1/schedules/uuid-1/qr.svg", "read"},
+		{http.MethodGet, "/api/v1/schedules/uuid-1/og.png", "read"},
+		{http.MethodGet, "/api/v1/schedules/uuid-1/feed.ics", "read"},
+		{http.MethodHead, "/api/v1/schedules/uuid-1/feed.ics", "read"},
+		{http.MethodGet, "/s/7K3M9QX2", "read"},
+		{http.MethodOptions, "/caldav/schedules/uuid-1/", "read"},
+		{"PROPFIND", "/caldav/schedules/uuid-1", "read"},
+		{"PROPFIND", "/caldav/schedules/uuid-1/", "read"},
+		{"REPORT", "/caldav/schedules/uuid-1/", "read"},
+		{http.MethodGet, "/caldav/schedules/uuid-1/", "read"},
+		{"PROPFIND", "/caldav/schedules/uuid-1/20261020T010000Z.ics", "read"},
+		{http.MethodGet, "/caldav/schedules/uuid-1/20261020T010000Z.ics", "read"},
+		{http.MethodGet, "/api/v1/schedules/edit/token-1", "edit"},
+		{http.MethodPut, "/api/v1/schedules/edit/token-1", "edit"},
+		{http.MethodDelete, "/api/v1/schedules/edit/token-1", "edit"},
+	}
+	for _, tt := range tests {
+		applied = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "%s %s", tt.method, tt.path)
+		assert.Equal(t, []string{tt.want}, applied, "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestLegacyEditRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(opts RouteOptions) *gin.Engine {
+		repo := NewMockScheduleRepository()
+		repo.schedules["uuid-1"] = &model.Schedule{
+			ID:        "uuid-1",
+			EditToken: "token-1",
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		router := gin.New()
+		SetupRoutesWithOptions(router, handlers.NewScheduleHandler(repo), opts)
+		return router
+	}
+
+	t.Run("旧ルートはDeprecationヘッダー付きで応答する", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newRouter(RouteOptions{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/schedules/edit/token-1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	})
+
+	t.Run("無効にすると旧ルートは404になり、ヘッダーでのトークン指定は使える", func(t *testing.T) {
+		router := newRouter(RouteOptions{DisableLegacyEditRoutes: true})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/schedules/edit/token-1", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/schedules/uuid-1", nil)
+		req.Header.Set(handlers.EditTokenHeader, "token-1")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Deprecation"))
+	})
+}
@@ -0,0 +1,73 @@
+package security
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRouter(cfg HeadersConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Headers(cfg))
+	router.GET("/api/v1/schedules/:uuid", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("uuid")})
+	})
+	return router
+}
+
+func TestHeaders(t *testing.T) {
+	cfg := HeadersConfig{HSTSMaxAge: 180 * 24 * time.Hour}
+
+	t.Run("APIのレスポンスにセキュリティヘッダーを付与する", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newTestRouter(cfg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/schedules/uuid-1", nil))
+
+		assert.Equal(t, APIContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		// HTTPではHSTSを付与しない
+		assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("ルートが見つからない場合も付与する", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newTestRouter(cfg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	})
+
+	t.Run("TLSで受けた場合はHSTSを付与する", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/schedules/uuid-1", nil)
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		newTestRouter(cfg).ServeHTTP(w, req)
+
+		assert.Equal(t, "max-age=15552000", w.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("TLS終端のプロキシ経由の場合はHSTSを付与する", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/schedules/uuid-1", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		newTestRouter(HeadersConfig{HSTSMaxAge: time.Hour, HSTSIncludeSubdomains: true}).ServeHTTP(w, req)
+
+		assert.Equal(t, "max-age=3600; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("max-ageが0の場合はHSTSを付与しない", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/schedules/uuid-1", nil)
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		newTestRouter(HeadersConfig{}).ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+	})
+}
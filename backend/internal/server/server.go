@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"kareru-backend/internal/config"
+)
+
+// shutdownHook はシャットダウン時に実行する後処理
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Server はタイムアウト付きのHTTPサーバーとシャットダウン処理をまとめたもの
+// コンテキストがキャンセルされると新規接続の受け付けを止め、処理中のリクエストを
+// 待ってから登録された後処理（ワーカー停止・クライアント切断など）を実行する
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+
+	mu     sync.Mutex
+	drains []func()
+	hooks  []shutdownHook
+}
+
+// New は設定に従ってサーバーを作成する
+func New(handler http.Handler, cfg config.ServerConfig) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		drainDelay:      cfg.DrainDelay,
+	}
+}
+
+// OnDrain はシャットダウン開始直後（新規接続の受け付けを止める前）に呼ぶ関数を登録する
+// readinessを落としてロードバランサーに振り分けを止めさせるために使う
+func (s *Server) OnDrain(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drains = append(s.drains, fn)
+}
+
+// OnShutdown はHTTPサーバー停止後に実行する後処理を登録する
+// deferと同じく登録と逆順に実行されるため、依存先（ストレージなど）を先に登録する
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Run は設定されたアドレスで待ち受け、ctxがキャンセルされるまでリクエストを処理する
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return errors.Join(err, s.runHooks(context.Background()))
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve は指定されたリスナーでリクエストを処理し、ctxがキャンセルされたら
+// シャットダウンを行う。シャットダウン時のエラーはまとめて返す
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// 起動直後の失敗などでサーバーが停止した場合も後処理は行う
+		return errors.Join(err, s.runHooks(context.Background()))
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	drains := s.drains
+	s.mu.Unlock()
+	for _, drain := range drains {
+		drain()
+	}
+	if s.drainDelay > 0 {
+		log.Printf("Waiting %s before closing listeners", s.drainDelay)
+		time.Sleep(s.drainDelay)
+	}
+
+	log.Printf("Shutting down server (draining up to %s)", s.shutdownTimeout)
+	shutdownCtx, cancel := s.shutdownContext()
+	defer cancel()
+
+	var errs []error
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+		// 猶予時間内に終わらなかった接続は強制的に閉じる
+		s.httpServer.Close()
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+	errs = append(errs, s.runHooks(shutdownCtx))
+
+	return errors.Join(errs...)
+}
+
+func (s *Server) shutdownContext() (context.Context, context.CancelFunc) {
+	if s.shutdownTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.shutdownTimeout)
+}
+
+func (s *Server) runHooks(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if err := hook.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+			continue
+		}
+		log.Printf("Stopped %s", hook.name)
+	}
+	return errors.Join(errs...)
+}
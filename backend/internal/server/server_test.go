@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/config"
+)
+
+func testServerConfig() config.ServerConfig {
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = 2 * time.Second
+	return cfg
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return ln
+}
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	t.Run("処理中のリクエストを完了させてから後処理を逆順に実行する", func(t *testing.T) {
+		started := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("done"))
+		})
+
+		srv := New(handler, testServerConfig())
+		var mu sync.Mutex
+		var order []string
+		record := func(name string) func(context.Context) error {
+			return func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			}
+		}
+		srv.OnShutdown("storage", record("storage"))
+		srv.OnShutdown("worker", record("worker"))
+
+		ln := listen(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		serveErr := make(chan error, 1)
+		go func() { serveErr <- srv.Serve(ctx, ln) }()
+
+		type result struct {
+			body string
+			err  error
+		}
+		resCh := make(chan result, 1)
+		go func() {
+			resp, err := http.Get("http://" + ln.Addr().String())
+			if err != nil {
+				resCh <- result{err: err}
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			resCh <- result{body: string(body), err: err}
+		}()
+
+		<-started
+		cancel()
+
+		res := <-resCh
+		require.NoError(t, res.err)
+		assert.Equal(t, "done", res.body)
+		require.NoError(t, <-serveErr)
+		assert.Equal(t, []string{"worker", "storage"}, order)
+
+		// シャットダウン後は新しい接続を受け付けない
+		_, err := http.Get("http://" + ln.Addr().String())
+		assert.Error(t, err)
+	})
+
+	t.Run("猶予時間を超えたリクエストは打ち切りエラーを返す", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		})
+
+		cfg := testServerConfig()
+		cfg.ShutdownTimeout = 50 * time.Millisecond
+		srv := New(handler, cfg)
+		hookCalled := false
+		srv.OnShutdown("storage", func(context.Context) error {
+			hookCalled = true
+			return nil
+		})
+
+		ln := listen(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		serveErr := make(chan error, 1)
+		go func() { serveErr <- srv.Serve(ctx, ln) }()
+		go http.Get("http://" + ln.Addr().String())
+
+		<-started
+		cancel()
+
+		err := <-serveErr
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, hookCalled, "猶予時間を超えても後処理は実行される")
+	})
+
+	t.Run("後処理のエラーは名前付きでまとめて返す", func(t *testing.T) {
+		srv := New(http.NotFoundHandler(), testServerConfig())
+		srv.OnShutdown("storage", func(context.Context) error { return errors.New("close failed") })
+		srv.OnShutdown("worker", func(context.Context) error { return nil })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := srv.Serve(ctx, listen(t))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "storage: close failed")
+	})
+
+	t.Run("待ち受けに失敗した場合も後処理を実行する", func(t *testing.T) {
+		ln := listen(t)
+		defer ln.Close()
+
+		cfg := testServerConfig()
+		cfg.Addr = ln.Addr().String()
+		srv := New(http.NotFoundHandler(), cfg)
+		hookCalled := false
+		srv.OnShutdown("storage", func(context.Context) error {
+			hookCalled = true
+			return nil
+		})
+
+		err := srv.Run(context.Background())
+		assert.Error(t, err)
+		assert.True(t, hookCalled)
+	})
+}
+
+func TestServer_OnDrain(t *testing.T) {
+	cfg := testServerConfig()
+	cfg.DrainDelay = 100 * time.Millisecond
+
+	// 待機中も新しいリクエストを処理できることを確認する
+	drained := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-drained:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	srv := New(handler, cfg)
+	srv.OnDrain(func() { close(drained) })
+
+	ln := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ctx, ln) }()
+
+	cancel()
+	<-drained
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	require.NoError(t, <-serveErr)
+}
+
+func TestNew(t *testing.T) {
+	cfg := testServerConfig()
+	srv := New(http.NotFoundHandler(), cfg)
+
+	assert.Equal(t, cfg.Addr, srv.httpServer.Addr)
+	assert.Equal(t, cfg.ReadTimeout, srv.httpServer.ReadTimeout)
+	assert.Equal(t, cfg.ReadHeaderTimeout, srv.httpServer.ReadHeaderTimeout)
+	assert.Equal(t, cfg.WriteTimeout, srv.httpServer.WriteTimeout)
+	assert.Equal(t, cfg.IdleTimeout, srv.httpServer.IdleTimeout)
+}
@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+)
+
+// ScheduleIDKey はスパンに記録するスケジュールIDの属性キー
+// 編集トークンは秘密情報のため属性には含めない
+const ScheduleIDKey = attribute.Key("kareru.schedule.id")
+
+// TracedScheduleRepository はリポジトリの各操作をスパンで囲むデコレーター
+// WithContextで渡されたリクエストのコンテキストを親スパンとして使う
+type TracedScheduleRepository struct {
+	repo    handlers.ScheduleRepository
+	backend string
+	tracer  trace.Tracer
+	ctx     context.Context
+}
+
+var (
+	_ handlers.ScheduleRepository = (*TracedScheduleRepository)(nil)
+	_ handlers.ContextBinder      = (*TracedScheduleRepository)(nil)
+)
+
+// NewTracedScheduleRepository はbackendを属性として操作をトレースするリポジトリを作成する
+func NewTracedScheduleRepository(repo handlers.ScheduleRepository, backend string, provider trace.TracerProvider) *TracedScheduleRepository {
+	return &TracedScheduleRepository{
+		repo:    repo,
+		backend: backend,
+		tracer:  provider.Tracer(instrumentationName),
+		ctx:     context.Background(),
+	}
+}
+
+// WithContext はctxを親とするスパンを作成するリポジトリを返す
+func (r *TracedScheduleRepository) WithContext(ctx context.Context) handlers.ScheduleRepository {
+	bound := *r
+	bound.ctx = ctx
+	return &bound
+}
+
+func (r *TracedScheduleRepository) start(operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", r.backend), attribute.String("db.operation.name", operation))
+	return r.tracer.Start(r.ctx, "ScheduleRepository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end はエラーをスパンに記録して終了する（not foundは正常な結果として扱う）
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, model.ErrScheduleNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// inner は下位のリポジトリにもコンテキストを引き継ぐ
+func (r *TracedScheduleRepository) inner(ctx context.Context) handlers.ScheduleRepository {
+	if binder, ok := r.repo.(handlers.ContextBinder); ok {
+		return binder.WithContext(ctx)
+	}
+	return r.repo
+}
+
+func (r *TracedScheduleRepository) Create(schedule *model.Schedule) error {
+	ctx, span := r.start("Create", ScheduleIDKey.String(schedule.ID))
+	err := r.inner(ctx).Create(schedule)
+	end(span, err)
+	return err
+}
+
+func (r *TracedScheduleRepository) GetByID(id string) (*model.Schedule, error) {
+	ctx, span := r.start("GetByID", ScheduleIDKey.String(id))
+	schedule, err := r.inner(ctx).GetByID(id)
+	end(span, err)
+	return schedule, err
+}
+
+func (r *TracedScheduleRepository) GetByEditToken(token string) (*model.Schedule, error) {
+	ctx, span := r.start("GetByEditToken")
+	schedule, err := r.inner(ctx).GetByEditToken(token)
+	if schedule != nil {
+		span.SetAttributes(ScheduleIDKey.String(schedule.ID))
+	}
+	end(span, err)
+	return schedule, err
+}
+
+func (r *TracedScheduleRepository) GetByShortCode(code string) (*model.Schedule, error) {
+	ctx, span := r.start("GetByShortCode")
+	schedule, err := r.inner(ctx).GetByShortCode(code)
+	if schedule != nil {
+		span.SetAttributes(ScheduleIDKey.String(schedule.ID))
+	}
+	end(span, err)
+	return schedule, err
+}
+
+func (r *TracedScheduleRepository) Update(schedule *model.Schedule) error {
+	ctx, span := r.start("Update", ScheduleIDKey.String(schedule.ID))
+	err := r.inner(ctx).Update(schedule)
+	end(span, err)
+	return err
+}
+
+func (r *TracedScheduleRepository) Delete(id string) error {
+	ctx, span := r.start("Delete", ScheduleIDKey.String(id))
+	err := r.inner(ctx).Delete(id)
+	end(span, err)
+	return err
+}
+
+// Ping は下位のリポジトリの疎通確認をそのまま呼び出す（ヘルスチェックはトレースしない）
+func (r *TracedScheduleRepository) Ping(ctx context.Context) error {
+	if pinger, ok := r.repo.(handlers.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"kareru-backend/internal/config"
+	"kareru-backend/internal/domain/model"
+	"kareru-backend/internal/handlers"
+	"kareru-backend/internal/infrastructure/repository"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newRecorder() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return recorder, provider
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func findSpan(t *testing.T, spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not found", name)
+	return nil
+}
+
+func newTestRouter(provider trace.TracerProvider, repo handlers.ScheduleRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(provider))
+
+	handler := handlers.NewScheduleHandler(NewTracedScheduleRepository(repo, "memory", provider))
+	router.GET("/api/v1/schedules/:uuid", handler.GetSchedule)
+	router.GET("/api/v1/schedules/edit/:token", handler.GetScheduleByEditToken)
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("ルートテンプレートでサーバースパンを作成し、traceparentを親にする", func(t *testing.T) {
+		recorder, provider := newRecorder()
+		repo := repository.NewMemoryScheduleRepository()
+		require.NoError(t, repo.Create(&model.Schedule{ID: "schedule-1", EditToken: "secret-token", ExpiresAt: time.Now().Add(time.Hour)}))
+		router := newTestRouter(provider, repo)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/schedules/schedule-1", nil)
+		req.Header.Set("traceparent", testTraceparent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+
+		server := findSpan(t, spans, "GET /api/v1/schedules/:uuid")
+		assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+		attrs := spanAttributes(server)
+		assert.Equal(t, "/api/v1/schedules/:uuid", attrs["http.route"].AsString())
+		assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+
+		repoSpan := findSpan(t, spans, "ScheduleRepository.GetByID")
+		assert.Equal(t, server.SpanContext().SpanID(), repoSpan.Parent().SpanID())
+		assert.Equal(t, server.SpanContext().TraceID(), repoSpan.SpanContext().TraceID())
+		assert.Equal(t, "schedule-1", spanAttributes(repoSpan)[ScheduleIDKey].AsString())
+	})
+
+	t.Run("traceparentがない場合は新しいトレースを開始する", func(t *testing.T) {
+		recorder, provider := newRecorder()
+		router := newTestRouter(provider, repository.NewMemoryScheduleRepository())
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/schedules/missing", nil))
+
+		server := findSpan(t, recorder.Ended(), "GET /api/v1/schedules/:uuid")
+		assert.False(t, server.Parent().IsValid())
+	})
+
+	t.Run("5xxのレスポンスはスパンをエラーにする", func(t *testing.T) {
+		recorder, provider := newRecorder()
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(Middleware(provider))
+		router.GET("/boom", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+		span := findSpan(t, recorder.Ended(), "GET /boom")
+		assert.Equal(t, codes.Error, span.Status().Code)
+	})
+}
+
+func TestTracedScheduleRepository(t *testing.T) {
+	t.Run("編集トークンは属性やスパン名に含めない", func(t *testing.T) {
+		recorder, provider := newRecorder()
+		repo := repository.NewMemoryScheduleRepository()
+		require.NoError(t, repo.Create(&model.Schedule{ID: "schedule-1", EditToken: "secret-token", ExpiresAt: time.Now().Add(time.Hour)}))
+		router := newTestRouter(provider, repo)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/schedules/edit/secret-token", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		spans := recorder.Ended()
+		for _, span := range spans {
+			assert.NotContains(t, span.Name(), "secret-token")
+			for _, kv := range span.Attributes() {
+				assert.NotContains(t, kv.Value.Emit(), "secret-token", "attribute %s", kv.Key)
+			}
+		}
+
+		repoSpan := findSpan(t, spans, "ScheduleRepository.GetByEditToken")
+		assert.Equal(t, "schedule-1", spanAttributes(repoSpan)[ScheduleIDKey].AsString())
+	})
+
+	t.Run("not foundはエラーとして記録しない", func(t *testing.T) {
+		recorder, provider := newRecorder()
+		repo := NewTracedScheduleRepository(repository.NewMemoryScheduleRepository(), "memory", provider)
+
+		_, err := repo.WithContext(context.Background()).GetByID("missing")
+		assert.ErrorIs(t, err, model.ErrScheduleNotFound)
+
+		span := findSpan(t, recorder.Ended(), "ScheduleRepository.GetByID")
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Equal(t, "memory", spanAttributes(span)["db.system"].AsString())
+	})
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("noneの場合はスパンを記録しない", func(t *testing.T) {
+		provider, shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: config.TracingExporterNone})
+		require.NoError(t, err)
+		defer shutdown(context.Background())
+
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		assert.False(t, span.SpanContext().IsValid())
+	})
+
+	t.Run("stdoutエクスポーターを作成できる", func(t *testing.T) {
+		provider, shutdown, err := Setup(context.Background(), config.TracingConfig{
+			Exporter:    config.TracingExporterStdout,
+			ServiceName: "kareru-test",
+			SampleRatio: 0,
+		})
+		require.NoError(t, err)
+		assert.IsType(t, &sdktrace.TracerProvider{}, provider)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+}
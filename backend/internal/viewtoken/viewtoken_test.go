@@ -0,0 +1,51 @@
+package viewtoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSigner(now *time.Time) *Signer {
+	signer := NewSigner([]byte("test-key-0123456789abcdef0123456"), 15*time.Minute)
+	signer.now = func() time.Time { return *now }
+	return signer
+}
+
+func TestSigner(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	signer := newTestSigner(&now)
+
+	token, expiresAt := signer.Issue("schedule-1", "hash-1")
+	assert.Equal(t, now.Add(15*time.Minute), expiresAt)
+
+	t.Run("発行したスケジュールとバインドした値で検証できる", func(t *testing.T) {
+		assert.NoError(t, signer.Verify(token, "schedule-1", "hash-1"))
+	})
+
+	t.Run("別のスケジュールやパスワード変更後は検証できない", func(t *testing.T) {
+		assert.ErrorIs(t, signer.Verify(token, "schedule-2", "hash-1"), ErrInvalid)
+		assert.ErrorIs(t, signer.Verify(token, "schedule-1", "hash-2"), ErrInvalid)
+	})
+
+	t.Run("別の鍵で署名されたトークンは検証できない", func(t *testing.T) {
+		other := NewSigner([]byte("another-key"), 15*time.Minute)
+		other.now = signer.now
+		forged, _ := other.Issue("schedule-1", "hash-1")
+		assert.ErrorIs(t, signer.Verify(forged, "schedule-1", "hash-1"), ErrInvalid)
+	})
+
+	t.Run("有効期限を書き換えると検証できない", func(t *testing.T) {
+		_, signature, _ := strings.Cut(token, ".")
+		assert.ErrorIs(t, signer.Verify("9999999999."+signature, "schedule-1", "hash-1"), ErrInvalid)
+		assert.ErrorIs(t, signer.Verify("garbage", "schedule-1", "hash-1"), ErrInvalid)
+	})
+
+	t.Run("有効期限を過ぎると期限切れになる", func(t *testing.T) {
+		later := now.Add(15 * time.Minute)
+		expired := newTestSigner(&later)
+		assert.ErrorIs(t, expired.Verify(token, "schedule-1", "hash-1"), ErrExpired)
+	})
+}
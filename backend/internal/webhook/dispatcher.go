@@ -0,0 +1,393 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+var (
+	// ErrQueueFull は配信待ちのキューがいっぱいで受け付けられなかった場合に返される
+	ErrQueueFull = errors.New("webhook: delivery queue is full")
+	// ErrDispatcherClosed は停止したディスパッチャーに配信を依頼した場合に返される
+	ErrDispatcherClosed = errors.New("webhook: dispatcher is closed")
+)
+
+// Options はディスパッチャーの設定
+type Options struct {
+	// Workers は同時に配信するリクエストの数
+	Workers int
+	// QueueSize は配信待ちのキューの長さ（溢れた配信はデッドレターになる）
+	QueueSize int
+	// MaxAttempts は1件の配信を試みる最大回数（初回を含む）
+	MaxAttempts int
+	// InitialBackoff は最初の再試行までの待ち時間。以降は再試行のたびに倍になる
+	InitialBackoff time.Duration
+	// MaxBackoff は再試行までの待ち時間の上限
+	MaxBackoff time.Duration
+	// Timeout は1回の配信リクエストのタイムアウト
+	Timeout time.Duration
+	// MaxDeadLetters は保持するデッドレターの件数（古いものから捨てる）
+	MaxDeadLetters int
+	// AllowPrivateNetworks が true の場合、ループバックやプライベートアドレスにも配信する
+	// 誰でもURLを登録できるため、社内ネットワークへのリクエストに悪用されないよう通常は拒否する
+	AllowPrivateNetworks bool
+	// Logger はデッドレターなどの出力先（nilの場合はslogのデフォルト）
+	Logger *slog.Logger
+}
+
+// DefaultOptions はデフォルトの設定を返す
+func DefaultOptions() Options {
+	return Options{
+		Workers:        4,
+		QueueSize:      1000,
+		MaxAttempts:    6,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		Timeout:        10 * time.Second,
+		MaxDeadLetters: 1000,
+	}
+}
+
+// Delivery は1件の通知先への配信
+type Delivery struct {
+	// ID はイベントID（同じイベントを複数のURLに配信する場合は同じ値）
+	ID         string
+	URL        string
+	Event      model.ScheduleEvent
+	ScheduleID string
+	Body       []byte
+}
+
+// DeadLetter は配信をあきらめたイベント
+type DeadLetter struct {
+	Delivery
+	// Attempts は試みた回数（キューに入らなかった場合は0）
+	Attempts int
+	// LastStatus は最後の応答のステータスコード（応答がなかった場合は0）
+	LastStatus int
+	// LastError は最後の失敗の理由
+	LastError string
+	FailedAt  time.Time
+}
+
+type job struct {
+	delivery Delivery
+	secret   string
+	// attempt は何回目の配信か
+	attempt int
+}
+
+// retry は再試行を待っている配信
+type retry struct {
+	job   job
+	timer *time.Timer
+	// status と err は直前の失敗（再試行せずにデッドレターに移すときに記録する）
+	status int
+	err    error
+}
+
+// Dispatcher はキューに入った配信をバックグラウンドのワーカーで送信する
+// 失敗した配信は指数バックオフの後にキューへ戻して再試行し、再試行できない失敗や回数の上限に達したものはデッドレターに移す
+// 待ち時間はタイマーで数えるため、再試行を待っている配信がワーカーを占有することはない
+type Dispatcher struct {
+	opts   Options
+	client *http.Client
+	logger *slog.Logger
+	now    func() time.Time
+
+	queue chan job
+	// ctx は強制停止のときに送信中のリクエストを中断するために使う
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+	closed bool
+	// retries は再試行を待っている配信（muで保護する）
+	retries map[*retry]struct{}
+	wg      sync.WaitGroup
+
+	deadMu      sync.Mutex
+	deadLetters []DeadLetter
+	// deadLetterCount は起動してからのデッドレターの総数（保持する件数を超えて捨てたものも含む）
+	deadLetterCount atomic.Uint64
+}
+
+// NewDispatcher は新しいディスパッチャーを作成する（Startを呼ぶまで配信しない）
+func NewDispatcher(opts Options) *Dispatcher {
+	defaults := DefaultOptions()
+	if opts.Workers <= 0 {
+		opts.Workers = defaults.Workers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaults.QueueSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = defaults.InitialBackoff
+	}
+	if opts.MaxBackoff < opts.InitialBackoff {
+		opts.MaxBackoff = max(defaults.MaxBackoff, opts.InitialBackoff)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.MaxDeadLetters <= 0 {
+		opts.MaxDeadLetters = defaults.MaxDeadLetters
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		opts:    opts,
+		client:  newHTTPClient(opts.AllowPrivateNetworks),
+		logger:  logger,
+		now:     time.Now,
+		queue:   make(chan job, opts.QueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		retries: make(map[*retry]struct{}),
+	}
+}
+
+// Start はワーカーを起動する
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.opts.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// Enqueue は配信をキューに入れる
+// キューがいっぱいの場合は配信せずにデッドレターに移し、ErrQueueFullを返す
+func (d *Dispatcher) Enqueue(delivery Delivery, secret string) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+
+	select {
+	case d.queue <- job{delivery: delivery, secret: secret, attempt: 1}:
+		return nil
+	default:
+		d.deadLetter(delivery, 0, 0, ErrQueueFull)
+		return ErrQueueFull
+	}
+}
+
+// Shutdown は新しい配信の受け付けを止め、キューに残った配信を送り終えるまで待つ
+// 停止中は再試行を待たずにデッドレターに移す。ctxが終了した場合は送信中のリクエストを中断する
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for r := range d.retries {
+			r.timer.Stop()
+			d.deadLetter(r.job.delivery, r.job.attempt, r.status, notRetried(r.err, "the dispatcher is shutting down"))
+		}
+		clear(d.retries)
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// DeadLetters は配信をあきらめたイベントのうち、保持している直近のものを古い順に返す
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	return append([]DeadLetter(nil), d.deadLetters...)
+}
+
+// DeadLetterCount は起動してから配信をあきらめたイベントの数を返す
+func (d *Dispatcher) DeadLetterCount() uint64 {
+	return d.deadLetterCount.Load()
+}
+
+// PendingRetries は再試行を待っている配信の数を返す
+func (d *Dispatcher) PendingRetries() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.retries)
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for j := range d.queue {
+		d.deliver(j)
+	}
+}
+
+// deliver は配信を1回試み、失敗した場合は再試行を予約するかデッドレターに移す
+func (d *Dispatcher) deliver(j job) {
+	status, retryAfter, err := d.send(j)
+	if err == nil {
+		return
+	}
+	if !retryable(status, err) || j.attempt >= d.opts.MaxAttempts {
+		d.deadLetter(j.delivery, j.attempt, status, err)
+		return
+	}
+
+	wait := d.backoff(j.attempt)
+	if retryAfter > wait {
+		wait = min(retryAfter, d.opts.MaxBackoff)
+	}
+	d.scheduleRetry(&retry{job: j, status: status, err: err}, wait)
+}
+
+// scheduleRetry はwaitの後に配信をキューへ戻すタイマーを設定する
+// 停止が始まっている場合は再試行せずにデッドレターに移す
+func (d *Dispatcher) scheduleRetry(r *retry, wait time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		d.deadLetter(r.job.delivery, r.job.attempt, r.status, notRetried(r.err, "the dispatcher is shutting down"))
+		return
+	}
+	d.retries[r] = struct{}{}
+	r.timer = time.AfterFunc(wait, func() { d.requeue(r) })
+}
+
+// requeue は待ち時間が過ぎた配信を次の試行としてキューへ戻す
+// キューがいっぱいの場合は再試行せずにデッドレターに移す
+func (d *Dispatcher) requeue(r *retry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.retries[r]; !ok {
+		// 停止時にデッドレターに移し済み
+		return
+	}
+	delete(d.retries, r)
+
+	next := r.job
+	next.attempt++
+	select {
+	case d.queue <- next:
+	default:
+		d.deadLetter(r.job.delivery, r.job.attempt, r.status, notRetried(r.err, "the delivery queue is full"))
+	}
+}
+
+// notRetried は再試行しなかった理由を最後の失敗に添える
+func notRetried(err error, reason string) error {
+	return fmt.Errorf("%w (not retried because %s)", err, reason)
+}
+
+// send は1回分の配信リクエストを送る
+// 2xx以外の応答はエラーとし、429や503のRetry-Afterがあれば待ち時間として返す
+func (d *Dispatcher) send(j job) (status int, retryAfter time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(d.ctx, d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.delivery.URL, bytes.NewReader(j.delivery.Body))
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, string(j.delivery.Event))
+	req.Header.Set(DeliveryHeader, j.delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(j.secret, d.now(), j.delivery.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	// 接続を再利用できるよう、応答の本文は一定の長さまで読み捨てる
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, 0, nil
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return resp.StatusCode, retryAfter, fmt.Errorf("webhook: receiver responded with %d", resp.StatusCode)
+}
+
+// retryable は失敗した配信を再試行するかを判定する
+// 接続できなかった場合とサーバー側の一時的なエラーは再試行し、
+// 許可されていない接続先や受信側が拒否した応答（4xx、リダイレクト）は再試行しない
+func retryable(status int, err error) bool {
+	if status == 0 {
+		return !errors.Is(err, ErrBlockedAddress)
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// backoff はattempt回目の失敗の後に待つ時間を返す
+// 多数の配信が同じ時刻に再試行しないよう、倍々に増える待ち時間の後半にばらつきを持たせる
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.opts.InitialBackoff
+	for i := 1; i < attempt && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, d.opts.MaxBackoff)
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// deadLetter は配信をあきらめたイベントを記録する
+// URLのクエリに受信側のトークンが含まれることがあるため、ログにはホスト名だけを出力する
+func (d *Dispatcher) deadLetter(delivery Delivery, attempts, status int, err error) {
+	d.deadMu.Lock()
+	d.deadLetters = append(d.deadLetters, DeadLetter{
+		Delivery:   delivery,
+		Attempts:   attempts,
+		LastStatus: status,
+		LastError:  err.Error(),
+		FailedAt:   d.now(),
+	})
+	if over := len(d.deadLetters) - d.opts.MaxDeadLetters; over > 0 {
+		d.deadLetters = append([]DeadLetter(nil), d.deadLetters[over:]...)
+	}
+	d.deadMu.Unlock()
+	d.deadLetterCount.Add(1)
+
+	host := ""
+	if u, err := url.Parse(delivery.URL); err == nil {
+		host = u.Host
+	}
+	d.logger.Warn("webhook delivery failed",
+		slog.String("event", string(delivery.Event)),
+		slog.String("delivery_id", delivery.ID),
+		slog.String("schedule_id", delivery.ScheduleID),
+		slog.String("host", host),
+		slog.Int("attempts", attempts),
+		slog.Int("status", status),
+		slog.String("error", err.Error()),
+	)
+}
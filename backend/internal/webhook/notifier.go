@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// Notifier はスケジュールのイベントを、そのスケジュールに登録されたWebhookに配信する
+type Notifier struct {
+	dispatcher *Dispatcher
+	logger     *slog.Logger
+	now        func() time.Time
+}
+
+// NewNotifier はdispatcherで配信するNotifierを作成する
+func NewNotifier(dispatcher *Dispatcher) *Notifier {
+	return &Notifier{
+		dispatcher: dispatcher,
+		logger:     dispatcher.logger,
+		now:        time.Now,
+	}
+}
+
+// Notify はイベントを登録された全てのURLへの配信としてキューに入れる（配信は待たない）
+func (n *Notifier) Notify(ctx context.Context, event model.ScheduleEvent, schedule *model.Schedule) {
+	n.enqueue(ctx, event, schedule, func(id string) Payload {
+		return NewPayload(id, event, schedule, n.now())
+	})
+}
+
+// NotifyResponse は閲覧者の回答を含めた schedule.response_submitted をキューに入れる
+func (n *Notifier) NotifyResponse(ctx context.Context, schedule *model.Schedule, response *model.Response) {
+	n.enqueue(ctx, model.EventResponseSubmitted, schedule, func(id string) Payload {
+		return NewResponsePayload(id, schedule, response, n.now())
+	})
+}
+
+// enqueue はイベントIDを発行してpayloadで本文を作り、登録された全てのURLへの配信をキューに入れる
+func (n *Notifier) enqueue(ctx context.Context, event model.ScheduleEvent, schedule *model.Schedule, payload func(id string) Payload) {
+	if len(schedule.WebhookURLs) == 0 || schedule.WebhookSecret == "" {
+		return
+	}
+
+	id, err := model.GenerateUUID()
+	if err != nil {
+		n.logger.ErrorContext(ctx, "failed to generate webhook event id", slog.String("error", err.Error()))
+		return
+	}
+	body, err := json.Marshal(payload(id))
+	if err != nil {
+		n.logger.ErrorContext(ctx, "failed to encode webhook payload", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, u := range schedule.WebhookURLs {
+		delivery := Delivery{
+			ID:         id,
+			URL:        u,
+			Event:      event,
+			ScheduleID: schedule.ID,
+			Body:       body,
+		}
+		// キューが溢れた場合はディスパッチャーがデッドレターとして記録する
+		if err := n.dispatcher.Enqueue(delivery, schedule.WebhookSecret); errors.Is(err, ErrDispatcherClosed) {
+			n.logger.WarnContext(ctx, "webhook delivery was not queued",
+				slog.String("event", string(event)),
+				slog.String("schedule_id", schedule.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
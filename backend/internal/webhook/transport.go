@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress は配信先がプライベートなアドレスに解決された場合に返される
+var ErrBlockedAddress = errors.New("webhook: destination address is not allowed")
+
+// sharedAddressSpace はキャリアグレードNATのアドレス（RFC 6598）
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// newHTTPClient は配信用のHTTPクライアントを作成する
+// リダイレクトは追わず、受信側の応答として扱う
+func newHTTPClient(allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivateNetworks {
+		// 名前解決の結果を差し替えられても防げるよう、接続する直前のアドレスで判定する
+		dialer.Control = denyPrivateAddress
+		// プロキシを経由すると実際の接続先のアドレスを確認できない
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// denyPrivateAddress はインターネット上のユニキャストアドレス以外への接続を拒否する
+func denyPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
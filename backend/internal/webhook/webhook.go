@@ -0,0 +1,162 @@
+// Package webhook はスケジュールのライフサイクルイベントを、作成者が登録したURLへ署名付きのJSONで配信する
+//
+// 受信側は X-Kareru-Signature ヘッダーの t（UNIX時刻）と本文から "t.本文" のHMAC-SHA256を
+// スケジュールの署名鍵で計算し、v1 の値と比較して検証する（Verify を参照）
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"kareru-backend/internal/domain/model"
+)
+
+// 配信時に付けるヘッダー
+const (
+	// SignatureHeader は "t=<UNIX時刻>,v1=<HMAC-SHA256の16進数>" 形式の署名
+	SignatureHeader = "X-Kareru-Signature"
+	// EventHeader はイベントの種類（schedule.created など）
+	EventHeader = "X-Kareru-Event"
+	// DeliveryHeader はイベントID。再送されても変わらないため、受信側で重複を取り除くのに使う
+	DeliveryHeader = "X-Kareru-Delivery"
+)
+
+// userAgent は配信リクエストのUser-Agent
+const userAgent = "Kareru-Webhook/1.0"
+
+// ErrInvalidSignature は署名が一致しない、または古すぎる場合に返される
+var ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+// Payload は配信するイベントのJSON
+type Payload struct {
+	// ID はイベントID（DeliveryHeaderと同じ値）
+	ID        string              `json:"id"`
+	Type      model.ScheduleEvent `json:"type"`
+	CreatedAt time.Time           `json:"createdAt"`
+	// Schedule はイベント発生時点のスケジュール（削除された場合は削除直前の内容）
+	Schedule Schedule `json:"schedule"`
+	// Response は閲覧者の回答（schedule.response_submitted の場合だけ含める）
+	Response *Response `json:"response,omitempty"`
+}
+
+// Schedule はイベントに含めるスケジュールの内容（編集トークンや署名鍵は含めない）
+type Schedule struct {
+	ID                string     `json:"id"`
+	ShortCode         string     `json:"shortCode,omitempty"`
+	TimeSlots         []TimeSlot `json:"timeSlots"`
+	Comment           string     `json:"comment"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	ExpiresAt         time.Time  `json:"expiresAt"`
+	PasswordProtected bool       `json:"passwordProtected"`
+}
+
+// TimeSlot はイベントに含めるタイムスロット
+type TimeSlot struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Available bool      `json:"available"`
+}
+
+// Response はイベントに含める閲覧者の回答
+type Response struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	// TimeSlots は選ばれたタイムスロットの schedule.timeSlots での添字
+	TimeSlots   []int     `json:"timeSlots"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+// NewResponsePayload は閲覧者の回答から schedule.response_submitted のイベントを作成する
+func NewResponsePayload(id string, schedule *model.Schedule, response *model.Response, now time.Time) Payload {
+	payload := NewPayload(id, model.EventResponseSubmitted, schedule, now)
+	payload.Response = &Response{
+		Name:        response.Name,
+		Message:     response.Message,
+		TimeSlots:   response.TimeSlots,
+		SubmittedAt: response.SubmittedAt,
+	}
+	return payload
+}
+
+// NewPayload はスケジュールからイベントを作成する
+func NewPayload(id string, event model.ScheduleEvent, schedule *model.Schedule, now time.Time) Payload {
+	slots := make([]TimeSlot, len(schedule.TimeSlots))
+	for i, slot := range schedule.TimeSlots {
+		slots[i] = TimeSlot{
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Available: slot.Available,
+		}
+	}
+	return Payload{
+		ID:        id,
+		Type:      event,
+		CreatedAt: now.UTC(),
+		Schedule: Schedule{
+			ID:                schedule.ID,
+			ShortCode:         schedule.ShortCode,
+			TimeSlots:         slots,
+			Comment:           schedule.Comment,
+			CreatedAt:         schedule.CreatedAt,
+			UpdatedAt:         schedule.LastModified(),
+			ExpiresAt:         schedule.ExpiresAt,
+			PasswordProtected: schedule.HasViewPassword(),
+		},
+	}
+}
+
+// Sign は本文と送信時刻に対する SignatureHeader の値を作成する
+// 時刻を署名に含めることで、傍受した配信を後から再送されても受信側で拒否できる
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(signature(secret, t, body))
+}
+
+// Verify は SignatureHeader の値を検証する
+// 送信時刻がnowからtoleranceより離れている場合も ErrInvalidSignature を返す
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var t string
+	var candidates [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			// 署名鍵の切り替え中は複数のv1が送られることがある
+			if sig, err := hex.DecodeString(value); err == nil {
+				candidates = append(candidates, sig)
+			}
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(candidates) == 0 {
+		return ErrInvalidSignature
+	}
+	if diff := now.Sub(time.Unix(unix, 0)); diff > tolerance || diff < -tolerance {
+		return ErrInvalidSignature
+	}
+	expected := signature(secret, t, body)
+	for _, sig := range candidates {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// signature は "t.本文" のHMAC-SHA256を計算する
+func signature(secret, t string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,342 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kareru-backend/internal/domain/model"
+)
+
+const testSecret = "whsec_test"
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"event-1"}`)
+	header := Sign(testSecret, now, body)
+
+	assert.Regexp(t, `^t=\d+,v1=[0-9a-f]{64}$`, header)
+	assert.NoError(t, Verify(testSecret, header, body, now.Add(time.Minute), 5*time.Minute))
+
+	// 鍵の切り替え中に複数のv1が送られても、一致するものがあれば受け付ける
+	assert.NoError(t, Verify(testSecret, header+",v1=00", body, now, 5*time.Minute))
+
+	for name, err := range map[string]error{
+		"本文の改ざん":  Verify(testSecret, header, []byte(`{"id":"event-2"}`), now, 5*time.Minute),
+		"鍵が異なる":   Verify("whsec_other", header, body, now, 5*time.Minute),
+		"古すぎる":    Verify(testSecret, header, body, now.Add(10*time.Minute), 5*time.Minute),
+		"形式が不正":   Verify(testSecret, "sha256=abc", body, now, 5*time.Minute),
+		"署名がない":   Verify(testSecret, "t=1792411200", body, now, 5*time.Minute),
+		"時刻を書き換え": Verify(testSecret, "t=1792411201"+header[len("t=1792411200"):], body, now, 5*time.Minute),
+	} {
+		assert.ErrorIs(t, err, ErrInvalidSignature, name)
+	}
+}
+
+// receiver は配信を記録するテスト用の受信サーバー
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	// respond は受信した回数（1から）に対するステータスコードを返す
+	respond func(n int) int
+}
+
+func newReceiver(t *testing.T, respond func(n int) int) *receiver {
+	t.Helper()
+	r := &receiver{respond: respond}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		n := len(r.requests)
+		r.mu.Unlock()
+		w.WriteHeader(r.respond(n))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// newTestDispatcher はテスト用に待ち時間を短くしたディスパッチャーを作成する
+func newTestDispatcher(t *testing.T, opts Options) *Dispatcher {
+	t.Helper()
+	opts.AllowPrivateNetworks = true
+	opts.InitialBackoff = time.Millisecond
+	opts.MaxBackoff = 4 * time.Millisecond
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := NewDispatcher(opts)
+	t.Cleanup(func() { d.Shutdown(context.Background()) })
+	return d
+}
+
+func testDelivery(url string) Delivery {
+	return Delivery{
+		ID:         "event-1",
+		URL:        url,
+		Event:      model.EventScheduleUpdated,
+		ScheduleID: "schedule-1",
+		Body:       []byte(`{"id":"event-1"}`),
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("署名とイベントのヘッダーを付けて配信する", func(t *testing.T) {
+		r := newReceiver(t, func(int) int { return http.StatusNoContent })
+		d := newTestDispatcher(t, Options{})
+		d.Start()
+
+		require.NoError(t, d.Enqueue(testDelivery(r.URL), testSecret))
+		require.NoError(t, d.Shutdown(context.Background()))
+
+		require.Equal(t, 1, r.count())
+		req := r.requests[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "schedule.updated", req.Header.Get(EventHeader))
+		assert.Equal(t, "event-1", req.Header.Get(DeliveryHeader))
+		assert.NoError(t, Verify(testSecret, req.Header.Get(SignatureHeader), r.bodies[0], time.Now(), time.Minute))
+		assert.Empty(t, d.DeadLetters())
+	})
+
+	t.Run("5xxと429は指数バックオフで再試行する", func(t *testing.T) {
+		r := newReceiver(t, func(n int) int {
+			switch n {
+			case 1:
+				return http.StatusServiceUnavailable
+			case 2:
+				return http.StatusTooManyRequests
+			}
+			return http.StatusOK
+		})
+		d := newTestDispatcher(t, Options{MaxAttempts: 3})
+		d.Start()
+
+		require.NoError(t, d.Enqueue(testDelivery(r.URL), testSecret))
+		require.Eventually(t, func() bool { return r.count() == 3 }, time.Second, time.Millisecond)
+		require.NoError(t, d.Shutdown(context.Background()))
+
+		assert.Empty(t, d.DeadLetters())
+		// 再送でもイベントIDは変わらない
+		assert.Equal(t, r.requests[0].Header.Get(DeliveryHeader), r.requests[2].Header.Get(DeliveryHeader))
+	})
+
+	t.Run("回数の上限に達した配信はデッドレターになる", func(t *testing.T) {
+		r := newReceiver(t, func(int) int { return http.StatusInternalServerError })
+		d := newTestDispatcher(t, Options{MaxAttempts: 3})
+		d.Start()
+
+		require.NoError(t, d.Enqueue(testDelivery(r.URL), testSecret))
+		require.Eventually(t, func() bool { return len(d.DeadLetters()) == 1 }, time.Second, time.Millisecond)
+
+		dead := d.DeadLetters()[0]
+		assert.Equal(t, 3, r.count())
+		assert.Equal(t, 3, dead.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, dead.LastStatus)
+		assert.Equal(t, "event-1", dead.ID)
+		assert.Equal(t, "schedule-1", dead.ScheduleID)
+	})
+
+	t.Run("4xxとリダイレクトは再試行しない", func(t *testing.T) {
+		for _, status := range []int{http.StatusBadRequest, http.StatusGone, http.StatusFound} {
+			r := newReceiver(t, func(int) int { return status })
+			d := newTestDispatcher(t, Options{MaxAttempts: 5})
+			d.Start()
+
+			require.NoError(t, d.Enqueue(testDelivery(r.URL), testSecret))
+			require.NoError(t, d.Shutdown(context.Background()))
+
+			assert.Equal(t, 1, r.count(), status)
+			require.Len(t, d.DeadLetters(), 1, status)
+			assert.Equal(t, status, d.DeadLetters()[0].LastStatus)
+		}
+	})
+
+	t.Run("プライベートアドレスへの配信は拒否する", func(t *testing.T) {
+		r := newReceiver(t, func(int) int { return http.StatusOK })
+		d := NewDispatcher(Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+		d.Start()
+
+		require.NoError(t, d.Enqueue(testDelivery(r.URL), testSecret))
+		require.NoError(t, d.Shutdown(context.Background()))
+
+		assert.Zero(t, r.count())
+		require.Len(t, d.DeadLetters(), 1)
+		assert.Equal(t, 1, d.DeadLetters()[0].Attempts)
+		assert.Contains(t, d.DeadLetters()[0].LastError, "not allowed")
+	})
+
+	t.Run("キューが溢れた配信と停止後の配信", func(t *testing.T) {
+		d := newTestDispatcher(t, Options{QueueSize: 1})
+
+		require.NoError(t, d.Enqueue(testDelivery("http://127.0.0.1:1/"), testSecret))
+		assert.ErrorIs(t, d.Enqueue(testDelivery("http://127.0.0.1:1/"), testSecret), ErrQueueFull)
+		require.Len(t, d.DeadLetters(), 1)
+		assert.Zero(t, d.DeadLetters()[0].Attempts)
+
+		d.Start()
+		require.NoError(t, d.Shutdown(context.Background()))
+		assert.ErrorIs(t, d.Enqueue(testDelivery("http://127.0.0.1:1/"), testSecret), ErrDispatcherClosed)
+	})
+
+	t.Run("停止中は再試行を待たずにデッドレターに移す", func(t *testing.T) {
+		r := newReceiver(t, func(int) int { return http.StatusBadGateway })
+		d := newTestDispatcher(t, Options{MaxAttempts: 10})
+		d.opts.InitialBackoff, d.opts.MaxBackoff = time.Hour, time.Hour
+		d.Start()
+
+		require.NoError(t, d.Enqueue(testDelivery(r.URL), testSecret))
+		require.Eventually(t, func() bool { return r.count() == 1 }, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, d.Shutdown(ctx))
+		require.Len(t, d.DeadLetters(), 1)
+		assert.Contains(t, d.DeadLetters()[0].LastError, "shutting down")
+	})
+
+	t.Run("再試行を待っている間もワーカーは他の配信を送る", func(t *testing.T) {
+		failing := newReceiver(t, func(int) int { return http.StatusServiceUnavailable })
+		ok := newReceiver(t, func(int) int { return http.StatusOK })
+		d := newTestDispatcher(t, Options{Workers: 1, MaxAttempts: 10})
+		d.opts.InitialBackoff, d.opts.MaxBackoff = time.Hour, time.Hour
+		d.Start()
+
+		require.NoError(t, d.Enqueue(testDelivery(failing.URL), testSecret))
+		require.Eventually(t, func() bool { return failing.count() == 1 }, time.Second, time.Millisecond)
+		require.NoError(t, d.Enqueue(testDelivery(ok.URL), testSecret))
+		require.Eventually(t, func() bool { return ok.count() == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, 1, d.PendingRetries())
+
+		require.NoError(t, d.Shutdown(context.Background()))
+		assert.Zero(t, d.PendingRetries())
+		assert.Equal(t, uint64(1), d.DeadLetterCount())
+		require.Len(t, d.DeadLetters(), 1)
+		assert.Equal(t, failing.URL, d.DeadLetters()[0].URL)
+		assert.Equal(t, 1, d.DeadLetters()[0].Attempts)
+	})
+
+	t.Run("デッドレターは上限を超えると古いものから捨てる", func(t *testing.T) {
+		d := newTestDispatcher(t, Options{QueueSize: 1, MaxDeadLetters: 2})
+		d.Enqueue(testDelivery("http://127.0.0.1:1/"), testSecret)
+		for _, id := range []string{"a", "b", "c"} {
+			delivery := testDelivery("http://127.0.0.1:1/")
+			delivery.ID = id
+			d.Enqueue(delivery, testSecret)
+		}
+
+		dead := d.DeadLetters()
+		require.Len(t, dead, 2)
+		assert.Equal(t, "b", dead[0].ID)
+		assert.Equal(t, "c", dead[1].ID)
+		assert.Equal(t, uint64(3), d.DeadLetterCount())
+	})
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	d := NewDispatcher(Options{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 10: 10 * time.Second} {
+		for i := 0; i < 20; i++ {
+			wait := d.backoff(attempt)
+			assert.GreaterOrEqual(t, wait, want/2, attempt)
+			assert.LessOrEqual(t, wait, want, attempt)
+		}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	var received atomic.Int32
+	var payload Payload
+	var signature string
+	var body []byte
+	r := newReceiver(t, func(int) int { return http.StatusOK })
+	r.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ = io.ReadAll(req.Body)
+		signature = req.Header.Get(SignatureHeader)
+		json.NewDecoder(bytes.NewReader(body)).Decode(&payload)
+		received.Add(1)
+	})
+
+	d := newTestDispatcher(t, Options{})
+	d.Start()
+	notifier := NewNotifier(d)
+
+	now := time.Now()
+	schedule := &model.Schedule{
+		ID:        "schedule-1",
+		EditToken: "edit-token",
+		Comment:   "定例の候補",
+		TimeSlots: []model.TimeSlot{{StartTime: now, EndTime: now.Add(time.Hour)}},
+		CreatedAt: now,
+		ExpiresAt: now.Add(24 * time.Hour),
+	}
+	// Webhookが登録されていないスケジュールは何もしない
+	notifier.Notify(context.Background(), model.EventScheduleCreated, schedule)
+
+	require.NoError(t, schedule.SetWebhookURLs([]string{r.URL}))
+	notifier.Notify(context.Background(), model.EventScheduleCreated, schedule)
+	require.NoError(t, d.Shutdown(context.Background()))
+
+	require.Equal(t, int32(1), received.Load())
+	assert.NoError(t, Verify(schedule.WebhookSecret, signature, body, time.Now(), time.Minute))
+	assert.Equal(t, model.EventScheduleCreated, payload.Type)
+	assert.NotEmpty(t, payload.ID)
+	assert.Equal(t, "schedule-1", payload.Schedule.ID)
+	assert.Equal(t, "定例の候補", payload.Schedule.Comment)
+	require.Len(t, payload.Schedule.TimeSlots, 1)
+	assert.NotContains(t, string(body), "edit-token")
+	assert.NotContains(t, string(body), schedule.WebhookSecret)
+}
+
+func TestNotifier_response(t *testing.T) {
+	var payload Payload
+	var event string
+	r := newReceiver(t, func(int) int { return http.StatusOK })
+	r.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		event = req.Header.Get(EventHeader)
+		json.NewDecoder(req.Body).Decode(&payload)
+	})
+
+	d := newTestDispatcher(t, Options{})
+	d.Start()
+	notifier := NewNotifier(d)
+
+	now := time.Now()
+	schedule := &model.Schedule{
+		ID:        "schedule-1",
+		TimeSlots: []model.TimeSlot{{StartTime: now, EndTime: now.Add(time.Hour)}},
+		CreatedAt: now,
+		ExpiresAt: now.Add(24 * time.Hour),
+	}
+	require.NoError(t, schedule.SetWebhookURLs([]string{r.URL}))
+	response, err := schedule.NewResponse("山田", "よろしくお願いします", []int{0}, now)
+	require.NoError(t, err)
+	notifier.NotifyResponse(context.Background(), schedule, response)
+	require.NoError(t, d.Shutdown(context.Background()))
+
+	assert.Equal(t, string(model.EventResponseSubmitted), event)
+	assert.Equal(t, model.EventResponseSubmitted, payload.Type)
+	assert.Equal(t, "schedule-1", payload.Schedule.ID)
+	require.NotNil(t, payload.Response)
+	assert.Equal(t, "山田", payload.Response.Name)
+	assert.Equal(t, "よろしくお願いします", payload.Response.Message)
+	assert.Equal(t, []int{0}, payload.Response.TimeSlots)
+	assert.Equal(t, response.SubmittedAt, payload.Response.SubmittedAt)
+}